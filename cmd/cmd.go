@@ -1,27 +1,49 @@
 package cmd
 
 import (
+	"crypto/tls"
 	"fmt"
+	"net"
+	"path/filepath"
 
 	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/certs"
+	"github.com/vimek-go/server-faker/internal/pkg/grpcmock"
 	"github.com/vimek-go/server-faker/internal/pkg/plugins"
 	"github.com/vimek-go/server-faker/internal/pkg/transformer"
 
 	"github.com/vimek-go/server-faker/internal/pkg/logger"
 	"github.com/vimek-go/server-faker/internal/pkg/parser"
+	"github.com/vimek-go/server-faker/internal/pkg/parser/dto"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
 var (
-	filePath     string
-	serverPort   int
-	url          string
-	responseType string
+	filePath      string
+	dirPath       string
+	profile       string
+	serverPort    int
+	grpcPort      int
+	url           string
+	responseType  string
+	tlsCert       string
+	tlsKey        string
+	tlsSelfSign   bool
+	tlsDir        string
+	tlsHosts      []string
+	tlsClientCA   string
+	tlsClientAuth string
+	h2cEnabled    bool
 )
 
-const defaultPort = 8080
+const (
+	defaultPort     = 8080
+	defaultGRPCPort = 9090
+	defaultTLSDir   = "certs"
+)
 
 var rootCmd = &cobra.Command{
 	Use:   "server-faker",
@@ -30,9 +52,11 @@ var rootCmd = &cobra.Command{
 Use run to start the server. 
 Use parser to prepare the json file.
 
-The default port is 8080.
+The default port is 8080, gRPC services are served on 9090.
 Examlpe:
-server-faker run --file=../test-api.json --port=8080`,
+server-faker run --file=../test-api.json --port=8080
+server-faker run --dir=../test-api --port=8080
+server-faker run --file=../test-api.json --tls-self-signed --tls-client-auth=require`,
 }
 
 var serverCmd = &cobra.Command{
@@ -62,16 +86,30 @@ func Execute() error {
 }
 
 func PrepareCommand() error {
-	serverCmd.Flags().StringVarP(&filePath, "file", "f", "", "[required] The file path to the json file")
-	err := serverCmd.MarkFlagRequired("file")
-	if err != nil {
-		fmt.Println("error marking flag required")
-		return err
-	}
+	serverCmd.Flags().StringVarP(&filePath, "file", "f", "", "The file path to the json file")
+	serverCmd.Flags().StringVarP(&dirPath, "dir", "d", "", "The directory with json files to load")
+	serverCmd.MarkFlagsOneRequired("file", "dir")
+	serverCmd.MarkFlagsMutuallyExclusive("file", "dir")
+	serverCmd.Flags().StringVar(&profile, "profile", "", "The profile overlaying the config variables")
 	serverCmd.PersistentFlags().IntVarP(&serverPort, "port", "p", defaultPort, "The port to run the server on")
+	serverCmd.PersistentFlags().IntVar(&grpcPort, "grpc-port", defaultGRPCPort, "The port to run the grpc server on")
+	serverCmd.Flags().StringVar(&tlsCert, "tls-cert", "", "The certificate file serving https")
+	serverCmd.Flags().StringVar(&tlsKey, "tls-key", "", "The key file of the certificate")
+	serverCmd.MarkFlagsRequiredTogether("tls-cert", "tls-key")
+	serverCmd.Flags().BoolVar(&tlsSelfSign, "tls-self-signed", false,
+		"Serve https with a generated CA and certificate written to the tls-dir")
+	serverCmd.MarkFlagsMutuallyExclusive("tls-cert", "tls-self-signed")
+	serverCmd.Flags().StringVar(&tlsDir, "tls-dir", defaultTLSDir, "The directory the generated certificates are written to")
+	serverCmd.Flags().StringSliceVar(&tlsHosts, "tls-hosts", []string{"localhost", "127.0.0.1", "::1"},
+		"The hosts of the generated certificate")
+	serverCmd.Flags().StringVar(&tlsClientCA, "tls-client-ca", "",
+		"The CA file verifying the client certificates, the generated CA is used with tls-self-signed")
+	serverCmd.Flags().StringVar(&tlsClientAuth, "tls-client-auth", "",
+		"The client certificate verification: none, request or require, require when tls-client-ca is set")
+	serverCmd.Flags().BoolVar(&h2cEnabled, "h2c", false, "Serve cleartext HTTP/2 (h2c) next to HTTP/1.1")
 
 	parserCmd.Flags().StringVarP(&filePath, "file", "f", "", "[required] The file path to the json file")
-	err = parserCmd.MarkFlagRequired("file")
+	err := parserCmd.MarkFlagRequired("file")
 	if err != nil {
 		fmt.Println("error marking flag required")
 		return err
@@ -92,19 +130,132 @@ func startServer() {
 	}
 	pluginLoader := plugins.NewPlugingLoader(logger)
 	factory := parser.NewFactory(pluginLoader, logger)
-	parser := parser.NewLoader(factory, logger)
-	handlers, err := parser.LoadConfig(filePath)
+	configLoader := parser.NewLoader(factory, profile, logger)
+	var config *parser.Config
+	if dirPath != "" {
+		config, err = configLoader.LoadDir(dirPath)
+	} else {
+		config, err = configLoader.LoadConfig(filePath)
+	}
 	if err != nil {
-		fmt.Println("error creating a logger")
+		fmt.Printf("error loading config %v\n", err)
 		return
 	}
 
-	e := gin.New()
-	api := api.NewBaseAPI(e, logger)
-	api.AddEndpoints(handlers)
+	if len(config.Services) > 0 {
+		grpcServer, err := grpcmock.NewServer(config.Services, logger)
+		if err != nil {
+			fmt.Printf("error creating grpc server %v\n", err)
+			return
+		}
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", grpcPort))
+		if err != nil {
+			fmt.Printf("error listening on grpc port %v\n", err)
+			return
+		}
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				fmt.Printf("error running grpc server %v\n", err)
+			}
+		}()
+		defer grpcServer.Stop()
+	}
 
-	if err := api.Run(fmt.Sprintf(":%d", serverPort)); err != nil {
-		fmt.Printf("error runnig server %v\n", err)
-		return
+	defaultTLS := &dto.ListenerTLS{
+		Cert:       tlsCert,
+		Key:        tlsKey,
+		SelfSigned: tlsSelfSign,
+		Dir:        tlsDir,
+		Hosts:      tlsHosts,
+		ClientCA:   tlsClientCA,
+		ClientAuth: tlsClientAuth,
+	}
+	listeners := append([]parser.Listener{{
+		Listener: dto.Listener{Name: parser.DefaultListener, Port: serverPort, TLS: defaultTLS, H2C: h2cEnabled},
+		Handlers: config.Handlers,
+	}}, config.Listeners...)
+	servers := make([]func() error, len(listeners))
+	for i := range listeners {
+		if servers[i], err = prepareServer(listeners[i], config.CatchAll, logger); err != nil {
+			fmt.Printf("error preparing listener %s %v\n", listeners[i].Name, err)
+			return
+		}
+	}
+	errs := make(chan error, len(servers))
+	for i := range servers {
+		listener, serve := listeners[i], servers[i]
+		go func() {
+			errs <- errors.Wrapf(serve(), "listener %s on port %d", listener.Name, listener.Port)
+		}()
+	}
+	fmt.Printf("error runnig server %v\n", <-errs)
+}
+
+// prepareServer returns the function serving the handlers of the listener,
+// the routes which are not mocked are served by the catch all when it is set
+func prepareServer(listener parser.Listener, catchAll api.Handler, logger logger.Logger) (func() error, error) {
+	tlsConfig, err := prepareTLSConfig(listener, logger)
+	if err != nil {
+		return nil, err
+	}
+	engine := gin.New()
+	engine.UseH2C = listener.H2C
+	api := api.NewBaseAPI(engine, logger)
+	api.AddEndpoints(listener.Handlers)
+	if catchAll != nil {
+		api.AddCatchAll(catchAll)
+	}
+	address := fmt.Sprintf(":%d", listener.Port)
+	if tlsConfig != nil {
+		return func() error { return api.RunTLS(address, tlsConfig) }, nil
+	}
+	return func() error { return api.Run(address) }, nil
+}
+
+// prepareTLSConfig returns nil when the listener runs on plain http
+func prepareTLSConfig(listener parser.Listener, logger logger.Logger) (*tls.Config, error) {
+	if listener.TLS == nil {
+		return nil, nil
+	}
+	listenerTLS := listener.TLS
+	options := certs.Options{
+		CertFile:     listenerTLS.Cert,
+		KeyFile:      listenerTLS.Key,
+		ClientCAFile: listenerTLS.ClientCA,
+		ClientAuth:   listenerTLS.ClientAuth,
+	}
+	if listenerTLS.SelfSigned {
+		dir, hosts := listenerTLS.Dir, listenerTLS.Hosts
+		if len(dir) == 0 {
+			dir = filepath.Join(tlsDir, listener.Name)
+		}
+		if len(hosts) == 0 {
+			hosts = tlsHosts
+		}
+		files, err := certs.GenerateSelfSigned(dir, hosts)
+		if err != nil {
+			return nil, err
+		}
+		logger.Infof(
+			"listener %s generated CA %s, client certificate %s key %s",
+			listener.Name,
+			files.CA,
+			files.ClientCert,
+			files.ClientKey,
+		)
+		options.CertFile, options.KeyFile = files.Cert, files.Key
+		if len(options.ClientCAFile) == 0 {
+			options.ClientCAFile = files.CA
+			if len(options.ClientAuth) == 0 {
+				options.ClientAuth = certs.ClientAuthNone
+			}
+		}
+	}
+	if len(options.CertFile) == 0 {
+		if len(options.ClientCAFile) > 0 || len(options.ClientAuth) > 0 {
+			return nil, errors.Wrap(certs.ErrLoadingCertificate, "client verification requires the certificate or self signed")
+		}
+		return nil, nil
 	}
+	return certs.NewTLSConfig(options)
 }
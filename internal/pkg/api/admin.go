@@ -0,0 +1,89 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// AdminPrefix is the url prefix of the admin API controlling the state of the server
+const AdminPrefix = "/__admin"
+
+type adminHandler struct {
+	method  string
+	url     string
+	respond gin.HandlerFunc
+}
+
+func (ah *adminHandler) Method() string {
+	return ah.method
+}
+
+func (ah *adminHandler) URL() string {
+	return ah.url
+}
+
+func (ah *adminHandler) Respond(c *gin.Context) {
+	ah.respond(c)
+}
+
+// scenarioState is the body setting the state of the scenario
+type scenarioState struct {
+	State string `json:"state" binding:"required"`
+	Key   string `json:"key"`
+}
+
+// NewScenarioAdmin lists the states of the scenarios, sets and resets them
+func NewScenarioAdmin(scenarios Scenarios, logger logger.Logger) []Handler {
+	url := AdminPrefix + "/scenarios"
+	reset := func(c *gin.Context) {
+		if err := scenarios.Reset(c.Param("name")); err != nil {
+			respondWithAdminError(c, err)
+			return
+		}
+		logger.Infof("scenario %s reset", c.Param("name"))
+		c.Status(http.StatusNoContent)
+	}
+	return []Handler{
+		&adminHandler{method: http.MethodGet, url: url, respond: func(c *gin.Context) {
+			c.JSON(http.StatusOK, scenarios.States())
+		}},
+		&adminHandler{method: http.MethodDelete, url: url, respond: reset},
+		&adminHandler{method: http.MethodDelete, url: url + "/:name", respond: reset},
+		&adminHandler{method: http.MethodPut, url: url + "/:name", respond: func(c *gin.Context) {
+			var body scenarioState
+			if err := c.ShouldBindJSON(&body); err != nil {
+				returnErrors(c, http.StatusBadRequest, c.Request.URL.EscapedPath(), adminTitle, err)
+				return
+			}
+			if err := scenarios.Set(c.Param("name"), body.Key, body.State); err != nil {
+				respondWithAdminError(c, err)
+				return
+			}
+			logger.Infof("scenario %s [%s] set to %s", c.Param("name"), body.Key, body.State)
+			c.Status(http.StatusNoContent)
+		}},
+	}
+}
+
+// NewSequenceAdmin starts all the sequences over
+func NewSequenceAdmin(sequences Sequences, logger logger.Logger) []Handler {
+	return []Handler{
+		&adminHandler{method: http.MethodDelete, url: AdminPrefix + "/sequences", respond: func(c *gin.Context) {
+			sequences.Reset()
+			logger.Info("sequences reset")
+			c.Status(http.StatusNoContent)
+		}},
+	}
+}
+
+func respondWithAdminError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, ErrUnknownScenario) {
+		status = http.StatusNotFound
+	}
+	returnErrors(c, status, c.Request.URL.EscapedPath(), adminTitle, err)
+}
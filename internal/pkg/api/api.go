@@ -1,52 +1,145 @@
 package api
 
 import (
+	"crypto/tls"
+	"net"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/vimek-go/server-faker/internal/pkg/logger"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 var ErrWrongPositionOfWildcard = errors.New("wildcard should be at the end of the url")
 
+const readHeaderTimeout = 10 * time.Second
+
 type API interface {
 	Run(addr ...string) (err error)
+	RunTLS(addr string, config *tls.Config) (err error)
 	Engine() *gin.Engine
 	AddRouters(handlers ...Handler)
 }
 
 type BaseAPI struct {
-	e      *gin.Engine
-	logger logger.Logger
+	e *gin.Engine
+	// hosts are the engines of the virtual hosts, the requests to other hosts are served by e
+	hosts map[string]*gin.Engine
+	// routes registered in the engines, the host handlers take precedence over the handlers of any host
+	routes map[*gin.Engine]map[string]bool
+	// anyHost handlers are added to the engines of the hosts created later
+	anyHost []Handler
+	// catchAll serves the requests to the routes of no handler
+	catchAll Handler
+	logger   logger.Logger
 }
 
 func NewBaseAPI(e *gin.Engine, logger logger.Logger) *BaseAPI {
+	useMiddlewares(e, logger)
+	be := &BaseAPI{
+		e:      e,
+		hosts:  make(map[string]*gin.Engine),
+		routes: make(map[*gin.Engine]map[string]bool),
+		logger: logger,
+	}
+	return be
+}
+
+func useMiddlewares(e *gin.Engine, logger logger.Logger) {
 	e.Use(GinStandardLoggerMiddleware())
 	e.Use(GinPayloadLoggerMiddleware(logger))
 	e.Use(GinResponseLogMiddleware(logger))
-	be := &BaseAPI{e: e, logger: logger}
-	return be
 }
 
 func (a *BaseAPI) Run(addr ...string) (err error) {
-	return a.e.Run(addr...)
+	if len(a.hosts) == 0 {
+		return a.e.Run(addr...)
+	}
+	server := &http.Server{Handler: a.Handler(), ReadHeaderTimeout: readHeaderTimeout}
+	if len(addr) > 0 {
+		server.Addr = addr[0]
+	}
+	return server.ListenAndServe()
+}
+
+// RunTLS serves https with the certificates of the config, the client certificates
+// are verified when the config requires them, the clients negotiate HTTP/2 or HTTP/1.1 with ALPN
+func (a *BaseAPI) RunTLS(addr string, config *tls.Config) (err error) {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           a.Handler(),
+		TLSConfig:         config,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	if err := http2.ConfigureServer(server, &http2.Server{}); err != nil {
+		return errors.Wrap(err, "configuring http2")
+	}
+	return server.ListenAndServeTLS("", "")
 }
 
+// Engine serves the requests to any host
 func (a *BaseAPI) Engine() *gin.Engine {
 	return a.e
 }
 
+// Handler routes the requests to the engines by the Host header,
+// cleartext HTTP/2 (h2c) is served when the engine has UseH2C set
+func (a *BaseAPI) Handler() http.Handler {
+	if len(a.hosts) == 0 {
+		return a.e.Handler()
+	}
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if engine, ok := a.hosts[hostName(r.Host)]; ok {
+			engine.ServeHTTP(w, r)
+			return
+		}
+		a.e.ServeHTTP(w, r)
+	})
+	if a.e.UseH2C {
+		handler = h2c.NewHandler(handler, &http2.Server{})
+	}
+	return handler
+}
+
+// AddEndpoints adds the handlers of the virtual hosts first,
+// so they take precedence over the handlers of any host with the same route
 func (a *BaseAPI) AddEndpoints(handlers []Handler) {
 	a.logger.Infof("adding handlers %d", len(handlers))
 	for _, h := range handlers {
-		a.AddRoute(h)
+		if _, ok := h.(HostHandler); ok {
+			a.AddRoute(h)
+		}
+	}
+	for _, h := range handlers {
+		if _, ok := h.(HostHandler); !ok {
+			a.AddRoute(h)
+		}
 	}
 }
 
+// AddRoute adds the handler to the engines of its hosts,
+// handlers of any host are added to all the engines
 func (a *BaseAPI) AddRoute(h Handler) {
+	hostHandler, ok := h.(HostHandler)
+	if !ok {
+		a.anyHost = append(a.anyHost, h)
+		a.addRoute(a.e, h)
+		for _, engine := range a.hosts {
+			a.addRoute(engine, h)
+		}
+		return
+	}
+	for _, host := range hostHandler.Hosts() {
+		a.addRoute(a.hostEngine(host), h)
+	}
+}
+
+func (a *BaseAPI) addRoute(engine *gin.Engine, h Handler) {
 	handlerFunc := a.decorateHandlerFunc(h)
 	url := h.URL()
 	var err error
@@ -57,19 +150,63 @@ func (a *BaseAPI) AddRoute(h Handler) {
 			return
 		}
 	}
+	key := h.Method() + " " + url
+	if a.routes[engine][key] {
+		a.logger.Debugf("endpoint %s already served by the host handler", key)
+		return
+	}
+	if a.routes[engine] == nil {
+		a.routes[engine] = make(map[string]bool)
+	}
+	a.routes[engine][key] = true
 	a.logger.Infof("Adding new endpoint %s: url: %s\n", h.Method(), h.URL())
 	switch h.Method() {
 	case http.MethodGet:
-		a.e.GET(url, handlerFunc)
+		engine.GET(url, handlerFunc)
 	case http.MethodPost:
-		a.e.POST(url, handlerFunc)
+		engine.POST(url, handlerFunc)
 	case http.MethodPut:
-		a.e.PUT(url, handlerFunc)
+		engine.PUT(url, handlerFunc)
 	case http.MethodDelete:
-		a.e.DELETE(url, handlerFunc)
+		engine.DELETE(url, handlerFunc)
 	case http.MethodPatch:
-		a.e.PATCH(url, handlerFunc)
+		engine.PATCH(url, handlerFunc)
+	}
+}
+
+// AddCatchAll serves the requests to the routes of no handler with the handler, like the pass through proxy
+func (a *BaseAPI) AddCatchAll(h Handler) {
+	a.catchAll = h
+	a.e.NoRoute(a.decorateHandlerFunc(h))
+	for _, engine := range a.hosts {
+		engine.NoRoute(a.decorateHandlerFunc(h))
+	}
+}
+
+// hostEngine creates the engine of the host with the handlers of any host added so far
+func (a *BaseAPI) hostEngine(host string) *gin.Engine {
+	host = hostName(host)
+	if engine, ok := a.hosts[host]; ok {
+		return engine
+	}
+	engine := gin.New()
+	useMiddlewares(engine, a.logger)
+	a.hosts[host] = engine
+	for _, h := range a.anyHost {
+		a.addRoute(engine, h)
+	}
+	if a.catchAll != nil {
+		engine.NoRoute(a.decorateHandlerFunc(a.catchAll))
+	}
+	return engine
+}
+
+// hostName strips the port and lowers the case of the Host header
+func hostName(host string) string {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
 	}
+	return strings.ToLower(host)
 }
 
 func (a *BaseAPI) decorateHandlerFunc(h Handler) gin.HandlerFunc {
@@ -1,6 +1,10 @@
 package api_test
 
 import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -12,6 +16,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
 )
 
 func TestNewBaseAPI(t *testing.T) {
@@ -74,3 +79,182 @@ func TestNewBaseAPI(t *testing.T) {
 		})
 	}
 }
+
+func TestBaseAPI_Hosts(t *testing.T) {
+	t.Parallel()
+	handler := func(url, body string) api.Handler {
+		hm := mocks.NewHandlerMock(t)
+		hm.On("URL").Return(url)
+		hm.On("Method").Return(http.MethodGet)
+		hm.On("Respond", mock.AnythingOfType("*gin.Context")).Run(func(args mock.Arguments) {
+			args.Get(0).(*gin.Context).String(http.StatusOK, body)
+		}).Return(nil).Maybe()
+		return hm
+	}
+	ba := api.NewBaseAPI(gin.New(), logger.NewTestLogger())
+	ba.AddEndpoints([]api.Handler{
+		handler("/who", "any"),
+		handler("/health", "ok"),
+		api.NewHostHandler(handler("/who", "api"), []string{"api.example.test"}),
+		api.NewHostHandler(handler("/who", "auth"), []string{"Auth.example.test"}),
+		api.NewHostHandler(handler("/login", "login"), []string{"auth.example.test"}),
+	})
+
+	testCases := []struct {
+		name         string
+		host         string
+		url          string
+		expectedCode int
+		expectedBody string
+	}{
+		{name: "any host", host: "localhost:8080", url: "/who", expectedCode: http.StatusOK, expectedBody: "any"},
+		{name: "virtual host", host: "api.example.test", url: "/who", expectedCode: http.StatusOK, expectedBody: "api"},
+		{
+			name:         "virtual host with port and case",
+			host:         "AUTH.example.test:8080",
+			url:          "/who",
+			expectedCode: http.StatusOK,
+			expectedBody: "auth",
+		},
+		{name: "any host endpoint", host: "api.example.test", url: "/health", expectedCode: http.StatusOK, expectedBody: "ok"},
+		{name: "endpoint of other host", host: "api.example.test", url: "/login", expectedCode: http.StatusNotFound},
+		{name: "host endpoint not served for any host", host: "localhost", url: "/login", expectedCode: http.StatusNotFound},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			req.Host = tc.host
+			ba.Handler().ServeHTTP(rr, req)
+			require.Equal(t, tc.expectedCode, rr.Code)
+			if len(tc.expectedBody) > 0 {
+				require.Equal(t, tc.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestBaseAPI_H2C(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name  string
+		hosts []string
+	}{
+		{name: "h2c on the engine"},
+		{name: "h2c with virtual hosts", hosts: []string{"api.example.test"}},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			hm := mocks.NewHandlerMock(t)
+			hm.On("URL").Return("/proto")
+			hm.On("Method").Return(http.MethodGet)
+			hm.On("Respond", mock.AnythingOfType("*gin.Context")).Run(func(args mock.Arguments) {
+				c := args.Get(0).(*gin.Context)
+				c.String(http.StatusOK, c.Request.Proto)
+			}).Return(nil)
+			var handler api.Handler = hm
+			if len(tc.hosts) > 0 {
+				handler = api.NewHostHandler(hm, tc.hosts)
+			}
+			engine := gin.New()
+			engine.UseH2C = true
+			ba := api.NewBaseAPI(engine, logger.NewTestLogger())
+			ba.AddEndpoints([]api.Handler{handler})
+			server := httptest.NewServer(ba.Handler())
+			defer server.Close()
+
+			client := &http.Client{Transport: &http2.Transport{
+				AllowHTTP: true,
+				DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+					return (&net.Dialer{}).DialContext(ctx, network, addr)
+				},
+			}}
+			req, err := http.NewRequest(http.MethodGet, server.URL+"/proto", nil)
+			require.NoError(t, err)
+			if len(tc.hosts) > 0 {
+				req.Host = tc.hosts[0]
+			}
+			response, err := client.Do(req)
+			require.NoError(t, err)
+			defer response.Body.Close()
+			body, err := io.ReadAll(response.Body)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, response.StatusCode)
+			require.Equal(t, "HTTP/2.0", string(body))
+		})
+	}
+}
+
+func TestBaseAPI_CatchAll(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = io.WriteString(rw, "upstream "+req.Method+" "+req.URL.RequestURI()+" "+req.Header.Get("X-Proxy"))
+	}))
+	t.Cleanup(server.Close)
+	headers := map[string]string{"X-Proxy": "faker"}
+	catchAll, err := api.NewPassThroughProxy(server.URL+"/base", headers, logger.NewTestLogger())
+	require.NoError(t, err)
+
+	hm := mocks.NewHandlerMock(t)
+	hm.On("URL").Return("/mocked")
+	hm.On("Method").Return(http.MethodGet)
+	hm.On("Respond", mock.AnythingOfType("*gin.Context")).Run(func(args mock.Arguments) {
+		args.Get(0).(*gin.Context).String(http.StatusOK, "mocked")
+	}).Return(nil)
+	ba := api.NewBaseAPI(gin.New(), logger.NewTestLogger())
+	ba.AddEndpoints([]api.Handler{hm, api.NewHostHandler(hm, []string{"api.example.test"})})
+	ba.AddCatchAll(catchAll)
+
+	testCases := []struct {
+		name         string
+		method       string
+		host         string
+		url          string
+		expectedBody string
+	}{
+		{name: "mocked route", method: http.MethodGet, url: "/mocked", expectedBody: "mocked"},
+		{
+			name:         "route passed through",
+			method:       http.MethodGet,
+			url:          "/users/1?expand=orders",
+			expectedBody: "upstream GET /base/users/1?expand=orders faker",
+		},
+		{
+			name:         "method of mocked route passed through",
+			method:       http.MethodDelete,
+			url:          "/mocked",
+			expectedBody: "upstream DELETE /base/mocked faker",
+		},
+		{
+			name:         "route of virtual host passed through",
+			method:       http.MethodPost,
+			host:         "api.example.test",
+			url:          "/orders",
+			expectedBody: "upstream POST /base/orders faker",
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rr := CreateTestResponseRecorder()
+			req := httptest.NewRequest(tc.method, tc.url, nil)
+			if len(tc.host) > 0 {
+				req.Host = tc.host
+			}
+			ba.Handler().ServeHTTP(rr, req)
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Equal(t, tc.expectedBody, rr.Body.String())
+		})
+	}
+}
+
+func TestNewPassThroughProxy(t *testing.T) {
+	t.Parallel()
+	_, err := api.NewPassThroughProxy("localhost:8080", nil, logger.NewTestLogger())
+	require.ErrorIs(t, err, api.ErrInvalidProxyURL)
+}
@@ -1,16 +1,49 @@
 package api
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"reflect"
+
 	"github.com/vimek-go/server-faker/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrUpstreamFailed  = errors.New("upstream responded with server error")
+	ErrInvalidProxyURL = errors.New("invalid proxy url")
 )
 
 type baseProxyHandler struct {
 	handlerMethod string
 	handlerURL    string
-	proxyURL      string
-	proxyMethod   string
-	headers       map[string]string
-	logger        logger.Logger
+	// upstreams select the upstream of every request, the upstreams of the dynamic proxy are the url templates
+	upstreams   UpstreamPool
+	proxyMethod string
+	headers     ProxyHeaders
+	// transport is shared by all the requests of the endpoint, nil uses the default transport
+	transport http.RoundTripper
+	// rewriter modifies the upstream responses, nil copies them untouched
+	rewriter ResponseRewriter
+	// fallback responds when the upstream is unreachable or responds with 5xx
+	fallback Handler
+	// mirror receives the copies of the requests, nil does not mirror them
+	mirror Mirror
+	logger logger.Logger
+}
+
+// proxyExchange is the request proxied to the upstream selected from the pool
+type proxyExchange struct {
+	target *UpstreamTarget
+	// compare logs the difference between the upstream response and the mirrored one
+	compare  func(response *http.Response, err error)
+	finished bool
 }
 
 func (bh *baseProxyHandler) Method() string {
@@ -20,3 +53,134 @@ func (bh *baseProxyHandler) Method() string {
 func (bh *baseProxyHandler) URL() string {
 	return bh.handlerURL
 }
+
+// parseProxyURL validates the url of the upstream when the endpoint is loaded
+func parseProxyURL(proxyURL string) (*url.URL, error) {
+	remote, err := url.Parse(proxyURL)
+	if err != nil || len(remote.Scheme) == 0 || len(remote.Host) == 0 {
+		return nil, errors.Wrapf(ErrInvalidProxyURL, "%s has to be an absolute url", proxyURL)
+	}
+	return remote, nil
+}
+
+// newReverseProxy creates the proxy of the request with the transport of the endpoint
+func (bh *baseProxyHandler) newReverseProxy(remote *url.URL) *httputil.ReverseProxy {
+	proxy := httputil.NewSingleHostReverseProxy(remote)
+	proxy.Transport = bh.transport
+	return proxy
+}
+
+// generateHeaders creates the header values of the request from the header params
+func (bh *baseProxyHandler) generateHeaders(c *gin.Context) (http.Header, error) {
+	if len(bh.headers.Params) == 0 {
+		return nil, nil
+	}
+	generated := make(http.Header, len(bh.headers.Params))
+	for key, valuer := range bh.headers.Params {
+		value, err := valuer.Generate(c)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error generating header %s", key)
+		}
+		header, err := bh.getStringValue(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error generating header %s", key)
+		}
+		generated.Set(key, header)
+	}
+	return generated, nil
+}
+
+func (bh *baseProxyHandler) getStringValue(value any) (string, error) {
+	switch val := value.(type) {
+	case int, int64, int32:
+		return fmt.Sprintf("%d", val), nil
+	case float64, float32:
+		return fmt.Sprintf("%f", val), nil
+	case string:
+		return val, nil
+	case []uint8:
+		return string(val), nil
+	default:
+		return "", errors.Wrapf(ErrNotHandledType, "not handled conversion to type %s val: %v", reflect.TypeOf(value), value)
+	}
+}
+
+// cacheBody keeps the incoming body in the context when the responses are rewritten,
+// so the rewriter params can map it after the body is sent upstream
+func (bh *baseProxyHandler) cacheBody(c *gin.Context) error {
+	if bh.rewriter == nil {
+		return nil
+	}
+	_, err := readBody(c)
+	return err
+}
+
+// restoreBody sends the incoming body upstream again when the params have read it while mapping
+func restoreBody(c *gin.Context, req *http.Request) {
+	cached, ok := c.Get(gin.BodyBytesKey)
+	if !ok {
+		return
+	}
+	content, ok := cached.([]byte)
+	if !ok {
+		return
+	}
+	req.ContentLength = int64(len(content))
+	if len(content) == 0 {
+		req.Body, req.GetBody = http.NoBody, nil
+		return
+	}
+	req.Body = io.NopCloser(bytes.NewReader(content))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(content)), nil }
+}
+
+// prepareRequest sets the headers of the request sent upstream
+func (bh *baseProxyHandler) prepareRequest(req *http.Request, generated http.Header) {
+	bh.headers.apply(req, generated)
+	if bh.rewriter != nil {
+		bh.rewriter.Prepare(req)
+	}
+}
+
+// mirrorRequest sends the copy of the request prepared for the upstream to the mirror
+func (bh *baseProxyHandler) mirrorRequest(exchange *proxyExchange, req *http.Request) {
+	if bh.mirror != nil {
+		exchange.compare = bh.mirror.Send(req)
+	}
+}
+
+// finish reports the health of the upstream and compares its response with the mirrored one once
+func (bh *baseProxyHandler) finish(exchange *proxyExchange, response *http.Response, err error) {
+	if exchange.finished {
+		return
+	}
+	exchange.finished = true
+	bh.upstreams.Report(exchange.target, err == nil && response.StatusCode < http.StatusInternalServerError)
+	if exchange.compare != nil {
+		exchange.compare(response, err)
+	}
+}
+
+// handleResponses applies the rewriter to the upstream responses of the proxy
+// and serves the fallback when the upstream fails
+func (bh *baseProxyHandler) handleResponses(c *gin.Context, proxy *httputil.ReverseProxy, exchange *proxyExchange) {
+	proxy.ModifyResponse = func(response *http.Response) error {
+		bh.finish(exchange, response, nil)
+		if bh.fallback != nil && response.StatusCode >= http.StatusInternalServerError {
+			return errors.Wrapf(ErrUpstreamFailed, "status %d", response.StatusCode)
+		}
+		if bh.rewriter == nil {
+			return nil
+		}
+		return bh.rewriter.Rewrite(c, response)
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, _ *http.Request, err error) {
+		bh.finish(exchange, nil, err)
+		bh.logger.Errorf("error proxying %s %+v", exchange.target.URL, err)
+		if bh.fallback != nil {
+			bh.fallback.Respond(c)
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"net/http"
 
+	"github.com/vimek-go/server-faker/internal/pkg/encoders"
 	"github.com/vimek-go/server-faker/internal/pkg/enums"
 	"github.com/vimek-go/server-faker/internal/pkg/logger"
 	"github.com/vimek-go/server-faker/internal/pkg/values"
@@ -16,6 +17,7 @@ func NewDynamicHandler(
 	method, url string,
 	responseCode int,
 	valuer values.Valuer,
+	options encoders.Options,
 	logger logger.Logger,
 ) (ResponseHandler, error) {
 	baseHandler := newBaseResponseHandler(method, url, responseCode, logger)
@@ -23,11 +25,15 @@ func NewDynamicHandler(
 	case enums.ResponseFormats.JSON():
 		return &dynamicJSONHandler{baseResponseHandler: baseHandler, valuer: valuer}, nil
 	default:
-		return nil, errors.Wrapf(
-			ErrNotSupportedFormat,
-			"format %s is not supported in dynamic endpoint",
-			responseFormat,
-		)
+		encoder, err := encoders.New(responseFormat, options)
+		if err != nil {
+			return nil, errors.Wrapf(
+				ErrNotSupportedFormat,
+				"format %s is not supported in dynamic endpoint",
+				responseFormat,
+			)
+		}
+		return &dynamicEncodedHandler{baseResponseHandler: baseHandler, valuer: valuer, encoder: encoder}, nil
 	}
 }
 
@@ -37,18 +43,49 @@ type dynamicJSONHandler struct {
 }
 
 func (djh *dynamicJSONHandler) Respond(c *gin.Context) {
-	response, err := djh.valuer.Generate(c)
+	response, ok := generateResponse(c, djh.valuer, djh.Logger)
+	if !ok {
+		return
+	}
+	c.JSON(djh.Code, response)
+}
+
+type dynamicEncodedHandler struct {
+	baseResponseHandler
+	valuer  values.Valuer
+	encoder encoders.Encoder
+}
+
+func (deh *dynamicEncodedHandler) Respond(c *gin.Context) {
+	response, ok := generateResponse(c, deh.valuer, deh.Logger)
+	if !ok {
+		return
+	}
+	body, err := deh.encoder.Encode(response)
 	if err != nil {
-		djh.Logger.Error(err)
+		deh.Logger.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	c.Data(deh.Code, deh.encoder.ContentType(), body)
+}
+
+// generateResponse responds with an error when the value cannot be generated
+func generateResponse(c *gin.Context, valuer values.Valuer, logger logger.Logger) (any, bool) {
+	response, err := valuer.Generate(c)
+	if err != nil {
+		logger.Error(err)
 		switch {
-		case errors.Is(err, values.ErrFailedLocatingElement):
+		case errors.Is(err, values.ErrFailedLocatingElement), errors.Is(err, values.ErrNoClientCertificate):
 			RespondWithErrorMappingParam(c, err)
 		case errors.Is(err, values.ErrConversionFailed):
 			RespondWithConversionFailure(c, err)
+		case errors.Is(err, values.ErrNotStored):
+			RespondWithNotStored(c, err)
 		default:
 			c.AbortWithStatus(http.StatusInternalServerError)
 		}
-		return
+		return nil, false
 	}
-	c.JSON(djh.Code, response)
+	return response, true
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/vimek-go/server-faker/internal/pkg/api"
 	"github.com/vimek-go/server-faker/internal/pkg/api/internal/mocks"
+	"github.com/vimek-go/server-faker/internal/pkg/encoders"
 	"github.com/vimek-go/server-faker/internal/pkg/enums"
 	"github.com/vimek-go/server-faker/internal/pkg/logger"
 	"github.com/vimek-go/server-faker/internal/pkg/values"
@@ -34,6 +35,16 @@ func TestDynamicHandler_Respond(t *testing.T) {
 			},
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name:           "success response with xml",
+			responseFormat: enums.ResponseFormats.XML(),
+			valuer: func(c *gin.Context) *mocks.ValuerMock {
+				v := mocks.NewValuerMock(t)
+				v.On("Generate", c).Return(map[string]any{"key": "value"}, nil)
+				return v
+			},
+			expectedStatus: http.StatusOK,
+		},
 		{
 			name:           "error response with failed locating element",
 			responseFormat: enums.ResponseFormats.JSON(),
@@ -76,6 +87,7 @@ func TestDynamicHandler_Respond(t *testing.T) {
 				"/test",
 				http.StatusOK,
 				tc.valuer(c),
+				encoders.Options{},
 				logger.NewTestLogger(),
 			)
 			if tc.expectedError != nil {
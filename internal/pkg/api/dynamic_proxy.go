@@ -2,13 +2,10 @@ package api
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
-	"reflect"
 	"strings"
 
 	"github.com/vimek-go/server-faker/internal/pkg/enums"
@@ -25,79 +22,115 @@ const urlReplacementPrefix = ":"
 
 type dynamicProxy struct {
 	baseProxyHandler
-	urlValuers    map[string]values.Valuer
-	queryValuers  map[string]values.Valuer
-	payloadValuer values.Valuer
+	urlValuers   map[string]values.Valuer
+	queryValuers map[string]values.Valuer
+	// body generates the body of the methods sending one, nil sends the empty body
+	body ProxyBody
 }
 
+// NewDynamicProxy forwards the requests to the upstreams selected from the pool with the generated url params,
+// query and body, the url params are replaced in the url of the upstream for every request
 func NewDynamicProxy(
-	handlerMethod, handlerURL, proxyMethod, proxyURL string,
+	handlerMethod, handlerURL, proxyMethod string,
+	upstreams UpstreamPool,
 	urlValuers, queryValuers map[string]values.Valuer,
-	payloadValuer values.Valuer,
-	headers map[string]string,
+	body ProxyBody,
+	headers ProxyHeaders,
+	transport http.RoundTripper,
+	rewriter ResponseRewriter,
+	fallback Handler,
+	mirror Mirror,
 	logger logger.Logger,
 ) Handler {
 	return &dynamicProxy{
 		baseProxyHandler: baseProxyHandler{
 			handlerMethod: handlerMethod,
 			handlerURL:    handlerURL,
-			proxyURL:      proxyURL,
+			upstreams:     upstreams,
 			proxyMethod:   proxyMethod,
 			headers:       headers,
+			transport:     transport,
+			rewriter:      rewriter,
+			fallback:      fallback,
+			mirror:        mirror,
 			logger:        logger,
 		},
-		queryValuers:  queryValuers,
-		urlValuers:    urlValuers,
-		payloadValuer: payloadValuer,
+		queryValuers: queryValuers,
+		urlValuers:   urlValuers,
+		body:         body,
 	}
 }
 
 func (dp *dynamicProxy) Respond(c *gin.Context) {
-	remote, err := dp.prepareURL(c)
+	exchange := &proxyExchange{target: dp.upstreams.Next()}
+	remote, err := dp.prepareURL(c, exchange.target.URL)
 	if err != nil {
 		dp.logger.Errorf("error preparing url %+v\n", err)
 		RespondWithConversionFailure(c, err)
 		return
 	}
+	headers, err := dp.generateHeaders(c)
+	if err != nil {
+		dp.logger.Errorf("error generating proxy headers %+v\n", err)
+		RespondWithErrorMappingParam(c, err)
+		return
+	}
+	if err := dp.cacheBody(c); err != nil {
+		dp.logger.Errorf("error reading request body %+v\n", err)
+		RespondWithErrorMappingParam(c, err)
+		return
+	}
 
 	var buf []byte
-	if dp.payloadValuer != nil && !dp.payloadValuer.IsNil() {
-		payloadObj, err := dp.payloadValuer.Generate(c)
-		if err != nil {
+	var contentType string
+	sendsBody := methodSendsBody(dp.proxyMethod)
+	if sendsBody && dp.body != nil {
+		if buf, contentType, err = dp.body.Generate(c); err != nil {
 			dp.logger.Errorf("error generating proxy body %+v\n", err)
 			RespondWithPayloadGenerationFailure(c, err)
 			return
 		}
-		buf, err = json.Marshal(payloadObj)
-		if err != nil {
-			dp.logger.Error(err)
-			return
-		}
 	}
-	proxy := httputil.NewSingleHostReverseProxy(remote)
+	proxy := dp.newReverseProxy(remote)
 	proxy.Director = func(req *http.Request) {
 		*req = *c.Request
+		restoreBody(c, req)
 		req.Host = remote.Host
 		req.Method = dp.proxyMethod
 		req.URL.Scheme = remote.Scheme
 		req.URL.Host = remote.Host
 		req.URL.Path = remote.Path
 		req.URL.RawQuery = remote.RawQuery
-
-		for key, value := range dp.headers {
-			req.Header.Set(key, value)
-		}
-		if dp.proxyMethod == http.MethodPost || dp.proxyMethod == http.MethodPut {
+		dp.prepareRequest(req, headers)
+		// nothing generated, the body of the client is forwarded
+		if sendsBody && buf != nil {
+			// the length of the incoming request does not describe the generated body
+			req.ContentLength = int64(len(buf))
 			req.Body = io.NopCloser(bytes.NewReader(buf))
+			req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(buf)), nil }
+			if len(contentType) > 0 {
+				req.Header.Set("Content-Type", contentType)
+			}
 		}
+		dp.mirrorRequest(exchange, req)
 	}
+	dp.handleResponses(c, proxy, exchange)
 	proxy.ServeHTTP(c.Writer, c.Request)
 }
 
-func (dp *dynamicProxy) prepareURL(c *gin.Context) (*url.URL, error) {
-	perparedURL, err := dp.prepareBaseURL(c)
+// methodSendsBody tells if the generated body is sent with the method, the other methods forward the incoming body
+func methodSendsBody(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func (dp *dynamicProxy) prepareURL(c *gin.Context, proxyURL string) (*url.URL, error) {
+	perparedURL, err := dp.prepareBaseURL(c, proxyURL)
 	if err != nil {
-		return nil, errors.Wrapf(err, "error generating url %s", dp.proxyURL)
+		return nil, errors.Wrapf(err, "error generating url %s", proxyURL)
 	}
 	values := perparedURL.Query()
 	dp.logger.Infof("preparing query values %s, %d", perparedURL, len(dp.queryValuers))
@@ -151,24 +184,8 @@ func (dp *dynamicProxy) getArrayQueryValues(value any) ([]string, error) {
 	return rval, nil
 }
 
-func (dp *dynamicProxy) getStringValue(value any) (string, error) {
-	// generated := valuer.Generate()
-	switch val := value.(type) {
-	case int, int64, int32:
-		return fmt.Sprintf("%d", val), nil
-	case float64, float32:
-		return fmt.Sprintf("%f", val), nil
-	case string:
-		return val, nil
-	case []uint8:
-		return string(val), nil
-	default:
-		return "", errors.Wrapf(ErrNotHandledType, "not handled conversion to type %s val: %v", reflect.TypeOf(value), value)
-	}
-}
-
-func (dp *dynamicProxy) prepareBaseURL(c *gin.Context) (*url.URL, error) {
-	rval := dp.proxyURL
+func (dp *dynamicProxy) prepareBaseURL(c *gin.Context, proxyURL string) (*url.URL, error) {
+	rval := proxyURL
 	for key, valuer := range dp.urlValuers {
 		value, err := valuer.Generate(c)
 		if err != nil {
@@ -2,8 +2,10 @@ package api_test
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/vimek-go/server-faker/internal/pkg/api"
@@ -42,6 +44,7 @@ func TestDynamicProxy_Respond(t *testing.T) {
 		urlValuer      func(*gin.Context) map[string]values.Valuer
 		payloadValuer  func(*gin.Context) *mocks.ValuerMock
 		headers        map[string]string
+		incoming       string
 		asserts        func(t *testing.T, req *http.Request)
 		expectedStatus int
 	}{
@@ -68,11 +71,10 @@ func TestDynamicProxy_Respond(t *testing.T) {
 			asserts: func(t *testing.T, req *http.Request) {
 				require.Contains(t, req.URL.Path, "url-value")
 				require.Equal(t, req.URL.RawQuery, "query=query-value")
-				reader := req.Body
-				buf := make([]byte, 19)
-				n, err := reader.Read(buf)
+				buf, err := io.ReadAll(req.Body)
 				require.NoError(t, err)
-				require.Equal(t, string(buf[:n]), `{"payload":"value"}`)
+				require.Equal(t, `{"payload":"value"}`, string(buf))
+				require.Equal(t, "application/json", req.Header.Get("Content-Type"))
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -149,6 +151,27 @@ func TestDynamicProxy_Respond(t *testing.T) {
 			},
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name: "incoming body forwarded when nothing generated",
+			queryValuer: func(*gin.Context) map[string]values.Valuer {
+				return nil
+			},
+			urlValuer: func(*gin.Context) map[string]values.Valuer {
+				return nil
+			},
+			payloadValuer: func(*gin.Context) *mocks.ValuerMock {
+				mv := mocks.NewValuerMock(t)
+				mv.On("IsNil").Return(true)
+				return mv
+			},
+			incoming: `{"id":1}`,
+			asserts: func(t *testing.T, req *http.Request) {
+				body, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				require.Equal(t, `{"id":1}`, string(body))
+			},
+			expectedStatus: http.StatusOK,
+		},
 	}
 	for i := range testCases {
 		tc := testCases[i]
@@ -163,18 +186,24 @@ func TestDynamicProxy_Respond(t *testing.T) {
 				rw.WriteHeader(http.StatusOK)
 			}))
 			defer server.Close()
+			body, err := api.NewProxyBody(tc.payloadValuer(c), "", false)
+			require.NoError(t, err)
 			dp := api.NewDynamicProxy(
 				http.MethodPost,
 				"/test",
 				http.MethodPost,
-				server.URL+"/:url",
+				newUpstreams(t, server.URL+"/:url"),
 				tc.urlValuer(c),
 				tc.queryValuer(c),
-				tc.payloadValuer(c),
-				tc.headers,
+				body,
+				api.ProxyHeaders{Set: tc.headers},
+				nil,
+				nil,
+				nil,
+				nil,
 				logger.NewTestLogger(),
 			)
-			c.Request = httptest.NewRequest(http.MethodPost, "/test", nil)
+			c.Request = httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(tc.incoming))
 
 			dp.Respond(c)
 			require.Equal(t, tc.expectedStatus, rr.Code)
@@ -12,6 +12,10 @@ const (
 	emptyParamGeneratedTitle = "Provided value for param is empty"
 	conversionFailedTitle    = "Conversion failed"
 	payloadGenerationTitle   = "Payload generation failed"
+	notAcceptableTitle       = "Requested format is not available"
+	adminTitle               = "Admin request failed"
+	sequenceExhaustedTitle   = "Sequence exhausted"
+	notStoredTitle           = "Requested value is not stored"
 )
 
 type ErrorResponse struct {
@@ -36,6 +40,15 @@ func RespondWithPayloadGenerationFailure(c *gin.Context, err error) {
 	returnErrors(c, http.StatusBadRequest, c.Request.URL.EscapedPath(), payloadGenerationTitle, err)
 }
 
+func RespondWithNotAcceptable(c *gin.Context, err error) {
+	returnErrors(c, http.StatusNotAcceptable, c.Request.URL.EscapedPath(), notAcceptableTitle, err)
+}
+
+// RespondWithNotStored responds to the requests for the values which were not stored or expired
+func RespondWithNotStored(c *gin.Context, err error) {
+	returnErrors(c, http.StatusNotFound, c.Request.URL.EscapedPath(), notStoredTitle, err)
+}
+
 func returnErrors(c *gin.Context, status int, url, title string, err error) {
 	var body ErrorResponse
 	var merr *multierror.Error
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/vimek-go/server-faker/internal/pkg/graphql"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const graphqlContentType = "application/graphql"
+
+var ErrInvalidGraphQLRequest = errors.New("invalid graphql request")
+
+type graphqlHandler struct {
+	baseResponseHandler
+	executor graphql.Executor
+}
+
+// NewGraphQLHandler answers the queries sent as json body of POST requests
+// or in the query, operationName and variables params of GET requests
+func NewGraphQLHandler(method, url string, executor graphql.Executor, logger logger.Logger) ResponseHandler {
+	return &graphqlHandler{
+		baseResponseHandler: newBaseResponseHandler(method, url, http.StatusOK, logger),
+		executor:            executor,
+	}
+}
+
+func (gh *graphqlHandler) Respond(c *gin.Context) {
+	request, err := gh.readRequest(c)
+	if err != nil {
+		gh.Logger.Error(err)
+		c.JSON(http.StatusBadRequest, graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("%s", err.Error())}})
+		return
+	}
+	response, err := gh.executor.Execute(c, request)
+	if errors.Is(err, graphql.ErrInvalidQuery) {
+		gh.Logger.Debug(err)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	if err != nil {
+		gh.Logger.Error(err)
+		c.JSON(http.StatusInternalServerError, graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("%s", err.Error())}})
+		return
+	}
+	c.JSON(gh.Code, response)
+}
+
+func (gh *graphqlHandler) readRequest(c *gin.Context) (graphql.Request, error) {
+	var request graphql.Request
+	if c.Request.Method == http.MethodGet {
+		request.Query = c.Query("query")
+		request.OperationName = c.Query("operationName")
+		if variables := c.Query("variables"); len(variables) > 0 {
+			if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+				return request, errors.Wrapf(ErrInvalidGraphQLRequest, "variables param: %v", err)
+			}
+		}
+	} else if strings.HasPrefix(c.ContentType(), graphqlContentType) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return request, errors.Wrapf(ErrInvalidGraphQLRequest, "reading body: %v", err)
+		}
+		request.Query = string(body)
+	} else if err := json.NewDecoder(c.Request.Body).Decode(&request); err != nil {
+		return request, errors.Wrapf(ErrInvalidGraphQLRequest, "body: %v", err)
+	}
+	if len(request.Query) == 0 {
+		return request, errors.Wrap(ErrInvalidGraphQLRequest, "query is missing")
+	}
+	return request, nil
+}
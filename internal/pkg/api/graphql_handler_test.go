@@ -0,0 +1,118 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/graphql"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/tools"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphQLHandler_Respond(t *testing.T) {
+	t.Parallel()
+	schemaPath := path.Join(t.TempDir(), "schema.graphql")
+	tools.SaveToAFile(t, "type Query { hello(name: String): String! }", schemaPath)
+	schema, err := graphql.LoadSchema(schemaPath)
+	require.NoError(t, err)
+	executor, err := graphql.NewExecutor(schema, graphql.Options{
+		Fields: map[string]values.Valuer{"Query.hello": values.NewStaticValuer("", "world")},
+	}, logger.NewTestLogger())
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name           string
+		method         string
+		target         string
+		contentType    string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "post json query",
+			method:         http.MethodPost,
+			target:         "/graphql",
+			contentType:    "application/json",
+			body:           `{"query": "query Hello($name: String) { hello(name: $name) }", "variables": {"name": "a"}}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"data": {"hello": "world"}}`,
+		},
+		{
+			name:           "post graphql query",
+			method:         http.MethodPost,
+			target:         "/graphql",
+			contentType:    "application/graphql",
+			body:           `{ greeting: hello }`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"data": {"greeting": "world"}}`,
+		},
+		{
+			name:   "get query with variables",
+			method: http.MethodGet,
+			target: "/graphql?" + url.Values{
+				"query":     {"query Hello($name: String) { hello(name: $name) }"},
+				"variables": {`{"name": "a"}`},
+			}.Encode(),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"data": {"hello": "world"}}`,
+		},
+		{
+			name:           "invalid query",
+			method:         http.MethodPost,
+			target:         "/graphql",
+			contentType:    "application/json",
+			body:           `{"query": "{ goodbye }"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: `{"errors": [{"message": "Cannot query field \"goodbye\" on type \"Query\".",` +
+				`"locations": [{"line": 1, "column": 3}]}]}`,
+		},
+		{
+			name:           "invalid body",
+			method:         http.MethodPost,
+			target:         "/graphql",
+			contentType:    "application/json",
+			body:           `{"query": `,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing query",
+			method:         http.MethodGet,
+			target:         "/graphql",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			handler := api.NewGraphQLHandler(tc.method, "/graphql", executor, logger.NewTestLogger())
+			rr := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rr)
+			c.Request = httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			if len(tc.contentType) > 0 {
+				c.Request.Header.Set("Content-Type", tc.contentType)
+			}
+			handler.Respond(c)
+
+			require.Equal(t, tc.expectedStatus, rr.Code)
+			if len(tc.expectedBody) > 0 {
+				require.JSONEq(t, tc.expectedBody, rr.Body.String())
+				return
+			}
+			var response map[string]any
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			require.NotEmpty(t, response["errors"])
+		})
+	}
+}
@@ -0,0 +1,309 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+var (
+	ErrStreamingNotSupported = errors.New("only the unary grpc methods can be proxied")
+	ErrGRPCUpstream          = errors.New("grpc upstream not available")
+)
+
+// grpcMetadataPrefix marks the response headers copied from the metadata of the upstream
+const grpcMetadataPrefix = "Grpc-Metadata-"
+
+// grpcStatuses are the http statuses of the grpc codes, the same as the grpc-gateway responds with
+var grpcStatuses = map[codes.Code]int{
+	codes.OK:                 http.StatusOK,
+	codes.Canceled:           499,
+	codes.Unknown:            http.StatusInternalServerError,
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.Aborted:            http.StatusConflict,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Internal:           http.StatusInternalServerError,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.DataLoss:           http.StatusInternalServerError,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+}
+
+// metadataSkipped are the headers of the http request which are not sent as the grpc metadata
+var metadataSkipped = map[string]bool{
+	"Accept-Encoding":   true,
+	"Connection":        true,
+	"Content-Length":    true,
+	"Content-Type":      true,
+	"Host":              true,
+	"Keep-Alive":        true,
+	"Te":                true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+}
+
+// GRPCProxyOptions configure the connections of the grpc proxy to the upstreams
+type GRPCProxyOptions struct {
+	// ConnectTimeout limits connecting to the upstream, 30 seconds when 0
+	ConnectTimeout time.Duration
+	// ResponseTimeout is the deadline of the call, no limit when 0
+	ResponseTimeout time.Duration
+	// TLS is used for the https upstreams, the system roots are used when nil
+	TLS *tls.Config
+}
+
+type grpcProxy struct {
+	baseProxyHandler
+	method protoreflect.MethodDescriptor
+	// files resolve the messages of the status details
+	files *protoregistry.Files
+	// body generates the request message in its json form, nil sends the incoming body
+	body    ProxyBody
+	options GRPCProxyOptions
+	mutex   sync.Mutex
+	conns   map[string]*grpc.ClientConn
+}
+
+// NewGRPCProxy calls the unary method of the upstream with the json body converted to the request message,
+// the response message is returned as json and the error status as its http status,
+// the url of the upstream is http for the plaintext connections and https for the tls ones
+func NewGRPCProxy(
+	handlerMethod, handlerURL string,
+	method protoreflect.MethodDescriptor,
+	files *protoregistry.Files,
+	upstreams UpstreamPool,
+	body ProxyBody,
+	headers ProxyHeaders,
+	options GRPCProxyOptions,
+	fallback Handler,
+	logger logger.Logger,
+) (Handler, error) {
+	if method.IsStreamingClient() || method.IsStreamingServer() {
+		return nil, errors.Wrapf(ErrStreamingNotSupported, "method %s", method.FullName())
+	}
+	return &grpcProxy{
+		baseProxyHandler: baseProxyHandler{
+			handlerMethod: handlerMethod,
+			handlerURL:    handlerURL,
+			upstreams:     upstreams,
+			headers:       headers,
+			fallback:      fallback,
+			logger:        logger,
+		},
+		method:  method,
+		files:   files,
+		body:    body,
+		options: options,
+		conns:   make(map[string]*grpc.ClientConn),
+	}, nil
+}
+
+func (gp *grpcProxy) Respond(c *gin.Context) {
+	target := gp.upstreams.Next()
+	request, err := gp.prepareMessage(c)
+	if err != nil {
+		gp.logger.Errorf("error preparing grpc request %+v\n", err)
+		RespondWithPayloadGenerationFailure(c, err)
+		return
+	}
+	generated, err := gp.generateHeaders(c)
+	if err != nil {
+		gp.logger.Errorf("error generating proxy headers %+v\n", err)
+		RespondWithErrorMappingParam(c, err)
+		return
+	}
+	conn, err := gp.conn(target)
+	if err != nil {
+		gp.upstreams.Report(target, false)
+		gp.respondWithError(c, status.Error(codes.Unavailable, err.Error()))
+		return
+	}
+
+	ctx := metadata.NewOutgoingContext(c.Request.Context(), gp.prepareMetadata(c, generated))
+	if gp.options.ResponseTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, gp.options.ResponseTimeout)
+		defer cancel()
+	}
+	var header metadata.MD
+	response := dynamicpb.NewMessage(gp.method.Output())
+	err = conn.Invoke(ctx, fullMethodName(gp.method), request, response, grpc.Header(&header))
+	gp.upstreams.Report(target, grpcStatus(status.Code(err)) < http.StatusInternalServerError)
+	gp.logger.Infof("proxied %s to %s%s: %s", c.Request.URL, target.URL, fullMethodName(gp.method), status.Code(err))
+	for key, values := range header {
+		for _, value := range values {
+			c.Writer.Header().Add(grpcMetadataPrefix+key, value)
+		}
+	}
+	if err != nil {
+		gp.respondWithError(c, err)
+		return
+	}
+	body, err := protojson.Marshal(response)
+	if err != nil {
+		gp.logger.Error(err)
+		RespondWithConversionFailure(c, err)
+		return
+	}
+	c.Data(http.StatusOK, "application/json", body)
+}
+
+// prepareMessage converts the generated body to the request message, the incoming one when nothing is generated
+func (gp *grpcProxy) prepareMessage(c *gin.Context) (*dynamicpb.Message, error) {
+	var body []byte
+	var err error
+	if gp.body != nil {
+		if body, _, err = gp.body.Generate(c); err != nil {
+			return nil, err
+		}
+	}
+	if body == nil {
+		if body, err = readBody(c); err != nil {
+			return nil, err
+		}
+	}
+	message := dynamicpb.NewMessage(gp.method.Input())
+	if len(body) == 0 {
+		return message, nil
+	}
+	if err := protojson.Unmarshal(body, message); err != nil {
+		return nil, errors.Wrapf(err, "request message %s", gp.method.Input().FullName())
+	}
+	return message, nil
+}
+
+// prepareMetadata sends the headers prepared the way the http proxies send them as the metadata
+func (gp *grpcProxy) prepareMetadata(c *gin.Context, generated http.Header) metadata.MD {
+	req := &http.Request{Header: c.Request.Header}
+	gp.headers.apply(req, generated)
+	md := make(metadata.MD, len(req.Header))
+	for key, values := range req.Header {
+		if metadataSkipped[key] || strings.HasPrefix(key, "Grpc-") {
+			continue
+		}
+		md.Append(key, values...)
+	}
+	return md
+}
+
+// conn returns the connection to the upstream, the connections are created once and connect when used
+func (gp *grpcProxy) conn(target *UpstreamTarget) (*grpc.ClientConn, error) {
+	gp.mutex.Lock()
+	defer gp.mutex.Unlock()
+	if conn, ok := gp.conns[target.URL]; ok {
+		return conn, nil
+	}
+	creds := insecure.NewCredentials()
+	if target.Remote.Scheme == "https" {
+		config := gp.options.TLS
+		if config == nil {
+			config = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		creds = credentials.NewTLS(config)
+	}
+	connectTimeout := gp.options.ConnectTimeout
+	if connectTimeout == 0 {
+		connectTimeout = defaultConnectTimeout
+	}
+	conn, err := grpc.NewClient(
+		target.Remote.Host,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithConnectParams(grpc.ConnectParams{Backoff: backoff.DefaultConfig, MinConnectTimeout: connectTimeout}),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(ErrGRPCUpstream, "%s: %v", target.URL, err)
+	}
+	gp.conns[target.URL] = conn
+	return conn, nil
+}
+
+// respondWithError serves the fallback for the server errors, the other statuses are returned as json
+func (gp *grpcProxy) respondWithError(c *gin.Context, err error) {
+	st := status.Convert(err)
+	httpStatus := grpcStatus(st.Code())
+	if gp.fallback != nil && httpStatus >= http.StatusInternalServerError {
+		gp.logger.Errorf("error proxying %s %+v", gp.method.FullName(), err)
+		gp.fallback.Respond(c)
+		return
+	}
+	options := protojson.MarshalOptions{Resolver: &detailsResolver{files: gp.files}}
+	body, marshalErr := options.Marshal(st.Proto())
+	if marshalErr != nil {
+		// the details of the types which are not known are dropped
+		gp.logger.Warnf("error converting status details of %s %v", gp.method.FullName(), marshalErr)
+		body, _ = protojson.Marshal(status.New(st.Code(), st.Message()).Proto())
+	}
+	c.Data(httpStatus, "application/json", body)
+}
+
+func grpcStatus(code codes.Code) int {
+	if httpStatus, ok := grpcStatuses[code]; ok {
+		return httpStatus
+	}
+	return http.StatusInternalServerError
+}
+
+func fullMethodName(method protoreflect.MethodDescriptor) string {
+	return "/" + string(method.Parent().FullName()) + "/" + string(method.Name())
+}
+
+// detailsResolver looks up the messages of the status details in the files of the service,
+// the google.rpc error details are always available
+type detailsResolver struct {
+	files *protoregistry.Files
+}
+
+func (r *detailsResolver) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageType, error) {
+	if r.files != nil {
+		if descriptor, err := r.files.FindDescriptorByName(name); err == nil {
+			if message, ok := descriptor.(protoreflect.MessageDescriptor); ok {
+				return dynamicpb.NewMessageType(message), nil
+			}
+		}
+	}
+	return protoregistry.GlobalTypes.FindMessageByName(name)
+}
+
+func (r *detailsResolver) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	name := url
+	if i := strings.LastIndexByte(url, '/'); i >= 0 {
+		name = url[i+1:]
+	}
+	return r.FindMessageByName(protoreflect.FullName(name))
+}
+
+func (r *detailsResolver) FindExtensionByName(field protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	return protoregistry.GlobalTypes.FindExtensionByName(field)
+}
+
+func (r *detailsResolver) FindExtensionByNumber(
+	message protoreflect.FullName,
+	field protoreflect.FieldNumber,
+) (protoreflect.ExtensionType, error) {
+	return protoregistry.GlobalTypes.FindExtensionByNumber(message, field)
+}
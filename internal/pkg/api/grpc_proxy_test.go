@@ -0,0 +1,215 @@
+package api_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/grpcmock"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/protos"
+	"github.com/vimek-go/server-faker/internal/pkg/tools"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const orderProto = `syntax = "proto3";
+package order.v1;
+
+message GetOrderRequest { string id = 1; string tenant = 2; }
+message Order { string id = 1; string tenant = 2; int64 total_cents = 3; }
+
+service OrderService {
+  rpc GetOrder(GetOrderRequest) returns (Order);
+  rpc DeleteOrder(GetOrderRequest) returns (Order);
+  rpc WatchOrder(GetOrderRequest) returns (stream Order);
+}
+`
+
+// startOrderService serves the order service echoing the request id and the tenant metadata
+func startOrderService(t *testing.T) (protoreflect.ServiceDescriptor, *protoregistry.Files, string) {
+	t.Helper()
+	testLogger := logger.NewTestLogger()
+	dir := t.TempDir()
+	tools.SaveToAFile(t, orderProto, path.Join(dir, "order.proto"))
+	loader := protos.NewLoader(testLogger)
+	files, err := loader.Load(path.Join(dir, "order.proto"), nil)
+	require.NoError(t, err)
+	service, err := loader.FindService(path.Join(dir, "order.proto"), nil, "order.v1.OrderService")
+	require.NoError(t, err)
+
+	id, err := values.NewMappedValuer("id", "", "$.id", "", enums.RequestLocations.Body(), nil,
+		enums.ConversionTypes.None(), testLogger)
+	require.NoError(t, err)
+	tenant, err := values.NewMappedValuer("tenant", "x-tenant", "", "", enums.RequestLocations.Header(), nil,
+		enums.ConversionTypes.None(), testLogger)
+	require.NoError(t, err)
+	detail, err := anypb.New(&errdetails.ErrorInfo{Reason: "ORDER_DELETED"})
+	require.NoError(t, err)
+	server, err := grpcmock.NewServer([]grpcmock.Service{{
+		Descriptor: service,
+		Files:      files,
+		Handlers: []grpcmock.MethodHandler{
+			grpcmock.NewDynamicHandler(service.Methods().ByName("GetOrder"), values.NewObjectValuer("", []values.Valuer{
+				id, tenant, values.NewStaticValuer("total_cents", 1250),
+			}), testLogger),
+			grpcmock.NewStatusHandler(service.Methods().ByName("DeleteOrder"), &spb.Status{
+				Code:    int32(codes.NotFound),
+				Message: "order not found",
+				Details: []*anypb.Any{detail},
+			}, testLogger),
+		},
+	}}, testLogger)
+	require.NoError(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+	return service, files, "http://" + listener.Addr().String()
+}
+
+func TestGRPCProxy_Respond(t *testing.T) {
+	t.Parallel()
+	service, files, upstream := startOrderService(t)
+	testCases := []struct {
+		name           string
+		method         string
+		upstream       string
+		body           func(t *testing.T) api.ProxyBody
+		headers        api.ProxyHeaders
+		fallback       bool
+		requestBody    string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "incoming body and headers",
+			method:         "GetOrder",
+			upstream:       upstream,
+			requestBody:    `{"id":"7"}`,
+			headers:        api.ProxyHeaders{Rename: map[string]string{"X-Caller-Tenant": "X-Tenant"}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":"7","tenant":"acme","totalCents":"1250"}`,
+		},
+		{
+			name:     "generated body merged into the incoming one",
+			method:   "GetOrder",
+			upstream: upstream,
+			body: func(t *testing.T) api.ProxyBody {
+				body, err := api.NewProxyBody(values.NewObjectValuer("", []values.Valuer{
+					values.NewStaticValuer("id", "generated"),
+				}), "", true)
+				require.NoError(t, err)
+				return body
+			},
+			requestBody:    `{"id":"7"}`,
+			headers:        api.ProxyHeaders{Set: map[string]string{"X-Tenant": "static"}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":"generated","tenant":"static","totalCents":"1250"}`,
+		},
+		{
+			name:     "nothing generated forwards the incoming body",
+			method:   "GetOrder",
+			upstream: upstream,
+			body: func(t *testing.T) api.ProxyBody {
+				body, err := api.NewProxyBody(nil, "", false)
+				require.NoError(t, err)
+				return body
+			},
+			requestBody:    `{"id":"7"}`,
+			headers:        api.ProxyHeaders{Set: map[string]string{"X-Tenant": "static"}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":"7","tenant":"static","totalCents":"1250"}`,
+		},
+		{
+			name:           "invalid request message",
+			method:         "GetOrder",
+			upstream:       upstream,
+			requestBody:    `{"unknown":"7"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "status with details",
+			method:         "DeleteOrder",
+			upstream:       upstream,
+			expectedStatus: http.StatusNotFound,
+			expectedBody: `{"code":5,"message":"order not found","details":[` +
+				`{"@type":"type.googleapis.com/google.rpc.ErrorInfo","reason":"ORDER_DELETED"}]}`,
+		},
+		{
+			name:           "unavailable upstream",
+			method:         "GetOrder",
+			upstream:       "http://127.0.0.1:1",
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "fallback of unavailable upstream",
+			method:         "GetOrder",
+			upstream:       "http://127.0.0.1:1",
+			fallback:       true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":"cached"}`,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var err error
+			var body api.ProxyBody
+			if tc.body != nil {
+				body = tc.body(t)
+			}
+			var fallback api.Handler
+			if tc.fallback {
+				fallback, err = api.NewStaticHandler(
+					enums.ResponseFormats.JSON(), http.MethodPost, "/orders", http.StatusOK,
+					[]byte(`{"id":"cached"}`), "", logger.NewTestLogger(),
+				)
+				require.NoError(t, err)
+			}
+			handler, err := api.NewGRPCProxy(
+				http.MethodPost, "/orders", service.Methods().ByName(protoreflect.Name(tc.method)), files,
+				newUpstreams(t, tc.upstream), body, tc.headers, api.GRPCProxyOptions{}, fallback,
+				logger.NewTestLogger(),
+			)
+			require.NoError(t, err)
+			rr := CreateTestResponseRecorder()
+			c, _ := gin.CreateTestContext(rr)
+			c.Request = httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(tc.requestBody))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Request.Header.Set("X-Caller-Tenant", "acme")
+
+			handler.Respond(c)
+			require.Equal(t, tc.expectedStatus, rr.Code)
+			if len(tc.expectedBody) > 0 {
+				require.JSONEq(t, tc.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestNewGRPCProxy_Streaming(t *testing.T) {
+	t.Parallel()
+	service, files, upstream := startOrderService(t)
+	_, err := api.NewGRPCProxy(
+		http.MethodGet, "/orders", service.Methods().ByName("WatchOrder"), files, newUpstreams(t, upstream),
+		nil, api.ProxyHeaders{}, api.GRPCProxyOptions{}, nil, logger.NewTestLogger(),
+	)
+	require.ErrorIs(t, err, api.ErrStreamingNotSupported)
+}
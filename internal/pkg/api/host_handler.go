@@ -0,0 +1,36 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// HostHandler is served only for the requests to its virtual hosts
+type HostHandler interface {
+	Handler
+	Hosts() []string
+}
+
+type hostHandler struct {
+	handler Handler
+	hosts   []string
+}
+
+func NewHostHandler(handler Handler, hosts []string) HostHandler {
+	return &hostHandler{handler: handler, hosts: hosts}
+}
+
+func (hh *hostHandler) Method() string {
+	return hh.handler.Method()
+}
+
+func (hh *hostHandler) URL() string {
+	return hh.handler.URL()
+}
+
+func (hh *hostHandler) Hosts() []string {
+	return hh.hosts
+}
+
+func (hh *hostHandler) Respond(c *gin.Context) {
+	hh.handler.Respond(c)
+}
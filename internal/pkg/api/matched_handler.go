@@ -0,0 +1,92 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/PaesslerAG/jsonpath"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestMatch selects the requests served by the mock, all the set conditions have to match
+type RequestMatch struct {
+	Query   map[string]string
+	Headers map[string]string
+	// Path is the json path of the body compared with the Value, any value matches when Value is nil
+	Path  string
+	Value any
+}
+
+type matchedHandler struct {
+	Handler
+	match    RequestMatch
+	fallback Handler
+}
+
+// NewMatchedHandler serves the matching requests with the handler and the others with the fallback,
+// nil fallback responds with not found
+func NewMatchedHandler(handler Handler, match RequestMatch, fallback Handler) Handler {
+	return &matchedHandler{Handler: handler, match: match, fallback: fallback}
+}
+
+func (mh *matchedHandler) Respond(c *gin.Context) {
+	if mh.match.matches(c) {
+		mh.Handler.Respond(c)
+		return
+	}
+	if mh.fallback == nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	mh.fallback.Respond(c)
+}
+
+func (m *RequestMatch) matches(c *gin.Context) bool {
+	for key, value := range m.Query {
+		if c.Query(key) != value {
+			return false
+		}
+	}
+	for key, value := range m.Headers {
+		if c.GetHeader(key) != value {
+			return false
+		}
+	}
+	if len(m.Path) == 0 {
+		return true
+	}
+	content, err := readBody(c)
+	if err != nil {
+		return false
+	}
+	var body any
+	if err := json.Unmarshal(content, &body); err != nil {
+		return false
+	}
+	value, err := jsonpath.Get(m.Path, body)
+	if err != nil {
+		return false
+	}
+	return m.Value == nil || jsonEqual(value, m.Value)
+}
+
+// readBody reads the body once and restores it, so it can be proxied or mapped afterwards
+func readBody(c *gin.Context) ([]byte, error) {
+	if cached, ok := c.Get(gin.BodyBytesKey); ok {
+		if content, ok := cached.([]byte); ok {
+			return content, nil
+		}
+	}
+	if c.Request.Body == nil {
+		return nil, nil
+	}
+	content, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(content))
+	c.Set(gin.BodyBytesKey, content)
+	return content, nil
+}
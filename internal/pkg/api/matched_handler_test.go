@@ -0,0 +1,110 @@
+package api_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/api/internal/mocks"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchedHandler_Respond(t *testing.T) {
+	t.Parallel()
+	match := api.RequestMatch{
+		Query:   map[string]string{"mode": "fake"},
+		Headers: map[string]string{"X-Tenant": "acme"},
+		Path:    "$.order.id",
+		Value:   42,
+	}
+	testCases := []struct {
+		name         string
+		url          string
+		headers      map[string]string
+		body         string
+		noFallback   bool
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "matching request mocked",
+			url:          "/orders?mode=fake",
+			headers:      map[string]string{"X-Tenant": "acme"},
+			body:         `{"order":{"id":42}}`,
+			expectedCode: http.StatusOK,
+			expectedBody: "mocked",
+		},
+		{
+			name:         "request with other query proxied with its body",
+			url:          "/orders?mode=real",
+			headers:      map[string]string{"X-Tenant": "acme"},
+			body:         `{"order":{"id":42}}`,
+			expectedCode: http.StatusAccepted,
+			expectedBody: `upstream {"order":{"id":42}}`,
+		},
+		{
+			name:         "request with other body value proxied",
+			url:          "/orders?mode=fake",
+			headers:      map[string]string{"X-Tenant": "acme"},
+			body:         `{"order":{"id":7}}`,
+			expectedCode: http.StatusAccepted,
+			expectedBody: `upstream {"order":{"id":7}}`,
+		},
+		{
+			name:         "request without header proxied",
+			url:          "/orders?mode=fake",
+			body:         `{"order":{"id":42}}`,
+			expectedCode: http.StatusAccepted,
+			expectedBody: `upstream {"order":{"id":42}}`,
+		},
+		{
+			name:         "not found without fallback",
+			url:          "/orders",
+			body:         `not json`,
+			noFallback:   true,
+			expectedCode: http.StatusNotFound,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				body, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				rw.WriteHeader(http.StatusAccepted)
+				_, _ = rw.Write([]byte("upstream " + string(body)))
+			}))
+			defer server.Close()
+			hm := mocks.NewHandlerMock(t)
+			hm.On("Respond", mock.AnythingOfType("*gin.Context")).Run(func(args mock.Arguments) {
+				args.Get(0).(*gin.Context).String(http.StatusOK, "mocked")
+			}).Return(nil).Maybe()
+			var fallback api.Handler
+			if !tc.noFallback {
+				fallback = api.NewStaticProxy(
+					http.MethodPost, "/orders", http.MethodPost, newUpstreams(t, server.URL),
+					api.ProxyHeaders{}, nil, nil, nil, nil, logger.NewTestLogger(),
+				)
+			}
+			handler := api.NewMatchedHandler(hm, match, fallback)
+
+			rr := CreateTestResponseRecorder()
+			c, _ := gin.CreateTestContext(rr)
+			c.Request = httptest.NewRequest(http.MethodPost, tc.url, strings.NewReader(tc.body))
+			for key, value := range tc.headers {
+				c.Request.Header.Set(key, value)
+			}
+			handler.Respond(c)
+			require.Equal(t, tc.expectedCode, rr.Code)
+			require.Equal(t, tc.expectedBody, rr.Body.String())
+		})
+	}
+}
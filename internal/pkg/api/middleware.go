@@ -22,7 +22,7 @@ func GinPayloadLoggerMiddleware(logger logger.Logger) gin.HandlerFunc {
 			logger.Infof("[RequestLog] url: %s\nerror: %v\n", c.Request.URL, err)
 		} else {
 			c.Request.Body = io.NopCloser(&buf)
-			logger.Infof("[RequestLog] url: %s %s", c.Request.Method, c.Request.URL)
+			logger.Infof("[RequestLog] url: %s %s %s", c.Request.Method, c.Request.URL, c.Request.Proto)
 			logger.Infof("[RequestLog] body: %s", string(body))
 			logger.Infof("[RequestLog] headers: %v", c.Request.Header)
 		}
@@ -57,6 +57,10 @@ func (w bodyLogWriter) Write(b []byte) (int, error) {
 	return w.ResponseWriter.Write(b)
 }
 
+func (w bodyLogWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
 func GinResponseLogMiddleware(logger logger.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		blw := &bodyLogWriter{body: bytes.NewBufferString(""), ResponseWriter: c.Writer}
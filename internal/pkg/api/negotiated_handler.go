@@ -0,0 +1,185 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/vimek-go/server-faker/internal/pkg/encoders"
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/tools"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+var ErrNotAcceptable = errors.New("none of the accepted formats is available")
+
+// mediaTypeAliases lists other media types clients use for the formats
+var mediaTypeAliases = map[string][]string{
+	"application/xml":        {"text/xml"},
+	"application/yaml":       {"application/x-yaml", "text/yaml", "text/x-yaml"},
+	"application/x-msgpack":  {"application/msgpack"},
+	"application/x-protobuf": {"application/protobuf", "application/vnd.google.protobuf"},
+}
+
+// negotiatedHandler renders the same generated value in the format requested by the Accept header
+type negotiatedHandler struct {
+	baseResponseHandler
+	valuer values.Valuer
+	// offered media types with the default format first
+	offered        []string
+	encoders       map[string]encoders.Encoder
+	defaultEncoder encoders.Encoder
+	strict         bool
+}
+
+func NewNegotiatedHandler(
+	defaultFormat enums.ResponseFormat,
+	formats []enums.ResponseFormat,
+	strict bool,
+	method, url string,
+	responseCode int,
+	valuer values.Valuer,
+	options encoders.Options,
+	logger logger.Logger,
+) (ResponseHandler, error) {
+	baseHandler := newBaseResponseHandler(method, url, responseCode, logger)
+	if !tools.ArrayContains(defaultFormat, formats) {
+		formats = append([]enums.ResponseFormat{defaultFormat}, formats...)
+	}
+
+	handler := &negotiatedHandler{
+		baseResponseHandler: baseHandler,
+		valuer:              valuer,
+		encoders:            make(map[string]encoders.Encoder),
+		strict:              strict,
+	}
+	for _, format := range formats {
+		encoder, err := encoders.New(format, options)
+		if err != nil {
+			return nil, errors.Wrapf(
+				ErrNotSupportedFormat,
+				"format %s is not supported in dynamic endpoint",
+				format,
+			)
+		}
+		mediaTypes := append([]string{encoder.ContentType()}, mediaTypeAliases[encoder.ContentType()]...)
+		if format == defaultFormat {
+			handler.defaultEncoder = encoder
+			handler.offered = append(mediaTypes, handler.offered...)
+		} else {
+			handler.offered = append(handler.offered, mediaTypes...)
+		}
+		for _, mediaType := range mediaTypes {
+			handler.encoders[mediaType] = encoder
+		}
+	}
+	return handler, nil
+}
+
+func (nh *negotiatedHandler) Respond(c *gin.Context) {
+	c.Header("Vary", "Accept")
+	encoder, ok := nh.encoders[negotiate(c.GetHeader("Accept"), nh.offered)]
+	if !ok {
+		if nh.strict {
+			RespondWithNotAcceptable(c, errors.Wrapf(
+				ErrNotAcceptable,
+				"accepted: %s, available: %v",
+				c.GetHeader("Accept"),
+				nh.offered,
+			))
+			return
+		}
+		encoder = nh.defaultEncoder
+	}
+
+	response, ok := generateResponse(c, nh.valuer, nh.Logger)
+	if !ok {
+		return
+	}
+	body, err := encoder.Encode(response)
+	if err != nil {
+		nh.Logger.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	c.Data(nh.Code, encoder.ContentType(), body)
+}
+
+// acceptedRange is a media range of the Accept header with its weight
+type acceptedRange struct {
+	mediaType string
+	quality   float64
+}
+
+// negotiate returns the offered media type with the highest weight in the Accept header
+// the earlier offered type wins the ties, so the default format is preferred
+func negotiate(accept string, offered []string) string {
+	if len(strings.TrimSpace(accept)) == 0 {
+		return offered[0]
+	}
+	ranges := parseAccept(accept)
+	best, bestQuality := "", 0.0
+	for _, mediaType := range offered {
+		if quality := rangeQuality(ranges, mediaType); quality > bestQuality {
+			best, bestQuality = mediaType, quality
+		}
+	}
+	return best
+}
+
+func parseAccept(accept string) []acceptedRange {
+	parts := strings.Split(accept, ",")
+	rval := make([]acceptedRange, 0, len(parts))
+	for _, part := range parts {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+		if len(mediaType) == 0 {
+			continue
+		}
+		quality := 1.0
+		for _, param := range params[1:] {
+			name, value, found := strings.Cut(param, "=")
+			if !found || !strings.EqualFold(strings.TrimSpace(name), "q") {
+				continue
+			}
+			if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				quality = q
+			}
+		}
+		rval = append(rval, acceptedRange{mediaType: mediaType, quality: quality})
+	}
+	return rval
+}
+
+// rangeQuality returns the weight of the most specific range matching the media type
+// zero means the media type is not accepted
+func rangeQuality(ranges []acceptedRange, mediaType string) float64 {
+	const (
+		exactMatch = iota + 1
+		subtypeWildcard
+		fullWildcard
+	)
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	quality, matched := 0.0, 0
+	for _, r := range ranges {
+		var match int
+		switch r.mediaType {
+		case mediaType:
+			match = exactMatch
+		case mainType + "/*":
+			match = subtypeWildcard
+		case "*/*", "*":
+			match = fullWildcard
+		default:
+			continue
+		}
+		if matched == 0 || match < matched {
+			quality, matched = r.quality, match
+		}
+	}
+	return quality
+}
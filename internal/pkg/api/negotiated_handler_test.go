@@ -0,0 +1,147 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/encoders"
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiatedHandler_Respond(t *testing.T) {
+	t.Parallel()
+	formats := []enums.ResponseFormat{
+		enums.ResponseFormats.XML(),
+		enums.ResponseFormats.YAML(),
+	}
+	testCases := []struct {
+		name           string
+		accept         string
+		strict         bool
+		expectedStatus int
+		expectedType   string
+		expectedBody   string
+	}{
+		{
+			name:           "default format without accept header",
+			expectedStatus: http.StatusOK,
+			expectedType:   "application/json",
+			expectedBody:   `{"key":"value"}`,
+		},
+		{
+			name:           "wildcard accept uses default format",
+			accept:         "*/*",
+			expectedStatus: http.StatusOK,
+			expectedType:   "application/json",
+			expectedBody:   `{"key":"value"}`,
+		},
+		{
+			name:           "xml requested with alias",
+			accept:         "text/xml",
+			expectedStatus: http.StatusOK,
+			expectedType:   "application/xml",
+			expectedBody:   `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<response><key>value</key></response>`,
+		},
+		{
+			name:           "first matching accepted format",
+			accept:         "text/html, application/yaml;q=0.9, application/xml;q=0.8",
+			expectedStatus: http.StatusOK,
+			expectedType:   "application/yaml",
+			expectedBody:   "key: value\n",
+		},
+		{
+			name:           "highest weight wins over the order",
+			accept:         "application/xml;q=0.5, application/yaml",
+			expectedStatus: http.StatusOK,
+			expectedType:   "application/yaml",
+			expectedBody:   "key: value\n",
+		},
+		{
+			name:           "most specific range decides the weight",
+			accept:         "application/*;q=0.1, text/yaml;q=0.5, */*;q=0.2",
+			expectedStatus: http.StatusOK,
+			expectedType:   "application/yaml",
+			expectedBody:   "key: value\n",
+		},
+		{
+			name:           "excluded default format",
+			accept:         "application/json;q=0, */*",
+			expectedStatus: http.StatusOK,
+			expectedType:   "application/xml",
+			expectedBody:   `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<response><key>value</key></response>`,
+		},
+		{
+			name:           "not matching format falls back to default",
+			accept:         "text/html",
+			expectedStatus: http.StatusOK,
+			expectedType:   "application/json",
+			expectedBody:   `{"key":"value"}`,
+		},
+		{
+			name:           "not matching format with strict accept",
+			accept:         "text/html",
+			strict:         true,
+			expectedStatus: http.StatusNotAcceptable,
+		},
+		{
+			name:           "all formats excluded with strict accept",
+			accept:         "*/*;q=0",
+			strict:         true,
+			expectedStatus: http.StatusNotAcceptable,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			handler, err := api.NewNegotiatedHandler(
+				enums.ResponseFormats.JSON(),
+				formats,
+				tc.strict,
+				http.MethodGet,
+				"/test",
+				http.StatusOK,
+				values.NewStaticValuer("key", "value"),
+				encoders.Options{},
+				logger.NewTestLogger(),
+			)
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rr)
+			c.Request = httptest.NewRequest(http.MethodGet, "/test", nil)
+			if len(tc.accept) > 0 {
+				c.Request.Header.Set("Accept", tc.accept)
+			}
+			handler.Respond(c)
+			require.Equal(t, tc.expectedStatus, rr.Code)
+			require.Equal(t, "Accept", rr.Header().Get("Vary"))
+			if tc.expectedStatus == http.StatusOK {
+				require.Equal(t, tc.expectedType, rr.Header().Get("Content-Type"))
+				require.Equal(t, tc.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestNewNegotiatedHandler_NotSupportedFormat(t *testing.T) {
+	t.Parallel()
+	_, err := api.NewNegotiatedHandler(
+		enums.ResponseFormats.JSON(),
+		[]enums.ResponseFormat{enums.ResponseFormats.Bytes()},
+		false,
+		http.MethodGet,
+		"/test",
+		http.StatusOK,
+		values.NewStaticValuer("key", "value"),
+		encoders.Options{},
+		logger.NewTestLogger(),
+	)
+	require.ErrorIs(t, err, api.ErrNotSupportedFormat)
+}
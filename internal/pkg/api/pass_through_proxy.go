@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httputil"
+
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// passThroughProxy forwards the requests to the routes which are not mocked,
+// the method, path and query of the request are kept
+type passThroughProxy struct {
+	baseProxyHandler
+	proxyURL string
+	proxy    *httputil.ReverseProxy
+}
+
+// NewPassThroughProxy forwards the requests to the upstream, the path of the request is appended to the proxy url
+func NewPassThroughProxy(proxyURL string, headers map[string]string, logger logger.Logger) (Handler, error) {
+	remote, err := parseProxyURL(proxyURL)
+	if err != nil {
+		return nil, err
+	}
+	ptp := &passThroughProxy{
+		baseProxyHandler: baseProxyHandler{
+			handlerMethod: "*",
+			handlerURL:    "/*",
+			headers:       ProxyHeaders{Set: headers},
+			logger:        logger,
+		},
+		proxyURL: proxyURL,
+	}
+	proxy := httputil.NewSingleHostReverseProxy(remote)
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = remote.Host
+		ptp.prepareRequest(req, nil)
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		logger.Errorf("error passing %s %s through to %s %+v", req.Method, req.URL.Path, proxyURL, err)
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	ptp.proxy = proxy
+	return ptp, nil
+}
+
+func (ptp *passThroughProxy) Respond(c *gin.Context) {
+	ptp.logger.Infof("passing %s %s through to %s", c.Request.Method, c.Request.URL, ptp.proxyURL)
+	ptp.proxy.ServeHTTP(c.Writer, c.Request)
+}
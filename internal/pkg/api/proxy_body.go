@@ -0,0 +1,250 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/vimek-go/server-faker/internal/pkg/encoders"
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrUnsupportedContentType = errors.New("content type of the proxy body not supported")
+	ErrMergingBody            = errors.New("cannot merge the generated body into the incoming one")
+)
+
+const (
+	defaultProxyContentType = "application/json"
+	formContentType         = "application/x-www-form-urlencoded"
+	multipartContentType    = "multipart/form-data"
+	// xmlBodyRoot is the document element of the xml body sent upstream
+	xmlBodyRoot = "request"
+	// multipartMemory limits the memory parsing the incoming multipart body, the bigger files are kept on disk
+	multipartMemory = 32 << 20
+)
+
+// ProxyBody generates the body of the request sent upstream
+type ProxyBody interface {
+	// Generate returns the encoded body and its content type, the empty content type keeps the incoming one
+	Generate(c *gin.Context) (body []byte, contentType string, err error)
+}
+
+type proxyBody struct {
+	valuer      values.Valuer
+	contentType string
+	// encoder is nil for the multipart body, its content type depends on the boundary
+	encoder encoders.Encoder
+	merge   bool
+}
+
+// incomingBody is the decoded body of the client request, the files are sent only in the multipart body
+type incomingBody struct {
+	value any
+	files map[string][]*multipart.FileHeader
+}
+
+// NewProxyBody encodes the generated payload as the content type, json when empty,
+// the merged payload is added to the incoming body instead of replacing it
+func NewProxyBody(valuer values.Valuer, contentType string, merge bool) (ProxyBody, error) {
+	if len(contentType) == 0 {
+		contentType = defaultProxyContentType
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, errors.Wrapf(ErrUnsupportedContentType, "%s: %v", contentType, err)
+	}
+	body := &proxyBody{valuer: valuer, contentType: contentType, merge: merge}
+	switch {
+	case isJSONMediaType(mediaType):
+		body.encoder, err = encoders.New(enums.ResponseFormats.JSON(), encoders.Options{})
+	case mediaType == formContentType:
+		body.encoder, err = encoders.New(enums.ResponseFormats.Form(), encoders.Options{})
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		// the xml body of the client cannot be decoded to the object without guessing the arrays and the types
+		if merge {
+			return nil, errors.Wrapf(ErrUnsupportedContentType, "%s cannot be merged", contentType)
+		}
+		body.encoder = encoders.NewXMLEncoder(encoders.XMLOptions{Root: xmlBodyRoot})
+	case mediaType == multipartContentType:
+	default:
+		return nil, errors.Wrapf(ErrUnsupportedContentType, "%s", contentType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (pb *proxyBody) Generate(c *gin.Context) ([]byte, string, error) {
+	var value any
+	if pb.valuer != nil && !pb.valuer.IsNil() {
+		generated, err := pb.valuer.Generate(c)
+		if err != nil {
+			return nil, "", err
+		}
+		value = generated
+	}
+	var incoming incomingBody
+	if pb.merge {
+		var err error
+		if incoming, err = decodeIncomingBody(c); err != nil {
+			return nil, "", err
+		}
+		if value, err = mergeIncoming(incoming.value, value); err != nil {
+			return nil, "", err
+		}
+	}
+	if value == nil && len(incoming.files) == 0 {
+		// nothing generated, the upstream receives the body of the client
+		return nil, "", nil
+	}
+	if pb.encoder == nil {
+		return pb.encodeMultipart(value, incoming.files)
+	}
+	if len(incoming.files) > 0 {
+		return nil, "", errors.Wrapf(ErrMergingBody, "the files are sent only as %s", multipartContentType)
+	}
+	body, err := pb.encoder.Encode(value)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, pb.contentType, nil
+}
+
+// encodeMultipart writes the fields the way the form is encoded, followed by the files of the incoming body
+func (pb *proxyBody) encodeMultipart(value any, files map[string][]*multipart.FileHeader) ([]byte, string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	if value != nil {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, "", errors.Wrapf(encoders.ErrEncodingFailed, "multipart: only objects can be encoded, got %T", value)
+		}
+		fields := encoders.FormValues(object)
+		for _, key := range sortedKeys(fields) {
+			for _, field := range fields[key] {
+				if err := writer.WriteField(key, field); err != nil {
+					return nil, "", errors.Wrapf(encoders.ErrEncodingFailed, "multipart: %v", err)
+				}
+			}
+		}
+	}
+	for _, key := range sortedKeys(files) {
+		for _, file := range files[key] {
+			if err := writeFile(writer, file); err != nil {
+				return nil, "", errors.Wrapf(encoders.ErrEncodingFailed, "multipart: %v", err)
+			}
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", errors.Wrapf(encoders.ErrEncodingFailed, "multipart: %v", err)
+	}
+	return buf.Bytes(), writer.FormDataContentType(), nil
+}
+
+func writeFile(writer *multipart.Writer, file *multipart.FileHeader) error {
+	content, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	part, err := writer.CreatePart(file.Header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, content)
+	return err
+}
+
+// decodeIncomingBody reads the body of the client request by its content type,
+// the body is cached in the context, so the body mappers can still read it
+func decodeIncomingBody(c *gin.Context) (incomingBody, error) {
+	raw, err := readBody(c)
+	if err != nil {
+		return incomingBody{}, errors.Wrapf(ErrMergingBody, "reading incoming body: %v", err)
+	}
+	if len(raw) == 0 {
+		return incomingBody{}, nil
+	}
+	mediaType, params, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if err != nil {
+		return incomingBody{}, errors.Wrapf(ErrMergingBody, "incoming content type: %v", err)
+	}
+	switch {
+	case isJSONMediaType(mediaType):
+		var value any
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return incomingBody{}, errors.Wrapf(ErrMergingBody, "incoming json: %v", err)
+		}
+		return incomingBody{value: value}, nil
+	case mediaType == formContentType:
+		form, err := url.ParseQuery(string(raw))
+		if err != nil {
+			return incomingBody{}, errors.Wrapf(ErrMergingBody, "incoming form: %v", err)
+		}
+		return incomingBody{value: formObject(form)}, nil
+	case mediaType == multipartContentType:
+		form, err := multipart.NewReader(bytes.NewReader(raw), params["boundary"]).ReadForm(multipartMemory)
+		if err != nil {
+			return incomingBody{}, errors.Wrapf(ErrMergingBody, "incoming multipart: %v", err)
+		}
+		return incomingBody{value: formObject(form.Value), files: form.File}, nil
+	}
+	return incomingBody{}, errors.Wrapf(ErrMergingBody, "incoming content type %s not supported", mediaType)
+}
+
+// formObject converts the form fields to the object, the repeated fields are the arrays
+func formObject(form map[string][]string) map[string]any {
+	object := make(map[string]any, len(form))
+	for key, fields := range form {
+		if len(fields) == 1 {
+			object[key] = fields[0]
+			continue
+		}
+		array := make([]any, len(fields))
+		for i := range fields {
+			array[i] = fields[i]
+		}
+		object[key] = array
+	}
+	return object
+}
+
+// mergeIncoming adds the generated value to the incoming one, only the objects can be merged
+func mergeIncoming(incoming, generated any) (any, error) {
+	if incoming == nil {
+		return generated, nil
+	}
+	if generated == nil {
+		return incoming, nil
+	}
+	_, incomingOK := incoming.(map[string]any)
+	_, generatedOK := generated.(map[string]any)
+	if !incomingOK || !generatedOK {
+		return nil, errors.Wrapf(ErrMergingBody, "only objects can be merged, got %T and %T", incoming, generated)
+	}
+	return mergeFields(incoming, generated), nil
+}
+
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == defaultProxyContentType || strings.HasSuffix(mediaType, "+json")
+}
+
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,213 @@
+package api_test
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newBodyValuer() values.Valuer {
+	return values.NewObjectValuer("", []values.Valuer{
+		values.NewStaticValuer("name", "book"),
+		values.NewObjectValuer("meta", []values.Valuer{values.NewStaticValuer("source", "faker")}),
+	})
+}
+
+func TestNewProxyBody(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name          string
+		contentType   string
+		merge         bool
+		expectedError error
+	}{
+		{name: "json by default"},
+		{name: "json suffix", contentType: "application/vnd.api+json"},
+		{name: "form", contentType: "application/x-www-form-urlencoded"},
+		{name: "xml with charset", contentType: "text/xml; charset=utf-8"},
+		{name: "multipart", contentType: "multipart/form-data"},
+		{name: "merged form", contentType: "application/x-www-form-urlencoded", merge: true},
+		{
+			name:          "merged xml",
+			contentType:   "application/xml",
+			merge:         true,
+			expectedError: api.ErrUnsupportedContentType,
+		},
+		{name: "not supported", contentType: "application/msgpack", expectedError: api.ErrUnsupportedContentType},
+		{name: "invalid", contentType: "application/", expectedError: api.ErrUnsupportedContentType},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := api.NewProxyBody(newBodyValuer(), tc.contentType, tc.merge)
+			require.ErrorIs(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestProxyBody_Generate(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name                string
+		valuer              values.Valuer
+		contentType         string
+		merge               bool
+		incoming            func(t *testing.T) (body, contentType string)
+		expectedBody        string
+		expectedContentType string
+		expectedError       error
+	}{
+		{
+			name:                "json",
+			valuer:              newBodyValuer(),
+			expectedBody:        `{"meta":{"source":"faker"},"name":"book"}`,
+			expectedContentType: "application/json",
+		},
+		{
+			name:                "form",
+			valuer:              newBodyValuer(),
+			contentType:         "application/x-www-form-urlencoded",
+			expectedBody:        "meta%5Bsource%5D=faker&name=book",
+			expectedContentType: "application/x-www-form-urlencoded",
+		},
+		{
+			name:        "xml",
+			valuer:      newBodyValuer(),
+			contentType: "application/xml",
+			expectedBody: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<request><meta><source>faker</source></meta><name>book</name></request>`,
+			expectedContentType: "application/xml",
+		},
+		{
+			name:        "nothing generated",
+			contentType: "application/xml",
+		},
+		{
+			name:        "merged into json",
+			valuer:      newBodyValuer(),
+			merge:       true,
+			contentType: "application/json",
+			incoming: func(*testing.T) (string, string) {
+				return `{"id":1,"name":"pen","meta":{"trace":"abc"}}`, "application/json"
+			},
+			expectedBody:        `{"id":1,"meta":{"source":"faker","trace":"abc"},"name":"book"}`,
+			expectedContentType: "application/json",
+		},
+		{
+			name:        "merged json into form",
+			valuer:      values.NewObjectValuer("", []values.Valuer{values.NewStaticValuer("status", "paid")}),
+			merge:       true,
+			contentType: "application/x-www-form-urlencoded",
+			incoming: func(*testing.T) (string, string) {
+				return `{"id":"7","tags":["a","b"]}`, "application/json; charset=utf-8"
+			},
+			expectedBody:        "id=7&status=paid&tags=a&tags=b",
+			expectedContentType: "application/x-www-form-urlencoded",
+		},
+		{
+			name:        "merged form without generated values",
+			merge:       true,
+			contentType: "application/json",
+			incoming: func(*testing.T) (string, string) {
+				return "id=7&tags=a&tags=b", "application/x-www-form-urlencoded"
+			},
+			expectedBody:        `{"id":"7","tags":["a","b"]}`,
+			expectedContentType: "application/json",
+		},
+		{
+			name:          "merging array",
+			valuer:        newBodyValuer(),
+			merge:         true,
+			incoming:      func(*testing.T) (string, string) { return `[1,2]`, "application/json" },
+			expectedError: api.ErrMergingBody,
+		},
+		{
+			name:          "merging not supported content type",
+			valuer:        newBodyValuer(),
+			merge:         true,
+			incoming:      func(*testing.T) (string, string) { return `<id>1</id>`, "application/xml" },
+			expectedError: api.ErrMergingBody,
+		},
+		{
+			name:          "merging files into json",
+			valuer:        newBodyValuer(),
+			merge:         true,
+			incoming:      multipartBody,
+			expectedError: api.ErrMergingBody,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPatch, "/test", nil)
+			if tc.incoming != nil {
+				body, contentType := tc.incoming(t)
+				c.Request = httptest.NewRequest(http.MethodPatch, "/test", strings.NewReader(body))
+				c.Request.Header.Set("Content-Type", contentType)
+			}
+			pb, err := api.NewProxyBody(tc.valuer, tc.contentType, tc.merge)
+			require.NoError(t, err)
+
+			body, contentType, err := pb.Generate(c)
+			require.ErrorIs(t, err, tc.expectedError)
+			require.Equal(t, tc.expectedBody, string(body))
+			require.Equal(t, tc.expectedContentType, contentType)
+		})
+	}
+}
+
+func TestProxyBody_GenerateMultipart(t *testing.T) {
+	t.Parallel()
+	incoming, incomingType := multipartBody(t)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(incoming))
+	c.Request.Header.Set("Content-Type", incomingType)
+	pb, err := api.NewProxyBody(newBodyValuer(), "multipart/form-data", true)
+	require.NoError(t, err)
+
+	body, contentType, err := pb.Generate(c)
+	require.NoError(t, err)
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	require.NoError(t, err)
+	require.Equal(t, "multipart/form-data", mediaType)
+	form, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(1 << 20)
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{
+		"description": {"cover"}, "meta[source]": {"faker"}, "name": {"book"},
+	}, form.Value)
+	require.Len(t, form.File["file"], 1)
+	require.Equal(t, "cover.png", form.File["file"][0].Filename)
+	file, err := form.File["file"][0].Open()
+	require.NoError(t, err)
+	content, err := io.ReadAll(file)
+	require.NoError(t, err)
+	require.Equal(t, "png", string(content))
+}
+
+func multipartBody(t *testing.T) (string, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	require.NoError(t, writer.WriteField("description", "cover"))
+	require.NoError(t, writer.WriteField("name", "pen"))
+	file, err := writer.CreateFormFile("file", "cover.png")
+	require.NoError(t, err)
+	_, err = file.Write([]byte("png"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return buf.String(), writer.FormDataContentType()
+}
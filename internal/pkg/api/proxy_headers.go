@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+)
+
+// ProxyHeaders modify the headers of the incoming request before it is sent upstream,
+// the incoming headers are forwarded, renamed and removed before the new headers are set
+type ProxyHeaders struct {
+	// Forward limits the incoming headers sent upstream, all of them are sent when empty
+	Forward []string
+	// Rename sends the incoming headers under the new names, the renamed headers are always forwarded
+	Rename map[string]string
+	Remove []string
+	// Set are the static headers
+	Set map[string]string
+	// Params generate the header values from the request, e.g. the tokens derived from the caller headers
+	Params map[string]values.Valuer
+}
+
+// apply modifies the headers of the request sent upstream
+func (ph *ProxyHeaders) apply(req *http.Request, generated http.Header) {
+	incoming := req.Header
+	if len(ph.Forward) > 0 {
+		req.Header = make(http.Header, len(ph.Forward))
+		for _, key := range ph.Forward {
+			if value := incoming.Values(key); len(value) > 0 {
+				req.Header[http.CanonicalHeaderKey(key)] = value
+			}
+		}
+	} else {
+		req.Header = incoming.Clone()
+	}
+	for from, to := range ph.Rename {
+		if value := incoming.Values(from); len(value) > 0 {
+			req.Header.Del(from)
+			req.Header[http.CanonicalHeaderKey(to)] = value
+		}
+	}
+	for _, key := range ph.Remove {
+		req.Header.Del(key)
+	}
+	for key, value := range ph.Set {
+		req.Header.Set(key, value)
+	}
+	for key, value := range generated {
+		req.Header[key] = value
+	}
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+)
+
+const (
+	// mirrorTimeout limits the mirrored request and waiting for the upstream response it is compared with
+	mirrorTimeout = 30 * time.Second
+	// defaultCompareLimit is the number of the bytes of the bodies compared when the limit is not set
+	defaultCompareLimit = 1 << 20
+)
+
+// Mirror sends the copies of the proxied requests to the secondary upstream, its responses are discarded
+type Mirror interface {
+	// Send copies the request prepared for the upstream to the mirror, the returned compare logs
+	// the difference between the upstream response and the mirrored one
+	Send(req *http.Request) (compare func(response *http.Response, err error))
+}
+
+type proxyMirror struct {
+	remote       *url.URL
+	headers      map[string]string
+	compareLimit int
+	transport    http.RoundTripper
+	logger       logger.Logger
+}
+
+// mirrorResult holds at most one byte more than the compare limit, to know whether the body was truncated
+type mirrorResult struct {
+	status int
+	body   []byte
+	err    error
+}
+
+// NewMirror mirrors the requests to the url, the path of the request is appended to the path of the url,
+// only the first compareLimit bytes of the bodies are compared, 1 MiB when not set,
+// nil transport uses the default transport
+func NewMirror(
+	mirrorURL string,
+	headers map[string]string,
+	compareLimit int,
+	transport http.RoundTripper,
+	logger logger.Logger,
+) (Mirror, error) {
+	remote, err := parseProxyURL(mirrorURL)
+	if err != nil {
+		return nil, err
+	}
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if compareLimit <= 0 {
+		compareLimit = defaultCompareLimit
+	}
+	return &proxyMirror{
+		remote:       remote,
+		headers:      headers,
+		compareLimit: compareLimit,
+		transport:    transport,
+		logger:       logger,
+	}, nil
+}
+
+func (pm *proxyMirror) Send(req *http.Request) func(response *http.Response, err error) {
+	body, err := replayableBody(req)
+	if err != nil {
+		pm.logger.Errorf("error mirroring %s %s %+v", req.Method, req.URL, err)
+		return func(*http.Response, error) {}
+	}
+	// the mirrored request outlives the proxied one, it is not canceled with the client
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(req.Context()), mirrorTimeout)
+	mirrored := req.Clone(ctx)
+	if mirrored.Body, err = body(); err != nil {
+		cancel()
+		pm.logger.Errorf("error mirroring %s %s %+v", req.Method, req.URL, err)
+		return func(*http.Response, error) {}
+	}
+	mirrored.RequestURI = ""
+	mirrored.Host = pm.remote.Host
+	mirrored.URL.Scheme = pm.remote.Scheme
+	mirrored.URL.Host = pm.remote.Host
+	mirrored.URL.Path = pm.remote.JoinPath(req.URL.Path).Path
+	mirrored.URL.RawPath = ""
+	for key, value := range pm.headers {
+		mirrored.Header.Set(key, value)
+	}
+
+	upstream := make(chan mirrorResult, 1)
+	go func() {
+		defer cancel()
+		result := pm.roundTrip(mirrored)
+		select {
+		case primary := <-upstream:
+			pm.compare(mirrored, primary, result)
+		case <-ctx.Done():
+			pm.logger.Infof("mirror %s %s not compared, upstream did not respond", mirrored.Method, mirrored.URL)
+		}
+	}()
+	var once sync.Once
+	return func(response *http.Response, err error) {
+		once.Do(func() {
+			result := mirrorResult{err: err}
+			if response != nil {
+				result.status = response.StatusCode
+				result.body, result.err = pm.readCompared(response.Body)
+				// the client still receives the whole body, the compared part is read again before the rest
+				response.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.MultiReader(bytes.NewReader(result.body), response.Body), response.Body}
+			}
+			upstream <- result
+		})
+	}
+}
+
+func (pm *proxyMirror) roundTrip(req *http.Request) mirrorResult {
+	response, err := pm.transport.RoundTrip(req)
+	if err != nil {
+		return mirrorResult{err: err}
+	}
+	defer response.Body.Close()
+	body, err := pm.readCompared(response.Body)
+	return mirrorResult{status: response.StatusCode, body: body, err: err}
+}
+
+// readCompared reads one byte more than the compare limit, the longer bodies are truncated while comparing
+func (pm *proxyMirror) readCompared(body io.Reader) ([]byte, error) {
+	return io.ReadAll(io.LimitReader(body, int64(pm.compareLimit)+1))
+}
+
+// truncate cuts the body to the compare limit and reports whether it was longer
+func (pm *proxyMirror) truncate(body []byte) ([]byte, bool) {
+	if len(body) > pm.compareLimit {
+		return body[:pm.compareLimit], true
+	}
+	return body, false
+}
+
+// compare logs the difference of the responses, the json bodies are compared regardless of the formatting,
+// the truncated bodies are compared byte by byte
+func (pm *proxyMirror) compare(req *http.Request, upstream, mirrored mirrorResult) {
+	upstreamBody, upstreamTruncated := pm.truncate(upstream.body)
+	mirroredBody, mirroredTruncated := pm.truncate(mirrored.body)
+	truncated := upstreamTruncated || mirroredTruncated
+	if truncated && upstream.err == nil && mirrored.err == nil {
+		pm.logger.Infof("mirror %s %s compared only the first %d bytes of the bodies", req.Method, req.URL, pm.compareLimit)
+	}
+	switch {
+	case mirrored.err != nil:
+		pm.logger.Warnf("mirror %s %s failed: %v", req.Method, req.URL, mirrored.err)
+	case upstream.err != nil:
+		pm.logger.Warnf(
+			"mirror %s %s responded with %d, upstream failed: %v", req.Method, req.URL, mirrored.status, upstream.err,
+		)
+	case upstream.status != mirrored.status:
+		pm.logger.Warnf(
+			"mirror %s %s responded with %d, upstream with %d", req.Method, req.URL, mirrored.status, upstream.status,
+		)
+	case upstreamTruncated != mirroredTruncated || !bodiesEqual(upstreamBody, mirroredBody, truncated):
+		pm.logger.Warnf(
+			"mirror %s %s responded with different body\nupstream: %s\nmirror: %s",
+			req.Method, req.URL, upstreamBody, mirroredBody,
+		)
+	default:
+		pm.logger.Debugf("mirror %s %s responded as the upstream", req.Method, req.URL)
+	}
+}
+
+func bodiesEqual(first, second []byte, truncated bool) bool {
+	if bytes.Equal(first, second) {
+		return true
+	}
+	if truncated {
+		return false
+	}
+	var firstJSON, secondJSON any
+	if json.Unmarshal(first, &firstJSON) != nil || json.Unmarshal(second, &secondJSON) != nil {
+		return false
+	}
+	return jsonEqual(firstJSON, secondJSON)
+}
@@ -0,0 +1,133 @@
+package api_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMirror_Send(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name          string
+		mirrorStatus  int
+		mirrorBody    string
+		compareLimit  int
+		unreachable   bool
+		expectedLog   string
+		expectedLevel zapcore.Level
+	}{
+		{
+			name:          "same response",
+			mirrorStatus:  http.StatusCreated,
+			mirrorBody:    `{ "id": 1, "status": "new" }`,
+			expectedLog:   "responded as the upstream",
+			expectedLevel: zapcore.DebugLevel,
+		},
+		{
+			name:          "different status",
+			mirrorStatus:  http.StatusInternalServerError,
+			mirrorBody:    `{"id":1,"status":"new"}`,
+			expectedLog:   "responded with 500, upstream with 201",
+			expectedLevel: zapcore.WarnLevel,
+		},
+		{
+			name:          "different body",
+			mirrorStatus:  http.StatusCreated,
+			mirrorBody:    `{"id":1,"status":"pending"}`,
+			expectedLog:   "responded with different body",
+			expectedLevel: zapcore.WarnLevel,
+		},
+		{
+			name:          "same truncated body",
+			mirrorStatus:  http.StatusCreated,
+			mirrorBody:    `{"id":1,"status":"pending"}`,
+			compareLimit:  8,
+			expectedLog:   "compared only the first 8 bytes",
+			expectedLevel: zapcore.InfoLevel,
+		},
+		{
+			name:          "different truncated body",
+			mirrorStatus:  http.StatusCreated,
+			mirrorBody:    `{"id":2,"status":"new"}`,
+			compareLimit:  8,
+			expectedLog:   "responded with different body",
+			expectedLevel: zapcore.WarnLevel,
+		},
+		{
+			name:          "unreachable mirror",
+			unreachable:   true,
+			expectedLog:   "failed",
+			expectedLevel: zapcore.WarnLevel,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			upstream := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+				rw.WriteHeader(http.StatusCreated)
+				_, _ = rw.Write([]byte(`{"id":1,"status":"new"}`))
+			}))
+			t.Cleanup(upstream.Close)
+			mirrored := make(chan *http.Request, 1)
+			mirror := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				body, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				req.Body = io.NopCloser(strings.NewReader(string(body)))
+				mirrored <- req
+				rw.WriteHeader(tc.mirrorStatus)
+				_, _ = rw.Write([]byte(tc.mirrorBody))
+			}))
+			t.Cleanup(mirror.Close)
+			mirrorURL := mirror.URL + "/shadow"
+			if tc.unreachable {
+				mirrorURL = "http://127.0.0.1:1"
+			}
+			core, logs := observer.New(zapcore.DebugLevel)
+			m, err := api.NewMirror(
+				mirrorURL, map[string]string{"X-Mirrored": "true"}, tc.compareLimit, nil, zap.New(core).Sugar(),
+			)
+			require.NoError(t, err)
+			sp := api.NewStaticProxy(
+				http.MethodPost, "/test", http.MethodPost, newUpstreams(t, upstream.URL+"/orders"),
+				api.ProxyHeaders{}, nil, nil, nil, m, logger.NewTestLogger(),
+			)
+			rr := CreateTestResponseRecorder()
+			c, _ := gin.CreateTestContext(rr)
+			c.Request = httptest.NewRequest(http.MethodPost, "/test?page=2", strings.NewReader(`{"item":"book"}`))
+
+			sp.Respond(c)
+			require.Equal(t, http.StatusCreated, rr.Code)
+			require.Equal(t, `{"id":1,"status":"new"}`, rr.Body.String())
+			if !tc.unreachable {
+				select {
+				case req := <-mirrored:
+					require.Equal(t, http.MethodPost, req.Method)
+					require.Equal(t, "/shadow/orders", req.URL.Path)
+					require.Equal(t, "true", req.Header.Get("X-Mirrored"))
+					body, err := io.ReadAll(req.Body)
+					require.NoError(t, err)
+					require.Equal(t, `{"item":"book"}`, string(body))
+				case <-time.After(time.Second):
+					require.Fail(t, "request not mirrored")
+				}
+			}
+			require.Eventually(t, func() bool {
+				return logs.FilterMessageSnippet(tc.expectedLog).FilterLevelExact(tc.expectedLevel).Len() == 1
+			}, time.Second, 10*time.Millisecond)
+		})
+	}
+}
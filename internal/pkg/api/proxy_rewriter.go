@@ -0,0 +1,280 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidFieldPath     = errors.New("invalid field path")
+	ErrRewritingUpstream    = errors.New("failed rewriting upstream response")
+	ErrInvalidStatusRewrite = errors.New("invalid status code rewrite")
+)
+
+const (
+	fieldPathRoot     = "$"
+	fieldPathWildcard = "*"
+)
+
+// ResponseRewriter modifies the upstream response before it is copied to the client
+type ResponseRewriter interface {
+	// Prepare adjusts the request sent upstream, so the response can be rewritten
+	Prepare(req *http.Request)
+	Rewrite(c *gin.Context, response *http.Response) error
+}
+
+type responseRewriter struct {
+	status        map[int]int
+	headers       map[string]string
+	removeHeaders []string
+	deletePaths   [][]fieldPathSegment
+	override      values.Valuer
+	logger        logger.Logger
+}
+
+// fieldPathSegment is the key of the object or the index of the array, wildcard selects all the elements
+type fieldPathSegment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// NewResponseRewriter maps the upstream status codes, sets and removes the headers,
+// deletes the fields of the json body at the paths like $.user.tokens[0] or $.items[*].id
+// and merges the object generated by the override valuer into the body
+func NewResponseRewriter(
+	status map[int]int,
+	headers map[string]string,
+	removeHeaders, deletePaths []string,
+	override values.Valuer,
+	logger logger.Logger,
+) (ResponseRewriter, error) {
+	for from, to := range status {
+		if http.StatusText(from) == "" || http.StatusText(to) == "" {
+			return nil, errors.Wrapf(ErrInvalidStatusRewrite, "from %d to %d", from, to)
+		}
+	}
+	paths := make([][]fieldPathSegment, len(deletePaths))
+	for i := range deletePaths {
+		path, err := parseFieldPath(deletePaths[i])
+		if err != nil {
+			return nil, err
+		}
+		paths[i] = path
+	}
+	if override != nil && override.IsNil() {
+		override = nil
+	}
+	return &responseRewriter{
+		status:        status,
+		headers:       headers,
+		removeHeaders: removeHeaders,
+		deletePaths:   paths,
+		override:      override,
+		logger:        logger,
+	}, nil
+}
+
+func (rr *responseRewriter) Prepare(req *http.Request) {
+	if rr.rewritesBody() {
+		// the transport decompresses the body only when it asked for the compression itself
+		req.Header.Del("Accept-Encoding")
+	}
+}
+
+func (rr *responseRewriter) Rewrite(c *gin.Context, response *http.Response) error {
+	if status, ok := rr.status[response.StatusCode]; ok {
+		rr.logger.Debugf("rewriting upstream status %d to %d", response.StatusCode, status)
+		response.StatusCode = status
+		response.Status = fmt.Sprintf("%d %s", status, http.StatusText(status))
+	}
+	for _, header := range rr.removeHeaders {
+		response.Header.Del(header)
+	}
+	for key, value := range rr.headers {
+		response.Header.Set(key, value)
+	}
+	if !rr.rewritesBody() {
+		return nil
+	}
+	return rr.rewriteBody(c, response)
+}
+
+func (rr *responseRewriter) rewritesBody() bool {
+	return len(rr.deletePaths) > 0 || rr.override != nil
+}
+
+// rewriteBody leaves the body untouched when it is not json
+func (rr *responseRewriter) rewriteBody(c *gin.Context, response *http.Response) error {
+	if encoding := response.Header.Get("Content-Encoding"); encoding != "" && encoding != "identity" {
+		rr.logger.Infof("upstream body encoded with %s is not rewritten", encoding)
+		return nil
+	}
+	content, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return errors.Wrapf(ErrRewritingUpstream, "reading body: %v", err)
+	}
+	// the numbers are kept as they are, the large integers would lose precision as float64
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var body any
+	if err := decoder.Decode(&body); err != nil {
+		rr.logger.Infof("upstream body is not json, not rewritten: %v", err)
+		setResponseBody(response, content)
+		return nil
+	}
+	// the upstream mappers read the body before the fields are deleted
+	var upstream any
+	if err := json.Unmarshal(content, &upstream); err != nil {
+		return errors.Wrapf(ErrRewritingUpstream, "decoding body: %v", err)
+	}
+	values.SetUpstreamBody(c, upstream)
+
+	for _, path := range rr.deletePaths {
+		body = deleteField(body, path)
+	}
+	if rr.override != nil {
+		generated, err := rr.override.Generate(c)
+		if err != nil {
+			return errors.Wrapf(ErrRewritingUpstream, "generating override: %v", err)
+		}
+		body = mergeFields(body, generated)
+	}
+	content, err = json.Marshal(body)
+	if err != nil {
+		return errors.Wrapf(ErrRewritingUpstream, "encoding body: %v", err)
+	}
+	setResponseBody(response, content)
+	return nil
+}
+
+func setResponseBody(response *http.Response, content []byte) {
+	response.Body = io.NopCloser(bytes.NewReader(content))
+	response.ContentLength = int64(len(content))
+	response.Header.Set("Content-Length", strconv.Itoa(len(content)))
+}
+
+// mergeFields merges the generated objects into the body, other generated values replace the body
+func mergeFields(body, generated any) any {
+	bodyObject, ok := body.(map[string]any)
+	if !ok {
+		return generated
+	}
+	generatedObject, ok := generated.(map[string]any)
+	if !ok {
+		return generated
+	}
+	for key, value := range generatedObject {
+		bodyObject[key] = mergeFields(bodyObject[key], value)
+	}
+	return bodyObject
+}
+
+// deleteField returns the node without the field at the path, missing fields are skipped
+func deleteField(node any, path []fieldPathSegment) any {
+	if len(path) == 0 {
+		return node
+	}
+	segment, last := path[0], len(path) == 1
+	switch typed := node.(type) {
+	case map[string]any:
+		if segment.isIndex {
+			return node
+		}
+		if segment.wildcard {
+			if last {
+				return map[string]any{}
+			}
+			for key := range typed {
+				typed[key] = deleteField(typed[key], path[1:])
+			}
+			return typed
+		}
+		child, ok := typed[segment.key]
+		if !ok {
+			return node
+		}
+		if last {
+			delete(typed, segment.key)
+			return typed
+		}
+		typed[segment.key] = deleteField(child, path[1:])
+	case []any:
+		if segment.wildcard {
+			if last {
+				return []any{}
+			}
+			for i := range typed {
+				typed[i] = deleteField(typed[i], path[1:])
+			}
+			return typed
+		}
+		if !segment.isIndex || segment.index >= len(typed) {
+			return node
+		}
+		if last {
+			return append(typed[:segment.index:segment.index], typed[segment.index+1:]...)
+		}
+		typed[segment.index] = deleteField(typed[segment.index], path[1:])
+	}
+	return node
+}
+
+// parseFieldPath splits the path like $.items[0].name or $.items[*].name into the segments
+func parseFieldPath(path string) ([]fieldPathSegment, error) {
+	rest, ok := strings.CutPrefix(path, fieldPathRoot)
+	if !ok {
+		return nil, errors.Wrapf(ErrInvalidFieldPath, "%s has to start with %s", path, fieldPathRoot)
+	}
+	var segments []fieldPathSegment
+	for len(rest) > 0 {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[") + 1
+			if end == 0 {
+				end = len(rest)
+			}
+			key := rest[1:end]
+			if len(key) == 0 {
+				return nil, errors.Wrapf(ErrInvalidFieldPath, "%s has an empty key", path)
+			}
+			segments = append(segments, fieldPathSegment{key: key, wildcard: key == fieldPathWildcard})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, errors.Wrapf(ErrInvalidFieldPath, "%s has an unclosed index", path)
+			}
+			index := rest[1:end]
+			if index == fieldPathWildcard {
+				segments = append(segments, fieldPathSegment{isIndex: true, wildcard: true})
+			} else {
+				position, err := strconv.Atoi(index)
+				if err != nil || position < 0 {
+					return nil, errors.Wrapf(ErrInvalidFieldPath, "%s has an invalid index %s", path, index)
+				}
+				segments = append(segments, fieldPathSegment{index: position, isIndex: true})
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, errors.Wrapf(ErrInvalidFieldPath, "%s has unexpected %q", path, rest[0])
+		}
+	}
+	if len(segments) == 0 {
+		return nil, errors.Wrapf(ErrInvalidFieldPath, "%s points to the whole body", path)
+	}
+	return segments, nil
+}
@@ -0,0 +1,212 @@
+package api_test
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+const upstreamUser = `{"user":{"id":7,"name":"john","password":"secret"},"items":[{"id":1,"sku":"a"},{"id":2,"sku":"b"}]}`
+
+func TestResponseRewriter_Rewrite(t *testing.T) {
+	t.Parallel()
+	mapped := func(key, path string) values.Valuer {
+		valuer, err := values.NewMappedValuer(
+			key, "", path, "/test",
+			enums.RequestLocations.Upstream(), nil, enums.ConversionTypes.None(), logger.NewTestLogger(),
+		)
+		require.NoError(t, err)
+		return valuer
+	}
+	requestName, err := values.NewMappedValuer(
+		"name", "", "$.name", "/test",
+		enums.RequestLocations.Body(), nil, enums.ConversionTypes.None(), logger.NewTestLogger(),
+	)
+	require.NoError(t, err)
+	testCases := []struct {
+		name            string
+		upstreamStatus  int
+		upstreamType    string
+		upstreamBody    string
+		status          map[int]int
+		headers         map[string]string
+		removeHeaders   []string
+		deletePaths     []string
+		override        values.Valuer
+		requestBody     string
+		expectedStatus  int
+		expectedBody    string
+		expectedHeaders map[string]string
+		// literals compared in the raw body, json comparison decodes the numbers as float64
+		expectedLiterals []string
+	}{
+		{
+			name:           "status and headers rewritten",
+			upstreamStatus: http.StatusOK,
+			upstreamType:   "application/json",
+			upstreamBody:   upstreamUser,
+			status:         map[int]int{http.StatusOK: http.StatusServiceUnavailable},
+			headers:        map[string]string{"Retry-After": "30"},
+			removeHeaders:  []string{"X-Upstream"},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   upstreamUser,
+			expectedHeaders: map[string]string{
+				"Retry-After": "30",
+				"X-Upstream":  "",
+			},
+		},
+		{
+			name:           "fields deleted",
+			upstreamStatus: http.StatusOK,
+			upstreamType:   "application/json",
+			upstreamBody:   upstreamUser,
+			deletePaths:    []string{"$.user.password", "$.items[*].sku", "$.items[0]", "$.missing.field"},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"user":{"id":7,"name":"john"},"items":[{"id":2}]}`,
+		},
+		{
+			name:           "fields overridden and mapped from upstream",
+			upstreamStatus: http.StatusCreated,
+			upstreamType:   "application/json",
+			upstreamBody:   upstreamUser,
+			deletePaths:    []string{"$.user.password"},
+			override: values.NewObjectValuer("", []values.Valuer{
+				values.NewObjectValuer("user", []values.Valuer{
+					values.NewStaticValuer("name", "Robert'); DROP TABLE users;--"),
+				}),
+				mapped("owner", "$.user.name"),
+				mapped("leaked", "$.user.password"),
+			}),
+			expectedStatus: http.StatusCreated,
+			expectedBody: `{
+				"user":{"id":7,"name":"Robert'); DROP TABLE users;--"},
+				"items":[{"id":1,"sku":"a"},{"id":2,"sku":"b"}],
+				"owner":"john",
+				"leaked":"secret"
+			}`,
+		},
+		{
+			name:           "large numbers kept and fields mapped from request body",
+			upstreamStatus: http.StatusOK,
+			upstreamType:   "application/json",
+			upstreamBody:   `{"id":12345678901234567890,"price":0.1,"name":"john"}`,
+			override: values.NewObjectValuer("", []values.Valuer{
+				requestName,
+			}),
+			requestBody:      `{"name":"Robert"}`,
+			expectedStatus:   http.StatusOK,
+			expectedBody:     `{"id":12345678901234567890,"price":0.1,"name":"Robert"}`,
+			expectedLiterals: []string{`"id":12345678901234567890`, `"price":0.1`},
+		},
+		{
+			name:           "body which is not json left untouched",
+			upstreamStatus: http.StatusBadGateway,
+			upstreamType:   "text/html",
+			upstreamBody:   "<html>bad gateway</html>",
+			status:         map[int]int{http.StatusBadGateway: http.StatusOK},
+			deletePaths:    []string{"$.user"},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "<html>bad gateway</html>",
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				forwarded, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				require.Equal(t, tc.requestBody, string(forwarded))
+				rw.Header().Set("Content-Type", tc.upstreamType)
+				rw.Header().Set("X-Upstream", "staging")
+				if !strings.Contains(req.Header.Get("Accept-Encoding"), "gzip") {
+					rw.WriteHeader(tc.upstreamStatus)
+					_, _ = rw.Write([]byte(tc.upstreamBody))
+					return
+				}
+				rw.Header().Set("Content-Encoding", "gzip")
+				rw.WriteHeader(tc.upstreamStatus)
+				writer := gzip.NewWriter(rw)
+				_, _ = writer.Write([]byte(tc.upstreamBody))
+				_ = writer.Close()
+			}))
+			defer server.Close()
+			rewriter, err := api.NewResponseRewriter(
+				tc.status, tc.headers, tc.removeHeaders, tc.deletePaths, tc.override, logger.NewTestLogger(),
+			)
+			require.NoError(t, err)
+			sp := api.NewStaticProxy(
+				http.MethodGet, "/test", http.MethodGet, newUpstreams(t, server.URL),
+				api.ProxyHeaders{}, nil, rewriter, nil, nil, logger.NewTestLogger(),
+			)
+			rr := CreateTestResponseRecorder()
+			c, _ := gin.CreateTestContext(rr)
+			c.Request = httptest.NewRequest(http.MethodGet, "/test", strings.NewReader(tc.requestBody))
+			c.Request.Header.Set("Accept-Encoding", "gzip")
+
+			sp.Respond(c)
+			require.Equal(t, tc.expectedStatus, rr.Code)
+			body := rr.Body.String()
+			// the compressed body is passed through when only the status and headers are rewritten
+			if rr.Header().Get("Content-Encoding") == "gzip" {
+				reader, err := gzip.NewReader(rr.Body)
+				require.NoError(t, err)
+				content, err := io.ReadAll(reader)
+				require.NoError(t, err)
+				body = string(content)
+			}
+			if tc.upstreamType == "application/json" {
+				require.JSONEq(t, tc.expectedBody, body)
+			} else {
+				require.Equal(t, tc.expectedBody, body)
+			}
+			for _, literal := range tc.expectedLiterals {
+				require.Contains(t, body, literal)
+			}
+			for key, value := range tc.expectedHeaders {
+				require.Equal(t, value, rr.Header().Get(key))
+			}
+		})
+	}
+}
+
+func TestNewResponseRewriter(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name          string
+		status        map[int]int
+		deletePaths   []string
+		expectedError error
+	}{
+		{name: "valid paths", deletePaths: []string{"$.a", "$.a[0].b", "$.a[*]", "$.*.id"}},
+		{name: "path without root", deletePaths: []string{"user.id"}, expectedError: api.ErrInvalidFieldPath},
+		{name: "path of the whole body", deletePaths: []string{"$"}, expectedError: api.ErrInvalidFieldPath},
+		{name: "path with empty key", deletePaths: []string{"$.user..id"}, expectedError: api.ErrInvalidFieldPath},
+		{name: "path with invalid index", deletePaths: []string{"$.items[first]"}, expectedError: api.ErrInvalidFieldPath},
+		{name: "path with unclosed index", deletePaths: []string{"$.items[0"}, expectedError: api.ErrInvalidFieldPath},
+		{name: "unknown status code", status: map[int]int{200: 999}, expectedError: api.ErrInvalidStatusRewrite},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := api.NewResponseRewriter(tc.status, nil, nil, tc.deletePaths, nil, logger.NewTestLogger())
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
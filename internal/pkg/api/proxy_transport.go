@@ -0,0 +1,156 @@
+package api
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+
+	"github.com/pkg/errors"
+)
+
+const defaultConnectTimeout = 30 * time.Second
+
+// defaultRetryStatuses are retried when the policy lists no statuses
+var defaultRetryStatuses = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+// ProxyTransportOptions configure the connections of the proxy to the upstream
+type ProxyTransportOptions struct {
+	// ConnectTimeout limits connecting to the upstream, 30 seconds when 0
+	ConnectTimeout time.Duration
+	// ResponseTimeout limits waiting for the response headers, no limit when 0
+	ResponseTimeout time.Duration
+	// TLS verifies the upstream and sends the client certificate, the system roots are used when nil
+	TLS *tls.Config
+	// HTTPProxy is the url of the proxy the requests are sent through, the environment settings when empty
+	HTTPProxy string
+	// Retry repeats the failed requests of the idempotent methods, nil sends the requests once
+	Retry *RetryPolicy
+}
+
+// RetryPolicy repeats the requests failing with the error or responding with one of the statuses
+type RetryPolicy struct {
+	// Attempts is the number of the requests sent, including the first one
+	Attempts int
+	// Backoff is the pause before the next attempt
+	Backoff time.Duration
+	// Statuses are retried, 502, 503 and 504 when empty
+	Statuses []int
+}
+
+// NewProxyTransport creates the transport shared by all the requests of the proxy endpoint
+func NewProxyTransport(options ProxyTransportOptions, logger logger.Logger) (http.RoundTripper, error) {
+	connectTimeout := options.ConnectTimeout
+	if connectTimeout == 0 {
+		connectTimeout = defaultConnectTimeout
+	}
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return nil, errors.New("default transport is not http.Transport")
+	}
+	transport = transport.Clone()
+	transport.DialContext = (&net.Dialer{Timeout: connectTimeout, KeepAlive: defaultConnectTimeout}).DialContext
+	transport.TLSHandshakeTimeout = connectTimeout
+	transport.ResponseHeaderTimeout = options.ResponseTimeout
+	if options.TLS != nil {
+		transport.TLSClientConfig = options.TLS
+	}
+	if len(options.HTTPProxy) > 0 {
+		proxyURL, err := url.Parse(options.HTTPProxy)
+		if err != nil || len(proxyURL.Host) == 0 {
+			return nil, errors.Wrapf(ErrInvalidProxyURL, "http proxy %s", options.HTTPProxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if options.Retry == nil || options.Retry.Attempts <= 1 {
+		return transport, nil
+	}
+	policy := *options.Retry
+	if len(policy.Statuses) == 0 {
+		policy.Statuses = defaultRetryStatuses
+	}
+	return &retryTransport{next: transport, policy: policy, logger: logger}, nil
+}
+
+type retryTransport struct {
+	next   http.RoundTripper
+	policy RetryPolicy
+	logger logger.Logger
+}
+
+func (rt *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isIdempotent(req.Method) {
+		return rt.next.RoundTrip(req)
+	}
+	body, err := replayableBody(req)
+	if err != nil {
+		return nil, err
+	}
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 {
+			attemptReq = req.Clone(req.Context())
+			if attemptReq.Body, err = body(); err != nil {
+				return nil, err
+			}
+		}
+		response, err := rt.next.RoundTrip(attemptReq)
+		if attempt >= rt.policy.Attempts || !rt.retryable(response, err) {
+			return response, err
+		}
+		if response != nil {
+			_, _ = io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+			rt.logger.Infof("retrying %s %s after status %d, attempt %d", req.Method, req.URL, response.StatusCode, attempt)
+		} else {
+			rt.logger.Infof("retrying %s %s after error %v, attempt %d", req.Method, req.URL, err, attempt)
+		}
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(rt.policy.Backoff):
+		}
+	}
+}
+
+func (rt *retryTransport) retryable(response *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	for _, status := range rt.policy.Statuses {
+		if response.StatusCode == status {
+			return true
+		}
+	}
+	return false
+}
+
+// replayableBody reads the body once, so it can be sent again with the next attempt
+func replayableBody(req *http.Request) (func() (io.ReadCloser, error), error) {
+	if req.GetBody != nil {
+		return req.GetBody, nil
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		return func() (io.ReadCloser, error) { return http.NoBody, nil }, nil
+	}
+	content, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading proxied body")
+	}
+	req.Body = io.NopCloser(bytes.NewReader(content))
+	return func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(content)), nil }, nil
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package api_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProxyTransport(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name           string
+		method         string
+		options        api.ProxyTransportOptions
+		failures       int32
+		delay          time.Duration
+		expectedStatus int
+		expectedCalls  int32
+	}{
+		{
+			name:           "get retried until success",
+			method:         http.MethodGet,
+			options:        api.ProxyTransportOptions{Retry: &api.RetryPolicy{Attempts: 3, Backoff: time.Millisecond}},
+			failures:       2,
+			expectedStatus: http.StatusOK,
+			expectedCalls:  3,
+		},
+		{
+			name:           "last failure returned after all attempts",
+			method:         http.MethodPut,
+			options:        api.ProxyTransportOptions{Retry: &api.RetryPolicy{Attempts: 2}},
+			failures:       5,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedCalls:  2,
+		},
+		{
+			name:           "status not listed not retried",
+			method:         http.MethodGet,
+			options:        api.ProxyTransportOptions{Retry: &api.RetryPolicy{Attempts: 3, Statuses: []int{500}}},
+			failures:       1,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedCalls:  1,
+		},
+		{
+			name:           "post not retried",
+			method:         http.MethodPost,
+			options:        api.ProxyTransportOptions{Retry: &api.RetryPolicy{Attempts: 3}},
+			failures:       1,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedCalls:  1,
+		},
+		{
+			name:           "response timeout",
+			method:         http.MethodGet,
+			options:        api.ProxyTransportOptions{ResponseTimeout: 20 * time.Millisecond},
+			delay:          time.Second,
+			expectedStatus: http.StatusBadGateway,
+			expectedCalls:  1,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				body, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				require.Equal(t, `{"id":1}`, string(body))
+				if calls.Add(1) <= tc.failures {
+					rw.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				select {
+				case <-time.After(tc.delay):
+				case <-req.Context().Done():
+				}
+				rw.WriteHeader(http.StatusOK)
+			}))
+			t.Cleanup(server.Close)
+			transport, err := api.NewProxyTransport(tc.options, logger.NewTestLogger())
+			require.NoError(t, err)
+			sp := api.NewStaticProxy(
+				tc.method, "/test", tc.method, newUpstreams(t, server.URL), api.ProxyHeaders{}, transport,
+				nil, nil, nil, logger.NewTestLogger(),
+			)
+			rr := CreateTestResponseRecorder()
+			c, _ := gin.CreateTestContext(rr)
+			c.Request = httptest.NewRequest(tc.method, "/test", strings.NewReader(`{"id":1}`))
+
+			sp.Respond(c)
+			// the upstreams respond without the body, so the status is not flushed to the recorder
+			require.Equal(t, tc.expectedStatus, c.Writer.Status())
+			require.Equal(t, tc.expectedCalls, calls.Load())
+		})
+	}
+}
+
+func TestNewProxyTransport_HTTPProxy(t *testing.T) {
+	t.Parallel()
+	var proxied atomic.Value
+	httpProxy := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		proxied.Store(req.URL.String())
+		rw.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(httpProxy.Close)
+
+	transport, err := api.NewProxyTransport(
+		api.ProxyTransportOptions{HTTPProxy: httpProxy.URL}, logger.NewTestLogger(),
+	)
+	require.NoError(t, err)
+	sp := api.NewStaticProxy(
+		http.MethodGet, "/test", http.MethodGet, newUpstreams(t, "http://upstream.internal/users"),
+		api.ProxyHeaders{}, transport, nil, nil, nil, logger.NewTestLogger(),
+	)
+	rr := CreateTestResponseRecorder()
+	c, _ := gin.CreateTestContext(rr)
+	c.Request = httptest.NewRequest(http.MethodGet, "/test", nil)
+
+	sp.Respond(c)
+	require.Equal(t, http.StatusAccepted, c.Writer.Status())
+	require.Equal(t, "http://upstream.internal/users", proxied.Load())
+
+	_, err = api.NewProxyTransport(api.ProxyTransportOptions{HTTPProxy: "proxy:3128"}, logger.NewTestLogger())
+	require.ErrorIs(t, err, api.ErrInvalidProxyURL)
+}
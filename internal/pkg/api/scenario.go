@@ -0,0 +1,207 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+var ErrUnknownScenario = errors.New("unknown scenario")
+
+// ScenarioStarted is the state of the scenario before its first transition and after the reset
+const ScenarioStarted = "started"
+
+// Scenarios keep the states of the named scenarios in memory, the state is kept per key of the scenario,
+// so the requests of the parallel tests do not interfere
+type Scenarios interface {
+	// Register declares the scenario, so it is listed and reset by the admin API
+	Register(name string)
+	// State returns the state of the scenario for the key
+	State(name, key string) string
+	// Transition moves the scenario to the next state when it is still in the state
+	Transition(name, key, state, next string) bool
+	// Set moves the scenario to the state for the key
+	Set(name, key, state string) error
+	// Reset moves the scenario to the started state for all the keys, all the scenarios are reset when name is empty
+	Reset(name string) error
+	// States returns the changed states of all the scenarios by the key
+	States() map[string]map[string]string
+}
+
+type scenarios struct {
+	mutex  sync.Mutex
+	states map[string]map[string]string
+}
+
+func NewScenarios() Scenarios {
+	return &scenarios{states: make(map[string]map[string]string)}
+}
+
+func (s *scenarios) Register(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.states[name]; !ok {
+		s.states[name] = make(map[string]string)
+	}
+}
+
+func (s *scenarios) State(name, key string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.state(name, key)
+}
+
+func (s *scenarios) Transition(name, key, state, next string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.state(name, key) != state {
+		return false
+	}
+	if s.states[name] == nil {
+		s.states[name] = make(map[string]string)
+	}
+	s.states[name][key] = next
+	return true
+}
+
+func (s *scenarios) Set(name, key, state string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.states[name]; !ok {
+		return errors.Wrapf(ErrUnknownScenario, "scenario %s", name)
+	}
+	s.states[name][key] = state
+	return nil
+}
+
+func (s *scenarios) Reset(name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(name) == 0 {
+		for name := range s.states {
+			s.states[name] = make(map[string]string)
+		}
+		return nil
+	}
+	if _, ok := s.states[name]; !ok {
+		return errors.Wrapf(ErrUnknownScenario, "scenario %s", name)
+	}
+	s.states[name] = make(map[string]string)
+	return nil
+}
+
+func (s *scenarios) States() map[string]map[string]string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	rval := make(map[string]map[string]string, len(s.states))
+	for name, states := range s.states {
+		rval[name] = make(map[string]string, len(states))
+		for key, state := range states {
+			rval[name][key] = state
+		}
+	}
+	return rval
+}
+
+func (s *scenarios) state(name, key string) string {
+	if state, ok := s.states[name][key]; ok {
+		return state
+	}
+	return ScenarioStarted
+}
+
+// ScenarioStep is the state the scenario has to be in for the endpoint to respond and the state it moves to
+type ScenarioStep struct {
+	Name string
+	// State is required for the endpoint to respond, any state when empty
+	State string
+	// Next is the state after the response, the state does not change when empty
+	Next string
+	// Key selects the instance of the scenario, e.g. mapped from the user id, a single instance when nil
+	Key values.Valuer
+}
+
+// ScenarioHandler responds only in the state of its step
+type ScenarioHandler interface {
+	Handler
+	// Serve responds and moves the scenario to the next state when the scenario is in the state of the step,
+	// false is returned when the request is left for the other handlers
+	Serve(c *gin.Context) bool
+}
+
+type scenarioHandler struct {
+	Handler
+	scenarios Scenarios
+	step      ScenarioStep
+	logger    logger.Logger
+}
+
+func NewScenarioHandler(handler Handler, scenarios Scenarios, step ScenarioStep, logger logger.Logger) ScenarioHandler {
+	scenarios.Register(step.Name)
+	return &scenarioHandler{Handler: handler, scenarios: scenarios, step: step, logger: logger}
+}
+
+func (sh *scenarioHandler) Respond(c *gin.Context) {
+	if !sh.Serve(c) {
+		c.AbortWithStatus(http.StatusNotFound)
+	}
+}
+
+func (sh *scenarioHandler) Serve(c *gin.Context) bool {
+	key, err := values.GenerateKey(c, sh.step.Key)
+	if err != nil {
+		sh.logger.Errorf("error generating key of scenario %s %+v", sh.step.Name, err)
+		RespondWithErrorMappingParam(c, err)
+		return true
+	}
+	state := sh.scenarios.State(sh.step.Name, key)
+	if len(sh.step.State) > 0 && state != sh.step.State {
+		return false
+	}
+	sh.Handler.Respond(c)
+	if len(sh.step.Next) > 0 && sh.scenarios.Transition(sh.step.Name, key, state, sh.step.Next) {
+		sh.logger.Infof("scenario %s [%s] moved from %s to %s", sh.step.Name, key, state, sh.step.Next)
+	}
+	return true
+}
+
+// ScenarioRoute serves the requests of the route with the first handler in the state of its scenario
+type ScenarioRoute interface {
+	Handler
+	Add(handler ScenarioHandler)
+}
+
+type scenarioRoute struct {
+	handlers []ScenarioHandler
+}
+
+// NewScenarioRoute serves the route of the handler, the requests served by none of the handlers respond with not found
+func NewScenarioRoute(handler ScenarioHandler) ScenarioRoute {
+	return &scenarioRoute{handlers: []ScenarioHandler{handler}}
+}
+
+func (sr *scenarioRoute) Method() string {
+	return sr.handlers[0].Method()
+}
+
+func (sr *scenarioRoute) URL() string {
+	return sr.handlers[0].URL()
+}
+
+func (sr *scenarioRoute) Add(handler ScenarioHandler) {
+	sr.handlers = append(sr.handlers, handler)
+}
+
+func (sr *scenarioRoute) Respond(c *gin.Context) {
+	for _, handler := range sr.handlers {
+		if handler.Serve(c) {
+			return
+		}
+	}
+	c.AbortWithStatus(http.StatusNotFound)
+}
@@ -0,0 +1,145 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newScenarioHandler(
+	t *testing.T,
+	scenarios api.Scenarios,
+	method, url, response string,
+	step api.ScenarioStep,
+) api.ScenarioHandler {
+	t.Helper()
+	handler, err := api.NewStaticHandler(
+		enums.ResponseFormats.JSON(), method, url, http.StatusOK, []byte(response), "", logger.NewTestLogger(),
+	)
+	require.NoError(t, err)
+	return api.NewScenarioHandler(handler, scenarios, step, logger.NewTestLogger())
+}
+
+func serveScenario(handler api.Handler, method, url, user string) *TestResponseRecorder {
+	rr := CreateTestResponseRecorder()
+	c, _ := gin.CreateTestContext(rr)
+	c.Request = httptest.NewRequest(method, url, nil)
+	if len(user) > 0 {
+		c.Request.Header.Set("X-User", user)
+	}
+	handler.Respond(c)
+	return rr
+}
+
+type scenarioRequest struct {
+	method, user, expectedBody string
+	expectedStatus             int
+}
+
+func TestScenarioRoute_Respond(t *testing.T) {
+	t.Parallel()
+	user, err := values.NewMappedValuer("", "X-User", "", "", enums.RequestLocations.Header(), nil,
+		enums.ConversionTypes.None(), logger.NewTestLogger())
+	require.NoError(t, err)
+	testCases := []struct {
+		name     string
+		key      values.Valuer
+		requests []scenarioRequest
+	}{
+		{
+			name: "pending until approved",
+			requests: []scenarioRequest{
+				{method: http.MethodGet, expectedStatus: http.StatusOK, expectedBody: `{"status":"pending"}`},
+				{method: http.MethodPost, expectedStatus: http.StatusOK, expectedBody: `{}`},
+				{method: http.MethodGet, expectedStatus: http.StatusOK, expectedBody: `{"status":"approved"}`},
+				{method: http.MethodPost, expectedStatus: http.StatusNotFound},
+			},
+		},
+		{
+			name: "states kept per user",
+			key:  user,
+			requests: []scenarioRequest{
+				{method: http.MethodPost, user: "1", expectedStatus: http.StatusOK, expectedBody: `{}`},
+				{method: http.MethodGet, user: "1", expectedStatus: http.StatusOK, expectedBody: `{"status":"approved"}`},
+				{method: http.MethodGet, user: "2", expectedStatus: http.StatusOK, expectedBody: `{"status":"pending"}`},
+			},
+		},
+		{
+			name: "missing key",
+			key:  user,
+			requests: []scenarioRequest{
+				{method: http.MethodGet, expectedStatus: http.StatusBadRequest},
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			scenarios := api.NewScenarios()
+			get := api.NewScenarioRoute(newScenarioHandler(t, scenarios, http.MethodGet, "/order", `{"status":"pending"}`,
+				api.ScenarioStep{Name: "order", State: api.ScenarioStarted, Key: tc.key}))
+			get.Add(newScenarioHandler(t, scenarios, http.MethodGet, "/order", `{"status":"approved"}`,
+				api.ScenarioStep{Name: "order", State: "approved", Key: tc.key}))
+			approve := newScenarioHandler(t, scenarios, http.MethodPost, "/order", `{}`,
+				api.ScenarioStep{Name: "order", State: api.ScenarioStarted, Next: "approved", Key: tc.key})
+
+			for _, request := range tc.requests {
+				handler := api.Handler(get)
+				if request.method == http.MethodPost {
+					handler = approve
+				}
+				rr := serveScenario(handler, request.method, "/order", request.user)
+				require.Equal(t, request.expectedStatus, rr.Code)
+				if len(request.expectedBody) > 0 {
+					require.JSONEq(t, request.expectedBody, rr.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestScenarioAdmin(t *testing.T) {
+	t.Parallel()
+	scenarios := api.NewScenarios()
+	get := newScenarioHandler(t, scenarios, http.MethodGet, "/order", `{"status":"approved"}`,
+		api.ScenarioStep{Name: "order", State: "approved"})
+	engine := gin.New()
+	for _, handler := range api.NewScenarioAdmin(scenarios, logger.NewTestLogger()) {
+		engine.Handle(handler.Method(), handler.URL(), handler.Respond)
+	}
+	admin := func(method, url, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		engine.ServeHTTP(rr, httptest.NewRequest(method, url, strings.NewReader(body)))
+		return rr
+	}
+
+	require.Equal(t, http.StatusNotFound, serveScenario(get, http.MethodGet, "/order", "").Code)
+	require.Equal(t, http.StatusNoContent, admin(http.MethodPut, "/__admin/scenarios/order", `{"state":"approved"}`).Code)
+	require.Equal(t, http.StatusOK, serveScenario(get, http.MethodGet, "/order", "").Code)
+	require.Equal(t, http.StatusNoContent, admin(http.MethodPut, "/__admin/scenarios/order",
+		`{"state":"approved","key":"7"}`).Code)
+
+	rr := admin(http.MethodGet, "/__admin/scenarios", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.JSONEq(t, `{"order":{"":"approved","7":"approved"}}`, rr.Body.String())
+
+	require.Equal(t, http.StatusNoContent, admin(http.MethodDelete, "/__admin/scenarios/order", "").Code)
+	require.Equal(t, http.StatusNotFound, serveScenario(get, http.MethodGet, "/order", "").Code)
+	require.Equal(t, http.StatusNoContent, admin(http.MethodDelete, "/__admin/scenarios", "").Code)
+	require.JSONEq(t, `{"order":{}}`, admin(http.MethodGet, "/__admin/scenarios", "").Body.String())
+
+	require.Equal(t, http.StatusNotFound, admin(http.MethodDelete, "/__admin/scenarios/unknown", "").Code)
+	require.Equal(t, http.StatusNotFound, admin(http.MethodPut, "/__admin/scenarios/unknown",
+		`{"state":"approved"}`).Code)
+	require.Equal(t, http.StatusBadRequest, admin(http.MethodPut, "/__admin/scenarios/order", `{}`).Code)
+}
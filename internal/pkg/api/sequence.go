@@ -0,0 +1,128 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+var ErrSequenceExhausted = errors.New("all the responses of the sequence were returned")
+
+// Sequences keep the resets of the sequence endpoints, so the admin API can start them over
+type Sequences interface {
+	// Register adds the reset of the sequence
+	Register(reset func())
+	// Reset starts all the sequences over from their first response
+	Reset()
+}
+
+type sequences struct {
+	mutex  sync.Mutex
+	resets []func()
+}
+
+func NewSequences() Sequences {
+	return &sequences{}
+}
+
+func (s *sequences) Register(reset func()) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.resets = append(s.resets, reset)
+}
+
+func (s *sequences) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, reset := range s.resets {
+		reset()
+	}
+}
+
+type sequenceHandler struct {
+	method    string
+	url       string
+	responses []Handler
+	policy    enums.SequencePolicy
+	// key counts the calls per the request value, all the calls are counted together when nil
+	key    values.Valuer
+	mutex  sync.Mutex
+	calls  map[string]int
+	logger logger.Logger
+}
+
+// NewSequenceHandler returns the responses in order on the successive calls,
+// the policy decides what is returned after the last one
+func NewSequenceHandler(
+	method, url string,
+	responses []Handler,
+	policy enums.SequencePolicy,
+	key values.Valuer,
+	sequences Sequences,
+	logger logger.Logger,
+) Handler {
+	handler := &sequenceHandler{
+		method:    method,
+		url:       url,
+		responses: responses,
+		policy:    policy,
+		key:       key,
+		calls:     make(map[string]int),
+		logger:    logger,
+	}
+	sequences.Register(handler.reset)
+	return handler
+}
+
+func (sh *sequenceHandler) Method() string {
+	return sh.method
+}
+
+func (sh *sequenceHandler) URL() string {
+	return sh.url
+}
+
+func (sh *sequenceHandler) Respond(c *gin.Context) {
+	key, err := values.GenerateKey(c, sh.key)
+	if err != nil {
+		sh.logger.Errorf("error generating key of sequence %s %s %+v", sh.method, sh.url, err)
+		RespondWithErrorMappingParam(c, err)
+		return
+	}
+	call := sh.next(key)
+	if call < len(sh.responses) {
+		sh.responses[call].Respond(c)
+		return
+	}
+	switch sh.policy {
+	case enums.SequencePolicies.Cycle():
+		sh.responses[call%len(sh.responses)].Respond(c)
+	case enums.SequencePolicies.Error():
+		returnErrors(c, http.StatusInternalServerError, c.Request.URL.EscapedPath(), sequenceExhaustedTitle,
+			errors.Wrapf(ErrSequenceExhausted, "call %d", call+1))
+	default:
+		sh.responses[len(sh.responses)-1].Respond(c)
+	}
+}
+
+// next counts the call of the key and returns its index
+func (sh *sequenceHandler) next(key string) int {
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+	call := sh.calls[key]
+	sh.calls[key]++
+	return call
+}
+
+// reset forgets the calls of all the keys
+func (sh *sequenceHandler) reset() {
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+	sh.calls = make(map[string]int)
+}
@@ -0,0 +1,122 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newSequenceResponse(t *testing.T, status int) api.Handler {
+	t.Helper()
+	handler, err := api.NewStaticHandler(
+		enums.ResponseFormats.JSON(), http.MethodGet, "/job", status, []byte(`{}`), "", logger.NewTestLogger(),
+	)
+	require.NoError(t, err)
+	return handler
+}
+
+func TestSequenceHandler_Respond(t *testing.T) {
+	t.Parallel()
+	user, err := values.NewMappedValuer("", "X-User", "", "", enums.RequestLocations.Header(), nil,
+		enums.ConversionTypes.None(), logger.NewTestLogger())
+	require.NoError(t, err)
+	testCases := []struct {
+		name             string
+		policy           enums.SequencePolicy
+		key              values.Valuer
+		users            []string
+		expectedStatuses []int
+	}{
+		{
+			name:   "repeat last",
+			policy: enums.SequencePolicies.RepeatLast(),
+			expectedStatuses: []int{
+				http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK, http.StatusOK,
+			},
+		},
+		{
+			name:   "cycle",
+			policy: enums.SequencePolicies.Cycle(),
+			expectedStatuses: []int{
+				http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK, http.StatusServiceUnavailable,
+			},
+		},
+		{
+			name:   "error after exhaustion",
+			policy: enums.SequencePolicies.Error(),
+			expectedStatuses: []int{
+				http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK, http.StatusInternalServerError,
+			},
+		},
+		{
+			name:   "calls counted per user",
+			policy: enums.SequencePolicies.RepeatLast(),
+			key:    user,
+			users:  []string{"1", "1", "2", "1"},
+			expectedStatuses: []int{
+				http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK,
+			},
+		},
+		{
+			name:             "missing key",
+			policy:           enums.SequencePolicies.RepeatLast(),
+			key:              user,
+			expectedStatuses: []int{http.StatusBadRequest},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			handler := api.NewSequenceHandler(http.MethodGet, "/job", []api.Handler{
+				newSequenceResponse(t, http.StatusServiceUnavailable),
+				newSequenceResponse(t, http.StatusServiceUnavailable),
+				newSequenceResponse(t, http.StatusOK),
+			}, tc.policy, tc.key, api.NewSequences(), logger.NewTestLogger())
+			require.Equal(t, http.MethodGet, handler.Method())
+			require.Equal(t, "/job", handler.URL())
+			for i, expectedStatus := range tc.expectedStatuses {
+				rr := CreateTestResponseRecorder()
+				c, _ := gin.CreateTestContext(rr)
+				c.Request = httptest.NewRequest(http.MethodGet, "/job", nil)
+				if i < len(tc.users) {
+					c.Request.Header.Set("X-User", tc.users[i])
+				}
+				handler.Respond(c)
+				require.Equal(t, expectedStatus, rr.Code, "call %d", i+1)
+			}
+		})
+	}
+}
+
+func TestSequenceAdmin(t *testing.T) {
+	t.Parallel()
+	sequences := api.NewSequences()
+	handler := api.NewSequenceHandler(http.MethodGet, "/job", []api.Handler{
+		newSequenceResponse(t, http.StatusServiceUnavailable),
+		newSequenceResponse(t, http.StatusOK),
+	}, enums.SequencePolicies.RepeatLast(), nil, sequences, logger.NewTestLogger())
+	engine := gin.New()
+	engine.Handle(handler.Method(), handler.URL(), handler.Respond)
+	for _, admin := range api.NewSequenceAdmin(sequences, logger.NewTestLogger()) {
+		engine.Handle(admin.Method(), admin.URL(), admin.Respond)
+	}
+	serve := func(method, url string) int {
+		rr := httptest.NewRecorder()
+		engine.ServeHTTP(rr, httptest.NewRequest(method, url, nil))
+		return rr.Code
+	}
+
+	require.Equal(t, http.StatusServiceUnavailable, serve(http.MethodGet, "/job"))
+	require.Equal(t, http.StatusOK, serve(http.MethodGet, "/job"))
+	require.Equal(t, http.StatusNoContent, serve(http.MethodDelete, "/__admin/sequences"))
+	require.Equal(t, http.StatusServiceUnavailable, serve(http.MethodGet, "/job"))
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/vimek-go/server-faker/internal/pkg/encoders"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// SSEStream describes the events sent to the client
+// the listed Events are sent when there is no Valuer generating them
+type SSEStream struct {
+	Events   []SSEEvent
+	Valuer   values.Valuer
+	Event    string
+	IDs      bool
+	Retry    time.Duration
+	Interval time.Duration
+	Count    int
+	Duration time.Duration
+	Done     any
+}
+
+type SSEEvent struct {
+	Event string
+	ID    string
+	Data  any
+}
+
+type sseHandler struct {
+	baseResponseHandler
+	stream  SSEStream
+	encoder encoders.Encoder
+}
+
+func NewSSEHandler(
+	method, url string,
+	responseCode int,
+	stream SSEStream,
+	encoder encoders.Encoder,
+	logger logger.Logger,
+) ResponseHandler {
+	return &sseHandler{
+		baseResponseHandler: newBaseResponseHandler(method, url, responseCode, logger),
+		stream:              stream,
+		encoder:             encoder,
+	}
+}
+
+func (sh *sseHandler) Respond(c *gin.Context) {
+	c.Status(sh.Code)
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	var deadline <-chan time.Time
+	if sh.stream.Duration > 0 {
+		timer := time.NewTimer(sh.stream.Duration)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	ticker := time.NewTicker(sh.interval())
+	defer ticker.Stop()
+
+	for sent := 0; sh.hasNext(sent); sent++ {
+		if sent > 0 {
+			select {
+			case <-c.Request.Context().Done():
+				sh.Logger.Debugf("sse client of %s disconnected", sh.HandlerURL)
+				return
+			case <-deadline:
+				sh.finish(c)
+				return
+			case <-ticker.C:
+			}
+		}
+		event, err := sh.nextEvent(c, sent)
+		if err != nil {
+			sh.Logger.Error(err)
+			return
+		}
+		if !sh.render(c, event) {
+			return
+		}
+	}
+	sh.finish(c)
+}
+
+func (sh *sseHandler) interval() time.Duration {
+	if sh.stream.Interval > 0 {
+		return sh.stream.Interval
+	}
+	// the ticker needs a positive interval, events without it are sent at once
+	return time.Nanosecond
+}
+
+func (sh *sseHandler) hasNext(sent int) bool {
+	if sh.stream.Valuer == nil {
+		return sent < len(sh.stream.Events)
+	}
+	return sh.stream.Count == 0 || sent < sh.stream.Count
+}
+
+func (sh *sseHandler) nextEvent(c *gin.Context, sent int) (sse.Event, error) {
+	var event SSEEvent
+	if sh.stream.Valuer == nil {
+		event = sh.stream.Events[sent]
+	} else {
+		data, err := sh.stream.Valuer.Generate(c)
+		if err != nil {
+			return sse.Event{}, err
+		}
+		event = SSEEvent{Event: sh.stream.Event, Data: data}
+		if sh.stream.IDs {
+			event.ID = strconv.Itoa(sent + 1)
+		}
+	}
+	data, err := sh.encodeData(event.Data)
+	if err != nil {
+		return sse.Event{}, err
+	}
+	rval := sse.Event{Event: event.Event, Id: event.ID, Data: data}
+	if sent == 0 && sh.stream.Retry > 0 {
+		rval.Retry = uint(sh.stream.Retry.Milliseconds())
+	}
+	return rval, nil
+}
+
+// encodeData keeps the strings untouched, so the text streams are not quoted
+func (sh *sseHandler) encodeData(data any) (string, error) {
+	if text, ok := data.(string); ok {
+		return text, nil
+	}
+	encoded, err := sh.encoder.Encode(data)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+func (sh *sseHandler) finish(c *gin.Context) {
+	if sh.stream.Done == nil {
+		return
+	}
+	data, err := sh.encodeData(sh.stream.Done)
+	if err != nil {
+		sh.Logger.Error(err)
+		return
+	}
+	sh.render(c, sse.Event{Event: sh.stream.Event, Data: data})
+}
+
+func (sh *sseHandler) render(c *gin.Context, event sse.Event) bool {
+	c.Render(-1, event)
+	if len(c.Errors) > 0 {
+		sh.Logger.Debugf("sse write to %s failed: %v", sh.HandlerURL, c.Errors.Last())
+		return false
+	}
+	c.Writer.Flush()
+	return true
+}
@@ -0,0 +1,90 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/encoders"
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSEHandler_Respond(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		stream   api.SSEStream
+		expected string
+		asserts  func(*testing.T, string)
+	}{
+		{
+			name: "listed events with retry and done",
+			stream: api.SSEStream{
+				Events: []api.SSEEvent{
+					{Event: "message", ID: "a", Data: "hello"},
+					{Data: map[string]any{"text": "world"}},
+				},
+				Retry: 3 * time.Second,
+				Done:  "[DONE]",
+			},
+			expected: "id:a\nevent:message\nretry:3000\ndata:hello\n\n" +
+				"data:{\"text\":\"world\"}\n\n" +
+				"data:[DONE]\n\n",
+		},
+		{
+			name: "generated events with ids",
+			stream: api.SSEStream{
+				Valuer:   values.NewStaticValuer("token", "abc"),
+				Event:    "token",
+				IDs:      true,
+				Interval: time.Millisecond,
+				Count:    2,
+			},
+			expected: "id:1\nevent:token\ndata:{\"token\":\"abc\"}\n\n" +
+				"id:2\nevent:token\ndata:{\"token\":\"abc\"}\n\n",
+		},
+		{
+			name: "generated events until duration",
+			stream: api.SSEStream{
+				Valuer:   values.NewStaticValuer("", "tick"),
+				Interval: 10 * time.Millisecond,
+				Duration: 55 * time.Millisecond,
+				Done:     "end",
+			},
+			asserts: func(t *testing.T, body string) {
+				require.True(t, strings.HasPrefix(body, "data:tick\n\n"))
+				require.True(t, strings.HasSuffix(body, "data:end\n\n"))
+				require.Greater(t, strings.Count(body, "data:tick"), 1)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			encoder, err := encoders.New(enums.ResponseFormats.JSON(), encoders.Options{})
+			require.NoError(t, err)
+			handler := api.NewSSEHandler(http.MethodGet, "/events", http.StatusOK, tc.stream, encoder,
+				logger.NewTestLogger())
+			rr := CreateTestResponseRecorder()
+			c, _ := gin.CreateTestContext(rr)
+			c.Request = httptest.NewRequest(http.MethodGet, "/events", nil)
+			handler.Respond(c)
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Equal(t, "text/event-stream", rr.Header().Get("Content-Type"))
+			if tc.asserts != nil {
+				tc.asserts(t, rr.Body.String())
+				return
+			}
+			require.Equal(t, tc.expected, rr.Body.String())
+		})
+	}
+}
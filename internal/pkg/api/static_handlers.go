@@ -1,6 +1,7 @@
 package api
 
 import (
+	"github.com/vimek-go/server-faker/internal/pkg/encoders"
 	"github.com/vimek-go/server-faker/internal/pkg/enums"
 	"github.com/vimek-go/server-faker/internal/pkg/logger"
 
@@ -27,20 +28,7 @@ func NewStaticHandler(
 	logger logger.Logger,
 ) (ResponseHandler, error) {
 	baseHandler := newBaseResponseHandler(method, url, responseCode, logger)
-	switch responseFormat {
-	case enums.ResponseFormats.JSON():
-		return &staticHandler{
-			baseResponseHandler: baseHandler,
-			byteValue:           response,
-			contentType:         "application/json",
-		}, nil
-	case enums.ResponseFormats.XML():
-		return &staticHandler{
-			baseResponseHandler: baseHandler,
-			byteValue:           response,
-			contentType:         "application/xml",
-		}, nil
-	case enums.ResponseFormats.Bytes():
+	if responseFormat == enums.ResponseFormats.Bytes() {
 		if len(contentType) == 0 {
 			return nil, errors.Wrapf(
 				ErrContentTypeEmpty,
@@ -52,11 +40,20 @@ func NewStaticHandler(
 		}
 		return &staticHandler{baseResponseHandler: baseHandler, byteValue: response, contentType: contentType}, nil
 	}
-	return nil, errors.Wrapf(
-		ErrNotSupportedResponseFormat,
-		"request  method: %s, URL: %s. Not supported response format %s",
-		baseHandler.HandlerMethod,
-		baseHandler.HandlerURL,
-		responseFormat,
-	)
+	// the content is already encoded, the encoder provides the content type of the format
+	encoder, err := encoders.New(responseFormat, encoders.Options{})
+	if err != nil {
+		return nil, errors.Wrapf(
+			ErrNotSupportedResponseFormat,
+			"request  method: %s, URL: %s. Not supported response format %s",
+			baseHandler.HandlerMethod,
+			baseHandler.HandlerURL,
+			responseFormat,
+		)
+	}
+	return &staticHandler{
+		baseResponseHandler: baseHandler,
+		byteValue:           response,
+		contentType:         encoder.ContentType(),
+	}, nil
 }
@@ -21,6 +21,7 @@ func TestStaticHandler_Respond(t *testing.T) {
 		method         string
 		response       []byte
 		contentType    string
+		expectedType   string
 		expectedError  error
 	}{
 		{
@@ -28,12 +29,35 @@ func TestStaticHandler_Respond(t *testing.T) {
 			responseFormat: enums.ResponseFormats.JSON(),
 			method:         "GET",
 			response:       []byte(`{"key": "value"}`),
+			expectedType:   "application/json",
 		},
 		{
 			name:           "success response with xml",
 			responseFormat: enums.ResponseFormats.XML(),
 			method:         "GET",
 			response:       []byte(`<key>value</key>`),
+			expectedType:   "application/xml",
+		},
+		{
+			name:           "success response with yaml",
+			responseFormat: enums.ResponseFormats.YAML(),
+			method:         "GET",
+			response:       []byte("key: value\n"),
+			expectedType:   "application/yaml",
+		},
+		{
+			name:           "success response with csv",
+			responseFormat: enums.ResponseFormats.CSV(),
+			method:         "GET",
+			response:       []byte("key\nvalue\n"),
+			expectedType:   "text/csv",
+		},
+		{
+			name:           "success response with ndjson",
+			responseFormat: enums.ResponseFormats.NDJSON(),
+			method:         "GET",
+			response:       []byte("{\"key\":1}\n{\"key\":2}\n"),
+			expectedType:   "application/x-ndjson",
 		},
 		{
 			name:           "success response with bytes",
@@ -41,6 +65,7 @@ func TestStaticHandler_Respond(t *testing.T) {
 			method:         "GET",
 			response:       []byte(`plain text`),
 			contentType:    "text/plain",
+			expectedType:   "text/plain",
 		},
 		{
 			name:           "error response with empty content type",
@@ -75,6 +100,7 @@ func TestStaticHandler_Respond(t *testing.T) {
 				sh.Respond(c)
 				require.Equal(t, rr.Code, http.StatusOK)
 				require.Equal(t, rr.Body.String(), string(tc.response))
+				require.Equal(t, tc.expectedType, rr.Header().Get("Content-Type"))
 			}
 		})
 	}
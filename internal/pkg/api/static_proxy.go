@@ -2,8 +2,6 @@ package api
 
 import (
 	"net/http"
-	"net/http/httputil"
-	"net/url"
 
 	"github.com/vimek-go/server-faker/internal/pkg/logger"
 
@@ -14,42 +12,61 @@ type staticProxy struct {
 	baseProxyHandler
 }
 
+// NewStaticProxy forwards the requests to the upstreams selected from the pool
 func NewStaticProxy(
-	handlerMethod, handlerURL, proxyMethod, proxyURL string,
-	headers map[string]string,
+	handlerMethod, handlerURL, proxyMethod string,
+	upstreams UpstreamPool,
+	headers ProxyHeaders,
+	transport http.RoundTripper,
+	rewriter ResponseRewriter,
+	fallback Handler,
+	mirror Mirror,
 	logger logger.Logger,
 ) Handler {
 	return &staticProxy{
 		baseProxyHandler: baseProxyHandler{
 			handlerMethod: handlerMethod,
 			handlerURL:    handlerURL,
-			proxyURL:      proxyURL,
+			upstreams:     upstreams,
 			proxyMethod:   proxyMethod,
 			headers:       headers,
+			transport:     transport,
+			rewriter:      rewriter,
+			fallback:      fallback,
+			mirror:        mirror,
 			logger:        logger,
 		},
 	}
 }
 
 func (sp *staticProxy) Respond(c *gin.Context) {
-	remote, err := url.Parse(sp.proxyURL)
+	headers, err := sp.generateHeaders(c)
 	if err != nil {
-		panic(err)
+		sp.logger.Errorf("error generating proxy headers %+v\n", err)
+		RespondWithErrorMappingParam(c, err)
+		return
 	}
-	proxy := httputil.NewSingleHostReverseProxy(remote)
+	if err := sp.cacheBody(c); err != nil {
+		sp.logger.Errorf("error reading request body %+v\n", err)
+		RespondWithErrorMappingParam(c, err)
+		return
+	}
+	exchange := &proxyExchange{target: sp.upstreams.Next()}
+	remote := exchange.target.Remote
+	proxy := sp.newReverseProxy(remote)
 	proxy.Director = func(req *http.Request) {
 		*req = *c.Request
+		restoreBody(c, req)
 		req.Host = remote.Host
 		req.Method = sp.proxyMethod
 		req.URL.Scheme = remote.Scheme
 		req.URL.Host = remote.Host
 		req.URL.Path = remote.Path
 		req.URL.RawQuery = remote.RawQuery
-
-		for key, value := range sp.headers {
-			req.Header.Set(key, value)
-		}
+		sp.prepareRequest(req, headers)
+		sp.mirrorRequest(exchange, req)
 	}
 
+	sp.handleResponses(c, proxy, exchange)
 	proxy.ServeHTTP(c.Writer, c.Request)
 }
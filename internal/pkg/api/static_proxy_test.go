@@ -1,12 +1,16 @@
 package api_test
 
 import (
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
 	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
@@ -14,20 +18,103 @@ import (
 
 func TestStaticProxy_Respond(t *testing.T) {
 	t.Parallel()
+	mapped := func(header string) values.Valuer {
+		valuer, err := values.NewMappedValuer(
+			"", header, "", "/test",
+			enums.RequestLocations.Header(), nil, enums.ConversionTypes.None(), logger.NewTestLogger(),
+		)
+		require.NoError(t, err)
+		return valuer
+	}
+	userID, err := values.NewMappedValuer(
+		"", "", "$.user.id", "/test",
+		enums.RequestLocations.Body(), nil, enums.ConversionTypes.None(), logger.NewTestLogger(),
+	)
+	require.NoError(t, err)
 	testCases := []struct {
-		name        string
-		proxyMethod string
-		headers     map[string]string
-		asserts     func(t *testing.T, req *http.Request)
+		name           string
+		proxyMethod    string
+		headers        api.ProxyHeaders
+		incoming       map[string]string
+		body           string
+		asserts        func(t *testing.T, req *http.Request)
+		expectedStatus int
 	}{
 		{
 			name:        "success response with headers",
 			proxyMethod: http.MethodGet,
-			headers:     map[string]string{"header": "value"},
+			headers:     api.ProxyHeaders{Set: map[string]string{"header": "value"}},
 			asserts: func(t *testing.T, req *http.Request) {
 				require.Equal(t, req.Header.Get("header"), "value")
 				require.Equal(t, req.Method, http.MethodGet)
 			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "headers renamed, removed and generated",
+			proxyMethod: http.MethodGet,
+			headers: api.ProxyHeaders{
+				Rename: map[string]string{"X-Caller-Token": "Authorization"},
+				Remove: []string{"Cookie"},
+				Params: map[string]values.Valuer{
+					"X-User-Id": mapped("X-Caller-Id"),
+					"X-Tenant":  values.NewStaticValuer("", "test"),
+				},
+			},
+			incoming: map[string]string{
+				"X-Caller-Token": "Bearer abc", "X-Caller-Id": "42", "Cookie": "session=1", "Accept": "text/plain",
+			},
+			asserts: func(t *testing.T, req *http.Request) {
+				require.Equal(t, "Bearer abc", req.Header.Get("Authorization"))
+				require.Empty(t, req.Header.Get("X-Caller-Token"))
+				require.Empty(t, req.Header.Get("Cookie"))
+				require.Equal(t, "42", req.Header.Get("X-User-Id"))
+				require.Equal(t, "test", req.Header.Get("X-Tenant"))
+				require.Equal(t, "text/plain", req.Header.Get("Accept"))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "only selected headers forwarded",
+			proxyMethod: http.MethodGet,
+			headers: api.ProxyHeaders{
+				Forward: []string{"accept", "X-Missing"},
+				Rename:  map[string]string{"X-Caller-Token": "Authorization"},
+				Set:     map[string]string{"X-Faked": "true"},
+			},
+			incoming: map[string]string{"X-Caller-Token": "Bearer abc", "Cookie": "session=1", "Accept": "text/plain"},
+			asserts: func(t *testing.T, req *http.Request) {
+				require.Equal(t, "Bearer abc", req.Header.Get("Authorization"))
+				require.Equal(t, "text/plain", req.Header.Get("Accept"))
+				require.Equal(t, "true", req.Header.Get("X-Faked"))
+				require.Empty(t, req.Header.Get("Cookie"))
+				require.NotContains(t, req.Header, "X-Missing")
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "body forwarded after generating header from it",
+			proxyMethod: http.MethodPost,
+			headers:     api.ProxyHeaders{Params: map[string]values.Valuer{"X-User-Id": userID}},
+			incoming:    map[string]string{"Content-Type": "application/json"},
+			body:        `{"user":{"id":"42"}}`,
+			asserts: func(t *testing.T, req *http.Request) {
+				require.Equal(t, "42", req.Header.Get("X-User-Id"))
+				body, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				require.Equal(t, `{"user":{"id":"42"}}`, string(body))
+				require.Equal(t, int64(len(body)), req.ContentLength)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "bad request on missing mapped header",
+			proxyMethod: http.MethodGet,
+			headers:     api.ProxyHeaders{Params: map[string]values.Valuer{"X-User-Id": mapped("X-Caller-Id")}},
+			asserts: func(t *testing.T, _ *http.Request) {
+				require.Fail(t, "request proxied without the header")
+			},
+			expectedStatus: http.StatusBadRequest,
 		},
 	}
 	for i := range testCases {
@@ -46,14 +133,92 @@ func TestStaticProxy_Respond(t *testing.T) {
 			sp := api.NewStaticProxy(
 				http.MethodPost,
 				"/test",
-				http.MethodGet,
-				server.URL,
+				tc.proxyMethod,
+				newUpstreams(t, server.URL),
 				tc.headers,
+				nil,
+				nil,
+				nil,
+				nil,
 				logger.NewTestLogger(),
 			)
-			c.Request = httptest.NewRequest(http.MethodPost, "/test", nil)
+			c.Request = httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(tc.body))
+			for key, value := range tc.incoming {
+				c.Request.Header.Set(key, value)
+			}
 			sp.Respond(c)
 			require.Equal(t, c.Request.Method, http.MethodPost)
+			require.Equal(t, tc.expectedStatus, c.Writer.Status())
+			for key, value := range tc.incoming {
+				require.Equal(t, value, c.Request.Header.Get(key), "incoming headers left untouched")
+			}
+		})
+	}
+}
+
+func TestStaticProxy_Fallback(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name           string
+		upstreamStatus int
+		unreachable    bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "upstream response served",
+			upstreamStatus: http.StatusOK,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "upstream",
+		},
+		{
+			name:           "upstream client error served",
+			upstreamStatus: http.StatusNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "upstream",
+		},
+		{
+			name:           "fallback on server error",
+			upstreamStatus: http.StatusServiceUnavailable,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"source":"fallback"}`,
+		},
+		{
+			name:           "fallback on unreachable upstream",
+			unreachable:    true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"source":"fallback"}`,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+				rw.WriteHeader(tc.upstreamStatus)
+				_, _ = rw.Write([]byte("upstream"))
+			}))
+			upstreamURL := server.URL
+			if tc.unreachable {
+				server.Close()
+			} else {
+				defer server.Close()
+			}
+			fallback, err := api.NewStaticHandler(
+				enums.ResponseFormats.JSON(), http.MethodGet, "/test", http.StatusOK,
+				[]byte(`{"source":"fallback"}`), "", logger.NewTestLogger(),
+			)
+			require.NoError(t, err)
+			sp := api.NewStaticProxy(
+				http.MethodGet, "/test", http.MethodGet, newUpstreams(t, upstreamURL),
+				api.ProxyHeaders{}, nil, nil, fallback, nil, logger.NewTestLogger(),
+			)
+			rr := CreateTestResponseRecorder()
+			c, _ := gin.CreateTestContext(rr)
+			c.Request = httptest.NewRequest(http.MethodGet, "/test", nil)
+			sp.Respond(c)
+			require.Equal(t, tc.expectedStatus, rr.Code)
+			require.Equal(t, tc.expectedBody, rr.Body.String())
 		})
 	}
 }
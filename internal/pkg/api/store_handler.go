@@ -0,0 +1,92 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"time"
+
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StoreEntry is the value saved in the named store under the key, both generated from the request
+type StoreEntry struct {
+	Name string
+	// Key is the empty key when nil, so the store keeps a single value
+	Key   values.Valuer
+	Value values.Valuer
+	// TTL is the time the value is kept for, it does not expire when 0
+	TTL time.Duration
+}
+
+type storeHandler struct {
+	Handler
+	store   values.Store
+	entries []StoreEntry
+	// mapsResponse keeps the copy of the response for the entries mapping it
+	mapsResponse bool
+	logger       logger.Logger
+}
+
+// NewStoreHandler saves the entries once the handler responded,
+// the entries can map the values of the json response with the response location
+func NewStoreHandler(handler Handler, store values.Store, entries []StoreEntry, logger logger.Logger) Handler {
+	sh := &storeHandler{Handler: handler, store: store, entries: entries, logger: logger}
+	for _, entry := range entries {
+		sh.mapsResponse = sh.mapsResponse || values.MapsResponse(entry.Key, entry.Value)
+	}
+	return sh
+}
+
+func (sh *storeHandler) Respond(c *gin.Context) {
+	var writer *bodyLogWriter
+	if sh.mapsResponse {
+		writer = &bodyLogWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+	}
+	sh.Handler.Respond(c)
+	if writer != nil {
+		c.Writer = writer.ResponseWriter
+	}
+	if c.IsAborted() {
+		sh.logger.Debugf("response of %s %s failed, nothing stored", sh.Method(), sh.URL())
+		return
+	}
+	if writer != nil {
+		sh.setResponseBody(c, writer.body.Bytes())
+	}
+	for _, entry := range sh.entries {
+		sh.save(c, entry)
+	}
+}
+
+// setResponseBody exposes the json response to the response mappers
+func (sh *storeHandler) setResponseBody(c *gin.Context, content []byte) {
+	mediaType, _, err := mime.ParseMediaType(c.Writer.Header().Get("Content-Type"))
+	if err != nil || !isJSONMediaType(mediaType) {
+		return
+	}
+	var body any
+	if err := json.Unmarshal(content, &body); err == nil {
+		values.SetResponseBody(c, body)
+	}
+}
+
+// save logs the errors, the response is already written
+func (sh *storeHandler) save(c *gin.Context, entry StoreEntry) {
+	key, err := values.GenerateKey(c, entry.Key)
+	if err != nil {
+		sh.logger.Errorf("error generating key of store %s %+v", entry.Name, err)
+		return
+	}
+	value, err := entry.Value.Generate(c)
+	if err != nil {
+		sh.logger.Errorf("error generating value of store %s [%s] %+v", entry.Name, key, err)
+		return
+	}
+	sh.store.Set(entry.Name, key, value, entry.TTL)
+	sh.logger.Infof("stored %s [%s]", entry.Name, key)
+}
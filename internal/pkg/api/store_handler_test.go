@@ -0,0 +1,141 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/api/internal/mocks"
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreHandler_Respond(t *testing.T) {
+	t.Parallel()
+	testLogger := logger.NewTestLogger()
+	token, err := values.NewMappedValuer("", "", "$.token", "", enums.RequestLocations.Response(), nil,
+		enums.ConversionTypes.None(), testLogger)
+	require.NoError(t, err)
+	username, err := values.NewMappedValuer("", "", "$.username", "", enums.RequestLocations.Body(), nil,
+		enums.ConversionTypes.None(), testLogger)
+	require.NoError(t, err)
+	testCases := []struct {
+		name          string
+		handler       func(t *testing.T) api.Handler
+		entries       []api.StoreEntry
+		expectedKey   string
+		expectedValue any
+	}{
+		{
+			name: "key mapped from the response",
+			handler: func(t *testing.T) api.Handler {
+				handler, err := api.NewStaticHandler(enums.ResponseFormats.JSON(), http.MethodPost, "/login",
+					http.StatusOK, []byte(`{"token":"abc"}`), "", testLogger)
+				require.NoError(t, err)
+				return handler
+			},
+			entries:       []api.StoreEntry{{Name: "sessions", Key: token, Value: username}},
+			expectedKey:   "abc",
+			expectedValue: "john",
+		},
+		{
+			name: "value without key",
+			handler: func(t *testing.T) api.Handler {
+				handler, err := api.NewStaticHandler(enums.ResponseFormats.JSON(), http.MethodPost, "/login",
+					http.StatusOK, []byte(`{}`), "", testLogger)
+				require.NoError(t, err)
+				return handler
+			},
+			entries:       []api.StoreEntry{{Name: "sessions", Value: values.NewStaticValuer("", "static")}},
+			expectedValue: "static",
+		},
+		{
+			name: "key missing in the response",
+			handler: func(t *testing.T) api.Handler {
+				handler, err := api.NewStaticHandler(enums.ResponseFormats.YAML(), http.MethodPost, "/login",
+					http.StatusOK, []byte(`token: abc`), "", testLogger)
+				require.NoError(t, err)
+				return handler
+			},
+			entries:     []api.StoreEntry{{Name: "sessions", Key: token, Value: username}},
+			expectedKey: "abc",
+		},
+		{
+			name: "failed response",
+			handler: func(t *testing.T) api.Handler {
+				hm := mocks.NewHandlerMock(t)
+				hm.On("Method").Return(http.MethodPost).Maybe()
+				hm.On("URL").Return("/login").Maybe()
+				hm.On("Respond", mock.AnythingOfType("*gin.Context")).Run(func(args mock.Arguments) {
+					args.Get(0).(*gin.Context).AbortWithStatus(http.StatusBadRequest)
+				})
+				return hm
+			},
+			entries: []api.StoreEntry{{Name: "sessions", Value: values.NewStaticValuer("", "static")}},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			store := values.NewStore()
+			handler := api.NewStoreHandler(tc.handler(t), store, tc.entries, testLogger)
+			rr := CreateTestResponseRecorder()
+			c, _ := gin.CreateTestContext(rr)
+			c.Request = httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"username":"john"}`))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			handler.Respond(c)
+			value, ok := store.Get("sessions", tc.expectedKey)
+			require.Equal(t, tc.expectedValue != nil, ok)
+			require.Equal(t, tc.expectedValue, value)
+		})
+	}
+}
+
+func TestStoreHandler_RespondBuffering(t *testing.T) {
+	t.Parallel()
+	testLogger := logger.NewTestLogger()
+	token, err := values.NewMappedValuer("", "", "$.token", "", enums.RequestLocations.Response(), nil,
+		enums.ConversionTypes.None(), testLogger)
+	require.NoError(t, err)
+	testCases := []struct {
+		name             string
+		entries          []api.StoreEntry
+		expectedBuffered bool
+	}{
+		{
+			name:             "response mapped by the key",
+			entries:          []api.StoreEntry{{Name: "sessions", Key: token, Value: values.NewStaticValuer("", "static")}},
+			expectedBuffered: true,
+		},
+		{
+			name:    "response not mapped",
+			entries: []api.StoreEntry{{Name: "sessions", Value: values.NewStaticValuer("", "static")}},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			c, _ := gin.CreateTestContext(CreateTestResponseRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/login", nil)
+			original := c.Writer
+			hm := mocks.NewHandlerMock(t)
+			hm.On("Respond", mock.AnythingOfType("*gin.Context")).Run(func(args mock.Arguments) {
+				buffered := args.Get(0).(*gin.Context).Writer != original
+				require.Equal(t, tc.expectedBuffered, buffered)
+			})
+
+			api.NewStoreHandler(hm, values.NewStore(), tc.entries, testLogger).Respond(c)
+			require.Equal(t, original, c.Writer)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+var ErrStreamInterrupted = errors.New("stream interrupted")
+
+// throttleGranularity is the part of a second sent at once when only the bandwidth is limited
+const throttleGranularity = 10
+
+// StreamOptions slows down writing of the response body
+type StreamOptions struct {
+	// ChunkSize is the number of bytes written at once, the whole body when 0
+	ChunkSize int
+	// Delay is the pause between the chunks
+	Delay time.Duration
+	// Chunked uses the chunked transfer encoding instead of the content length
+	Chunked bool
+	// BytesPerSecond limits the bandwidth, 0 is unlimited
+	BytesPerSecond int
+}
+
+type streamedHandler struct {
+	ResponseHandler
+	options StreamOptions
+}
+
+// NewStreamedHandler writes the response of the handler in chunks
+func NewStreamedHandler(handler ResponseHandler, options StreamOptions) ResponseHandler {
+	if options.ChunkSize == 0 && options.BytesPerSecond > 0 {
+		options.ChunkSize = max(options.BytesPerSecond/throttleGranularity, 1)
+	}
+	return &streamedHandler{ResponseHandler: handler, options: options}
+}
+
+func (sh *streamedHandler) Respond(c *gin.Context) {
+	writer := &streamingWriter{ResponseWriter: c.Writer, options: sh.options, ctx: c.Request.Context()}
+	c.Writer = writer
+	defer func() { c.Writer = writer.ResponseWriter }()
+	sh.ResponseHandler.Respond(c)
+}
+
+type streamingWriter struct {
+	gin.ResponseWriter
+	options StreamOptions
+	ctx     context.Context
+}
+
+func (w *streamingWriter) Write(data []byte) (int, error) {
+	if !w.Written() {
+		if w.options.Chunked {
+			w.Header().Del("Content-Length")
+		} else {
+			// the body is written at once by the handlers, so its length is known
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		}
+	}
+	chunkSize := w.options.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = len(data)
+	}
+	written := 0
+	for written < len(data) {
+		end := min(written+chunkSize, len(data))
+		// the throttle holds every chunk for the time it takes with the bandwidth
+		delay := w.throttle(end - written)
+		if written > 0 {
+			delay += w.options.Delay
+		}
+		if !w.wait(delay) {
+			return written, errors.Wrapf(ErrStreamInterrupted, "written %d of %d bytes", written, len(data))
+		}
+		n, err := w.ResponseWriter.Write(data[written:end])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		w.ResponseWriter.Flush()
+	}
+	return written, nil
+}
+
+func (w *streamingWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *streamingWriter) throttle(size int) time.Duration {
+	if w.options.BytesPerSecond <= 0 {
+		return 0
+	}
+	return time.Duration(size) * time.Second / time.Duration(w.options.BytesPerSecond)
+}
+
+// wait returns false when the client disconnects
+func (w *streamingWriter) wait(delay time.Duration) bool {
+	if delay <= 0 {
+		return true
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-w.ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
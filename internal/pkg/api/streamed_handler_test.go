@@ -0,0 +1,75 @@
+package api_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamedHandler_Respond(t *testing.T) {
+	t.Parallel()
+	body := strings.Repeat("0123456789", 10)
+	testCases := []struct {
+		name        string
+		options     api.StreamOptions
+		chunked     bool
+		minDuration time.Duration
+	}{
+		{
+			name:        "chunks with delay and content length",
+			options:     api.StreamOptions{ChunkSize: 25, Delay: 20 * time.Millisecond},
+			minDuration: 60 * time.Millisecond,
+		},
+		{
+			name:        "chunked transfer encoding",
+			options:     api.StreamOptions{ChunkSize: 50, Delay: 10 * time.Millisecond, Chunked: true},
+			chunked:     true,
+			minDuration: 10 * time.Millisecond,
+		},
+		{
+			name:        "bandwidth throttle",
+			options:     api.StreamOptions{BytesPerSecond: 1000},
+			minDuration: 90 * time.Millisecond,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			testLogger := logger.NewTestLogger()
+			static, err := api.NewStaticHandler(enums.ResponseFormats.Bytes(), http.MethodGet, "/download",
+				http.StatusOK, []byte(body), "text/plain", testLogger)
+			require.NoError(t, err)
+			ba := api.NewBaseAPI(gin.New(), testLogger)
+			ba.AddRoute(api.NewStreamedHandler(static, tc.options))
+			server := httptest.NewServer(ba.Engine())
+			t.Cleanup(server.Close)
+
+			start := time.Now()
+			response, err := http.Get(server.URL + "/download")
+			require.NoError(t, err)
+			defer response.Body.Close()
+			received, err := io.ReadAll(response.Body)
+			require.NoError(t, err)
+			require.GreaterOrEqual(t, time.Since(start), tc.minDuration)
+			require.Equal(t, body, string(received))
+			require.Equal(t, http.StatusOK, response.StatusCode)
+			if tc.chunked {
+				require.Equal(t, []string{"chunked"}, response.TransferEncoding)
+				require.Equal(t, int64(-1), response.ContentLength)
+			} else {
+				require.Equal(t, int64(len(body)), response.ContentLength)
+			}
+		})
+	}
+}
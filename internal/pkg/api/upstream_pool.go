@@ -0,0 +1,174 @@
+package api
+
+import (
+	"math/rand"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNoUpstreams     = errors.New("no upstream targets")
+	ErrUnknownBalance  = errors.New("unknown balance type")
+	ErrNegativeWeights = errors.New("negative upstream weight")
+)
+
+const (
+	defaultMaxFails = 3
+	defaultCooldown = 30 * time.Second
+)
+
+// Upstream is the target of the proxy, the weight is used by the weighted balance and is 1 when 0
+type Upstream struct {
+	URL    string
+	Weight int
+}
+
+// HealthCheck ejects the upstream failing MaxFails times in a row for the Cooldown,
+// the failures are the connection errors, timeouts and 5xx responses
+type HealthCheck struct {
+	// MaxFails is 3 when 0
+	MaxFails int
+	// Cooldown is 30 seconds when 0
+	Cooldown time.Duration
+}
+
+// UpstreamTarget is the upstream selected for the request
+type UpstreamTarget struct {
+	URL    string
+	Remote *url.URL
+	weight int
+	// current is the smooth weighted round robin counter
+	current      int
+	failures     int
+	ejectedUntil time.Time
+}
+
+// UpstreamPool selects the upstreams of the proxied requests and tracks their health passively
+type UpstreamPool interface {
+	// Next selects the upstream, the ejected upstreams are skipped unless all of them are ejected
+	Next() *UpstreamTarget
+	// Report records the result of the request sent to the upstream
+	Report(target *UpstreamTarget, healthy bool)
+}
+
+type upstreamPool struct {
+	mu      sync.Mutex
+	targets []*UpstreamTarget
+	balance enums.BalanceType
+	health  HealthCheck
+	next    int
+	logger  logger.Logger
+}
+
+// NewUpstreamPool validates the urls of the upstreams and balances the requests between them
+func NewUpstreamPool(
+	upstreams []Upstream,
+	balance enums.BalanceType,
+	health HealthCheck,
+	logger logger.Logger,
+) (UpstreamPool, error) {
+	if len(upstreams) == 0 {
+		return nil, ErrNoUpstreams
+	}
+	if !balance.IsValid() {
+		return nil, errors.Wrapf(ErrUnknownBalance, "%s", balance)
+	}
+	if health.MaxFails == 0 {
+		health.MaxFails = defaultMaxFails
+	}
+	if health.Cooldown == 0 {
+		health.Cooldown = defaultCooldown
+	}
+	targets := make([]*UpstreamTarget, len(upstreams))
+	for i, upstream := range upstreams {
+		remote, err := parseProxyURL(upstream.URL)
+		if err != nil {
+			return nil, err
+		}
+		if upstream.Weight < 0 {
+			return nil, errors.Wrapf(ErrNegativeWeights, "%s weight %d", upstream.URL, upstream.Weight)
+		}
+		weight := upstream.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		targets[i] = &UpstreamTarget{URL: upstream.URL, Remote: remote, weight: weight}
+	}
+	return &upstreamPool{
+		targets: targets,
+		balance: balance,
+		health:  health,
+		logger:  logger,
+	}, nil
+}
+
+func (up *upstreamPool) Next() *UpstreamTarget {
+	if len(up.targets) == 1 {
+		return up.targets[0]
+	}
+	up.mu.Lock()
+	defer up.mu.Unlock()
+	targets := up.healthy()
+	switch up.balance {
+	case enums.BalanceTypes.Random():
+		//nolint:gosec // the upstreams are not selected for security
+		return targets[rand.Intn(len(targets))]
+	case enums.BalanceTypes.Weighted():
+		return up.weighted(targets)
+	default:
+		up.next++
+		return targets[(up.next-1)%len(targets)]
+	}
+}
+
+func (up *upstreamPool) Report(target *UpstreamTarget, healthy bool) {
+	up.mu.Lock()
+	defer up.mu.Unlock()
+	if healthy {
+		target.failures = 0
+		return
+	}
+	target.failures++
+	if target.failures < up.health.MaxFails {
+		return
+	}
+	target.failures = 0
+	target.ejectedUntil = time.Now().Add(up.health.Cooldown)
+	up.logger.Warnf("upstream %s failed %d times, ejected for %s", target.URL, up.health.MaxFails, up.health.Cooldown)
+}
+
+// healthy returns the upstreams which are not ejected, all of them when every upstream is ejected
+func (up *upstreamPool) healthy() []*UpstreamTarget {
+	now := time.Now()
+	targets := make([]*UpstreamTarget, 0, len(up.targets))
+	for _, target := range up.targets {
+		if !now.Before(target.ejectedUntil) {
+			targets = append(targets, target)
+		}
+	}
+	if len(targets) == 0 {
+		return up.targets
+	}
+	return targets
+}
+
+// weighted is the smooth weighted round robin, it interleaves the upstreams instead of sending them bursts
+func (up *upstreamPool) weighted(targets []*UpstreamTarget) *UpstreamTarget {
+	var selected *UpstreamTarget
+	total := 0
+	for _, target := range targets {
+		target.current += target.weight
+		total += target.weight
+		if selected == nil || target.current > selected.current {
+			selected = target
+		}
+	}
+	selected.current -= total
+	return selected
+}
@@ -0,0 +1,196 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newUpstreams(t *testing.T, urls ...string) api.UpstreamPool {
+	t.Helper()
+	upstreams := make([]api.Upstream, len(urls))
+	for i := range urls {
+		upstreams[i] = api.Upstream{URL: urls[i]}
+	}
+	pool, err := api.NewUpstreamPool(upstreams, enums.BalanceTypes.RoundRobin(), api.HealthCheck{}, logger.NewTestLogger())
+	require.NoError(t, err)
+	return pool
+}
+
+func TestUpstreamPool_Next(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name      string
+		upstreams []api.Upstream
+		balance   enums.BalanceType
+		expected  []string
+	}{
+		{
+			name: "round robin",
+			upstreams: []api.Upstream{
+				{URL: "http://a"}, {URL: "http://b"}, {URL: "http://c"},
+			},
+			balance:  enums.BalanceTypes.RoundRobin(),
+			expected: []string{"http://a", "http://b", "http://c", "http://a", "http://b"},
+		},
+		{
+			name: "weighted interleaved",
+			upstreams: []api.Upstream{
+				{URL: "http://stable", Weight: 3}, {URL: "http://canary", Weight: 1},
+			},
+			balance: enums.BalanceTypes.Weighted(),
+			expected: []string{
+				"http://stable", "http://stable", "http://canary", "http://stable",
+				"http://stable", "http://stable", "http://canary", "http://stable",
+			},
+		},
+		{
+			name: "weight defaults to one",
+			upstreams: []api.Upstream{
+				{URL: "http://a"}, {URL: "http://b", Weight: 1},
+			},
+			balance:  enums.BalanceTypes.Weighted(),
+			expected: []string{"http://a", "http://b", "http://a", "http://b"},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			pool, err := api.NewUpstreamPool(tc.upstreams, tc.balance, api.HealthCheck{}, logger.NewTestLogger())
+			require.NoError(t, err)
+			selected := make([]string, len(tc.expected))
+			for i := range selected {
+				selected[i] = pool.Next().URL
+			}
+			require.Equal(t, tc.expected, selected)
+		})
+	}
+}
+
+func TestUpstreamPool_Random(t *testing.T) {
+	t.Parallel()
+	pool, err := api.NewUpstreamPool(
+		[]api.Upstream{{URL: "http://a"}, {URL: "http://b"}},
+		enums.BalanceTypes.Random(), api.HealthCheck{}, logger.NewTestLogger(),
+	)
+	require.NoError(t, err)
+	selected := map[string]int{}
+	for range 100 {
+		selected[pool.Next().URL]++
+	}
+	require.Len(t, selected, 2)
+}
+
+func TestUpstreamPool_Report(t *testing.T) {
+	t.Parallel()
+	pool, err := api.NewUpstreamPool(
+		[]api.Upstream{{URL: "http://a"}, {URL: "http://b"}},
+		enums.BalanceTypes.RoundRobin(),
+		api.HealthCheck{MaxFails: 2, Cooldown: 50 * time.Millisecond},
+		logger.NewTestLogger(),
+	)
+	require.NoError(t, err)
+	first := pool.Next()
+	require.Equal(t, "http://a", first.URL)
+	pool.Report(first, false)
+	pool.Report(first, true)
+	pool.Report(first, false)
+	require.Equal(t, "http://b", pool.Next().URL)
+	require.Equal(t, "http://a", pool.Next().URL, "success resets the failures")
+
+	pool.Report(first, false)
+	pool.Report(first, false)
+	for range 3 {
+		require.Equal(t, "http://b", pool.Next().URL, "ejected upstream skipped")
+	}
+	second := pool.Next()
+	pool.Report(second, false)
+	pool.Report(second, false)
+	selected := map[string]bool{pool.Next().URL: true, pool.Next().URL: true}
+	require.Len(t, selected, 2, "all upstreams used when all are ejected")
+
+	time.Sleep(60 * time.Millisecond)
+	selected = map[string]bool{pool.Next().URL: true, pool.Next().URL: true}
+	require.Len(t, selected, 2, "upstreams restored after the cooldown")
+}
+
+func TestNewUpstreamPool(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name          string
+		upstreams     []api.Upstream
+		balance       enums.BalanceType
+		expectedError error
+	}{
+		{name: "no upstreams", balance: enums.BalanceTypes.RoundRobin(), expectedError: api.ErrNoUpstreams},
+		{
+			name:          "relative url",
+			upstreams:     []api.Upstream{{URL: "http://a"}, {URL: "/users"}},
+			balance:       enums.BalanceTypes.RoundRobin(),
+			expectedError: api.ErrInvalidProxyURL,
+		},
+		{
+			name:          "negative weight",
+			upstreams:     []api.Upstream{{URL: "http://a", Weight: -1}},
+			balance:       enums.BalanceTypes.Weighted(),
+			expectedError: api.ErrNegativeWeights,
+		},
+		{
+			name:          "unknown balance",
+			upstreams:     []api.Upstream{{URL: "http://a"}},
+			balance:       enums.BalanceType("least_conn"),
+			expectedError: api.ErrUnknownBalance,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := api.NewUpstreamPool(tc.upstreams, tc.balance, api.HealthCheck{}, logger.NewTestLogger())
+			require.ErrorIs(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestStaticProxy_Upstreams(t *testing.T) {
+	t.Parallel()
+	healthy := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		_, _ = rw.Write([]byte("healthy"))
+	}))
+	t.Cleanup(healthy.Close)
+	failing := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(http.StatusInternalServerError)
+		_, _ = rw.Write([]byte("failing"))
+	}))
+	t.Cleanup(failing.Close)
+
+	pool, err := api.NewUpstreamPool(
+		[]api.Upstream{{URL: failing.URL}, {URL: healthy.URL}},
+		enums.BalanceTypes.RoundRobin(),
+		api.HealthCheck{MaxFails: 1, Cooldown: time.Minute},
+		logger.NewTestLogger(),
+	)
+	require.NoError(t, err)
+	sp := api.NewStaticProxy(
+		http.MethodGet, "/test", http.MethodGet, pool, api.ProxyHeaders{}, nil, nil, nil, nil,
+		logger.NewTestLogger(),
+	)
+	bodies := make([]string, 4)
+	for i := range bodies {
+		rr := CreateTestResponseRecorder()
+		c, _ := gin.CreateTestContext(rr)
+		c.Request = httptest.NewRequest(http.MethodGet, "/test", nil)
+		sp.Respond(c)
+		bodies[i] = rr.Body.String()
+	}
+	require.Equal(t, []string{"failing", "healthy", "healthy", "healthy"}, bodies)
+}
@@ -0,0 +1,286 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/PaesslerAG/jsonpath"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// WebSocketFlow describes the conversation with a connected client
+type WebSocketFlow struct {
+	// OnConnect messages are sent right after the upgrade
+	OnConnect []values.Valuer
+	// Replies are checked in order, the first matching one responds to the incoming message
+	Replies []WebSocketReply
+	Pushes  []WebSocketPush
+	Close   *WebSocketClose
+}
+
+// WebSocketMatch selects the incoming messages, empty match accepts any message
+type WebSocketMatch struct {
+	Text  string
+	Regex *regexp.Regexp
+	// Path is the json path compared with the Value
+	Path  string
+	Value any
+}
+
+type WebSocketReply struct {
+	Match    WebSocketMatch
+	Messages []values.Valuer
+	// Close ends the connection after the reply is sent
+	Close *WebSocketClose
+}
+
+// WebSocketPush sends the generated message periodically, Count limits the messages, 0 is unlimited
+type WebSocketPush struct {
+	Interval time.Duration
+	Count    int
+	Message  values.Valuer
+}
+
+type WebSocketClose struct {
+	// After closes the connection when the time since connecting passes
+	After time.Duration
+	// AfterMessages closes the connection when the client sends the number of messages
+	AfterMessages int
+	Code          int
+	Reason        string
+}
+
+type webSocketHandler struct {
+	HandlerURL string
+	flow       WebSocketFlow
+	upgrader   websocket.Upgrader
+	logger     logger.Logger
+}
+
+func NewWebSocketHandler(url string, flow WebSocketFlow, logger logger.Logger) Handler {
+	return &webSocketHandler{
+		HandlerURL: url,
+		flow:       flow,
+		upgrader: websocket.Upgrader{
+			// the fake server accepts the connections from any page
+			CheckOrigin: func(*http.Request) bool { return true },
+		},
+		logger: logger,
+	}
+}
+
+func (wh *webSocketHandler) Method() string {
+	return http.MethodGet
+}
+
+func (wh *webSocketHandler) URL() string {
+	return wh.HandlerURL
+}
+
+func (wh *webSocketHandler) Respond(c *gin.Context) {
+	conn, err := wh.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// the upgrader already responded with the error
+		wh.logger.Error(err)
+		return
+	}
+	session := &webSocketSession{
+		conn:    conn,
+		request: c.Request,
+		params:  c.Params,
+		done:    make(chan struct{}),
+		logger:  wh.logger,
+	}
+	session.run(c, wh.flow)
+}
+
+type webSocketSession struct {
+	conn    *websocket.Conn
+	request *http.Request
+	params  gin.Params
+	// writes have to be serialized, pushes are sent from separate goroutines
+	mutex     sync.Mutex
+	done      chan struct{}
+	closeOnce sync.Once
+	logger    logger.Logger
+}
+
+func (s *webSocketSession) run(c *gin.Context, flow WebSocketFlow) {
+	var pushes sync.WaitGroup
+	// closing stops the pushes, the handler returns after they are finished
+	defer pushes.Wait()
+	defer s.close(nil)
+	for _, message := range flow.OnConnect {
+		if !s.send(c, message) {
+			return
+		}
+	}
+	for i := range flow.Pushes {
+		pushes.Add(1)
+		go func(push WebSocketPush) {
+			defer pushes.Done()
+			s.push(push)
+		}(flow.Pushes[i])
+	}
+	if flow.Close != nil && flow.Close.After > 0 {
+		timer := time.AfterFunc(flow.Close.After, func() { s.close(flow.Close) })
+		defer timer.Stop()
+	}
+
+	received := 0
+	for {
+		_, message, err := s.conn.ReadMessage()
+		if err != nil {
+			s.logger.Debugf("websocket %s closed: %v", s.request.URL.Path, err)
+			return
+		}
+		received++
+		s.logger.Infof("[RequestLog] websocket: %s message: %s", s.request.URL.Path, message)
+		if reply := s.findReply(flow.Replies, message); reply != nil {
+			messageContext := s.prepareContext(message)
+			for _, valuer := range reply.Messages {
+				if !s.send(messageContext, valuer) {
+					return
+				}
+			}
+			if reply.Close != nil {
+				s.close(reply.Close)
+				return
+			}
+		}
+		if flow.Close != nil && flow.Close.AfterMessages > 0 && received >= flow.Close.AfterMessages {
+			s.close(flow.Close)
+			return
+		}
+	}
+}
+
+// push runs with its own context, the gin context is not safe to share between goroutines
+func (s *webSocketSession) push(push WebSocketPush) {
+	c := s.prepareContext(nil)
+	ticker := time.NewTicker(push.Interval)
+	defer ticker.Stop()
+	for sent := 0; push.Count == 0 || sent < push.Count; sent++ {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			if !s.send(c, push.Message) {
+				return
+			}
+		}
+	}
+}
+
+// send writes the strings as they are, other values are sent as json
+func (s *webSocketSession) send(c *gin.Context, valuer values.Valuer) bool {
+	value, err := valuer.Generate(c)
+	if err != nil {
+		s.logger.Error(err)
+		s.close(&WebSocketClose{Code: websocket.CloseInternalServerErr, Reason: "generating message failed"})
+		return false
+	}
+	var payload []byte
+	if text, ok := value.(string); ok {
+		payload = []byte(text)
+	} else if payload, err = json.Marshal(value); err != nil {
+		s.logger.Error(err)
+		s.close(&WebSocketClose{Code: websocket.CloseInternalServerErr, Reason: "encoding message failed"})
+		return false
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+		s.logger.Debugf("websocket %s write failed: %v", s.request.URL.Path, err)
+		return false
+	}
+	s.logger.Infof("[ResponseLog] websocket: %s message: %s", s.request.URL.Path, payload)
+	return true
+}
+
+// close sends the close frame once, nil closes without the frame
+func (s *webSocketSession) close(closing *WebSocketClose) {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		if closing != nil {
+			code := closing.Code
+			if code == 0 {
+				code = websocket.CloseNormalClosure
+			}
+			s.mutex.Lock()
+			err := s.conn.WriteControl(
+				websocket.CloseMessage,
+				websocket.FormatCloseMessage(code, closing.Reason),
+				time.Now().Add(time.Second),
+			)
+			s.mutex.Unlock()
+			if err != nil {
+				s.logger.Debugf("websocket %s close failed: %v", s.request.URL.Path, err)
+			}
+		}
+		s.conn.Close()
+	})
+}
+
+func (s *webSocketSession) findReply(replies []WebSocketReply, message []byte) *WebSocketReply {
+	for i := range replies {
+		if replies[i].Match.matches(message) {
+			return &replies[i]
+		}
+	}
+	return nil
+}
+
+// prepareContext exposes the incoming message as the body to the valuers
+// url, query and path params are mapped from the upgrade request
+func (s *webSocketSession) prepareContext(message []byte) *gin.Context {
+	request := s.request.Clone(s.request.Context())
+	request.Body = io.NopCloser(bytes.NewReader(message))
+	request.ContentLength = int64(len(message))
+	return &gin.Context{Request: request, Params: s.params}
+}
+
+func (m *WebSocketMatch) matches(message []byte) bool {
+	if len(m.Text) > 0 && m.Text != string(message) {
+		return false
+	}
+	if m.Regex != nil && !m.Regex.Match(message) {
+		return false
+	}
+	if len(m.Path) > 0 {
+		var body any
+		if err := json.Unmarshal(message, &body); err != nil {
+			return false
+		}
+		value, err := jsonpath.Get(m.Path, body)
+		if err != nil {
+			return false
+		}
+		if m.Value != nil && !jsonEqual(value, m.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonEqual compares the values in their json form, so numbers of any type are matched
+func jsonEqual(a, b any) bool {
+	first, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	second, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(first, second)
+}
@@ -0,0 +1,148 @@
+package api_test
+
+import (
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebSocketHandler_Respond(t *testing.T) {
+	t.Parallel()
+	testLogger := logger.NewTestLogger()
+	mapped, err := values.NewMappedValuer("echo", "", "$.text", "", enums.RequestLocations.Body(), nil,
+		enums.ConversionTypes.None(), testLogger)
+	require.NoError(t, err)
+	room, err := values.NewMappedValuer("room", "room", "", "", enums.RequestLocations.Query(), nil,
+		enums.ConversionTypes.None(), testLogger)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		query   string
+		flow    api.WebSocketFlow
+		asserts func(*testing.T, *websocket.Conn)
+	}{
+		{
+			name: "messages on connect",
+			flow: api.WebSocketFlow{
+				OnConnect: []values.Valuer{
+					values.NewStaticValuer("", "hello"),
+					values.NewStaticValuer("status", "ready"),
+				},
+			},
+			asserts: func(t *testing.T, conn *websocket.Conn) {
+				requireMessage(t, conn, "hello")
+				requireMessage(t, conn, `{"status":"ready"}`)
+			},
+		},
+		{
+			name: "replies selected by matchers",
+			flow: api.WebSocketFlow{
+				Replies: []api.WebSocketReply{
+					{
+						Match:    api.WebSocketMatch{Text: "ping"},
+						Messages: []values.Valuer{values.NewStaticValuer("", "pong")},
+					},
+					{
+						Match:    api.WebSocketMatch{Path: "$.type", Value: "echo"},
+						Messages: []values.Valuer{mapped},
+					},
+					{
+						Match:    api.WebSocketMatch{Regex: regexp.MustCompile(`^bye`)},
+						Messages: []values.Valuer{values.NewStaticValuer("", "see you")},
+						Close:    &api.WebSocketClose{Code: 4000, Reason: "bye"},
+					},
+				},
+			},
+			asserts: func(t *testing.T, conn *websocket.Conn) {
+				require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("ping")))
+				requireMessage(t, conn, "pong")
+				require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"echo","text":"hi"}`)))
+				requireMessage(t, conn, `{"echo":"hi"}`)
+				require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("bye now")))
+				requireMessage(t, conn, "see you")
+				_, _, err := conn.ReadMessage()
+				require.True(t, websocket.IsCloseError(err, 4000))
+			},
+		},
+		{
+			name: "limited pushes",
+			flow: api.WebSocketFlow{
+				Pushes: []api.WebSocketPush{
+					{Interval: 10 * time.Millisecond, Count: 2, Message: values.NewStaticValuer("", "tick")},
+				},
+				Close: &api.WebSocketClose{After: 200 * time.Millisecond},
+			},
+			asserts: func(t *testing.T, conn *websocket.Conn) {
+				requireMessage(t, conn, "tick")
+				requireMessage(t, conn, "tick")
+				_, _, err := conn.ReadMessage()
+				require.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+			},
+		},
+		{
+			name:  "pushes mapped from the upgrade request",
+			query: "?room=lobby",
+			flow: api.WebSocketFlow{
+				Pushes: []api.WebSocketPush{
+					{Interval: 10 * time.Millisecond, Count: 2, Message: room},
+					{Interval: 15 * time.Millisecond, Count: 1, Message: room},
+				},
+				Close: &api.WebSocketClose{After: 200 * time.Millisecond},
+			},
+			asserts: func(t *testing.T, conn *websocket.Conn) {
+				for range 3 {
+					requireMessage(t, conn, `{"room":"lobby"}`)
+				}
+				_, _, err := conn.ReadMessage()
+				require.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+			},
+		},
+		{
+			name: "close after messages",
+			flow: api.WebSocketFlow{
+				Close: &api.WebSocketClose{AfterMessages: 2, Code: websocket.CloseGoingAway},
+			},
+			asserts: func(t *testing.T, conn *websocket.Conn) {
+				require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("first")))
+				require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("second")))
+				_, _, err := conn.ReadMessage()
+				require.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway))
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ba := api.NewBaseAPI(gin.New(), testLogger)
+			ba.AddRoute(api.NewWebSocketHandler("/ws", tc.flow, testLogger))
+			server := httptest.NewServer(ba.Engine())
+			t.Cleanup(server.Close)
+
+			conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws"+tc.query, nil)
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = conn.Close() })
+			require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+			tc.asserts(t, conn)
+		})
+	}
+}
+
+func requireMessage(t *testing.T, conn *websocket.Conn, expected string) {
+	t.Helper()
+	_, message, err := conn.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, expected, string(message))
+}
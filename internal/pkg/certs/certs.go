@@ -0,0 +1,328 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrGeneratingCertificate = errors.New("failed generating certificate")
+	ErrLoadingCertificate    = errors.New("failed loading certificate")
+	ErrUnknownClientAuth     = errors.New("unknown client auth")
+)
+
+const (
+	validity   = 365 * 24 * time.Hour
+	serialBits = 128
+	// names of the files written by GenerateSelfSigned
+	caFile         = "ca.pem"
+	caKeyFile      = "ca-key.pem"
+	certFile       = "cert.pem"
+	keyFile        = "key.pem"
+	clientCertFile = "client.pem"
+	clientKeyFile  = "client-key.pem"
+	certPerm       = 0o644
+	keyPerm        = 0o600
+	dirPerm        = 0o755
+)
+
+// client auth modes of the listener
+const (
+	ClientAuthNone    = "none"
+	ClientAuthRequest = "request"
+	ClientAuthRequire = "require"
+)
+
+// Files are the paths of the generated certificates and keys in PEM format
+type Files struct {
+	CA         string
+	Cert       string
+	Key        string
+	ClientCert string
+	ClientKey  string
+}
+
+// Options describe the tls listener, ClientCA verifies the client certificates
+type Options struct {
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+	// ClientAuth is one of none, request or require, empty requires the certificate when ClientCAFile is set
+	ClientAuth string
+}
+
+// ClientOptions describe the tls connections to the upstream servers,
+// CAFile replaces the system roots and the client certificate is sent when CertFile is set
+type ClientOptions struct {
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	ServerName         string
+	InsecureSkipVerify bool
+}
+
+// GenerateSelfSigned writes a CA with the server certificate for the hosts and a client certificate
+// signed by the CA to the dir, so the clients can trust the server and authenticate with mutual tls
+// the valid files already in the dir are reused, so the clients keep trusting the CA between the runs,
+// they are regenerated when missing, expired or the hosts of the server certificate changed
+func GenerateSelfSigned(dir string, hosts []string) (Files, error) {
+	if err := os.MkdirAll(dir, dirPerm); err != nil {
+		return Files{}, errors.Wrapf(ErrGeneratingCertificate, "creating dir %s: %v", dir, err)
+	}
+	files := Files{
+		CA:         filepath.Join(dir, caFile),
+		Cert:       filepath.Join(dir, certFile),
+		Key:        filepath.Join(dir, keyFile),
+		ClientCert: filepath.Join(dir, clientCertFile),
+		ClientKey:  filepath.Join(dir, clientKeyFile),
+	}
+
+	caKeyPath := filepath.Join(dir, caKeyFile)
+	ca, caKey, err := loadPair(files.CA, caKeyPath)
+	// the certificates of the previous CA are not trusted by the new one
+	reissue := err != nil || !ca.IsCA
+	if reissue {
+		if ca, caKey, err = generateCA(files.CA, caKeyPath); err != nil {
+			return Files{}, err
+		}
+	}
+
+	if reissue || !isIssued(files.Cert, files.Key, ca, hosts) {
+		serverTemplate, err := newTemplate("server-faker")
+		if err != nil {
+			return Files{}, err
+		}
+		serverTemplate.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		for _, host := range hosts {
+			if ip := net.ParseIP(host); ip != nil {
+				serverTemplate.IPAddresses = append(serverTemplate.IPAddresses, ip)
+			} else {
+				serverTemplate.DNSNames = append(serverTemplate.DNSNames, host)
+			}
+		}
+		if err := issue(serverTemplate, ca, caKey, files.Cert, files.Key); err != nil {
+			return Files{}, err
+		}
+	}
+
+	if reissue || !isIssued(files.ClientCert, files.ClientKey, ca, nil) {
+		clientTemplate, err := newTemplate("server-faker-client")
+		if err != nil {
+			return Files{}, err
+		}
+		clientTemplate.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+		if err := issue(clientTemplate, ca, caKey, files.ClientCert, files.ClientKey); err != nil {
+			return Files{}, err
+		}
+	}
+	return files, nil
+}
+
+// NewTLSConfig loads the server certificate and the CA verifying the clients
+func NewTLSConfig(options Options) (*tls.Config, error) {
+	certificate, err := tls.LoadX509KeyPair(options.CertFile, options.KeyFile)
+	if err != nil {
+		return nil, errors.Wrapf(ErrLoadingCertificate, "cert %s key %s: %v", options.CertFile, options.KeyFile, err)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+	}
+	clientAuth := options.ClientAuth
+	if len(clientAuth) == 0 {
+		clientAuth = ClientAuthNone
+		if len(options.ClientCAFile) > 0 {
+			clientAuth = ClientAuthRequire
+		}
+	}
+	switch clientAuth {
+	case ClientAuthNone:
+		return config, nil
+	case ClientAuthRequest:
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	case ClientAuthRequire:
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, errors.Wrapf(ErrUnknownClientAuth, "%s, use one of none, request or require", clientAuth)
+	}
+	if len(options.ClientCAFile) == 0 {
+		return nil, errors.Wrapf(ErrLoadingCertificate, "client auth %s requires the client CA", clientAuth)
+	}
+	content, err := os.ReadFile(options.ClientCAFile)
+	if err != nil {
+		return nil, errors.Wrapf(ErrLoadingCertificate, "client CA %s: %v", options.ClientCAFile, err)
+	}
+	config.ClientCAs = x509.NewCertPool()
+	if !config.ClientCAs.AppendCertsFromPEM(content) {
+		return nil, errors.Wrapf(ErrLoadingCertificate, "client CA %s has no certificates", options.ClientCAFile)
+	}
+	return config, nil
+}
+
+// NewClientTLSConfig loads the CA verifying the upstream and the client certificate of mutual tls
+func NewClientTLSConfig(options ClientOptions) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName: options.ServerName,
+		//nolint:gosec // skipping the verification is requested explicitly for the staging upstreams
+		InsecureSkipVerify: options.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if len(options.CAFile) > 0 {
+		content, err := os.ReadFile(options.CAFile)
+		if err != nil {
+			return nil, errors.Wrapf(ErrLoadingCertificate, "CA %s: %v", options.CAFile, err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(content) {
+			return nil, errors.Wrapf(ErrLoadingCertificate, "CA %s has no certificates", options.CAFile)
+		}
+	}
+	if len(options.CertFile) > 0 {
+		certificate, err := tls.LoadX509KeyPair(options.CertFile, options.KeyFile)
+		if err != nil {
+			return nil, errors.Wrapf(ErrLoadingCertificate, "cert %s key %s: %v", options.CertFile, options.KeyFile, err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+	return config, nil
+}
+
+func generateCA(certPath, keyPath string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	caTemplate, err := newTemplate("server-faker CA")
+	if err != nil {
+		return nil, nil, err
+	}
+	caTemplate.IsCA = true
+	caTemplate.BasicConstraintsValid = true
+	caTemplate.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+	caKey, caDER, err := createCertificate(caTemplate, caTemplate, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return nil, nil, errors.Wrapf(ErrGeneratingCertificate, "parsing CA: %v", err)
+	}
+	if err := writePair(certPath, keyPath, caDER, caKey); err != nil {
+		return nil, nil, err
+	}
+	return ca, caKey, nil
+}
+
+// loadPair reads the certificate with its key, the certificate has to be valid now
+func loadPair(certPath, keyPath string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	pair, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, nil, errors.Wrapf(ErrLoadingCertificate, "cert %s key %s: %v", certPath, keyPath, err)
+	}
+	certificate, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, nil, errors.Wrapf(ErrLoadingCertificate, "parsing %s: %v", certPath, err)
+	}
+	key, ok := pair.PrivateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, nil, errors.Wrapf(ErrLoadingCertificate, "key %s is not an ecdsa key", keyPath)
+	}
+	now := time.Now()
+	if now.Before(certificate.NotBefore) || now.After(certificate.NotAfter) {
+		return nil, nil, errors.Wrapf(ErrLoadingCertificate, "cert %s expired on %s", certPath, certificate.NotAfter)
+	}
+	return certificate, key, nil
+}
+
+// isIssued checks the certificate is valid, signed by the CA and issued exactly for the hosts
+func isIssued(certPath, keyPath string, ca *x509.Certificate, hosts []string) bool {
+	certificate, _, err := loadPair(certPath, keyPath)
+	if err != nil || certificate.CheckSignatureFrom(ca) != nil {
+		return false
+	}
+	issued := make([]string, 0, len(certificate.DNSNames)+len(certificate.IPAddresses))
+	issued = append(issued, certificate.DNSNames...)
+	for _, ip := range certificate.IPAddresses {
+		issued = append(issued, ip.String())
+	}
+	requested := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			host = ip.String()
+		}
+		requested = append(requested, host)
+	}
+	slices.Sort(issued)
+	slices.Sort(requested)
+	return slices.Equal(slices.Compact(issued), slices.Compact(requested))
+}
+
+func newTemplate(commonName string) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), serialBits))
+	if err != nil {
+		return nil, errors.Wrapf(ErrGeneratingCertificate, "serial number: %v", err)
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"server-faker"}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}, nil
+}
+
+// createCertificate signs the template with the parent key, nil key self signs the certificate
+func createCertificate(
+	template, parent *x509.Certificate,
+	parentKey *ecdsa.PrivateKey,
+) (*ecdsa.PrivateKey, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, errors.Wrapf(ErrGeneratingCertificate, "key: %v", err)
+	}
+	if parentKey == nil {
+		parentKey = key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		return nil, nil, errors.Wrapf(ErrGeneratingCertificate, "%s: %v", template.Subject.CommonName, err)
+	}
+	return key, der, nil
+}
+
+func issue(template, ca *x509.Certificate, caKey *ecdsa.PrivateKey, certPath, keyPath string) error {
+	key, der, err := createCertificate(template, ca, caKey)
+	if err != nil {
+		return err
+	}
+	return writePair(certPath, keyPath, der, key)
+}
+
+func writePair(certPath, keyPath string, der []byte, key *ecdsa.PrivateKey) error {
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return errors.Wrapf(ErrGeneratingCertificate, "marshaling key: %v", err)
+	}
+	if err := writePEM(certPath, "CERTIFICATE", der, certPerm); err != nil {
+		return err
+	}
+	return writePEM(keyPath, "EC PRIVATE KEY", keyDER, keyPerm)
+}
+
+func writePEM(path, blockType string, content []byte, perm os.FileMode) error {
+	encoded := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: content})
+	if err := os.WriteFile(path, encoded, perm); err != nil {
+		return errors.Wrapf(ErrGeneratingCertificate, "writing %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package certs_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/vimek-go/server-faker/internal/pkg/certs"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSelfSigned(t *testing.T) {
+	t.Parallel()
+	files, err := certs.GenerateSelfSigned(path.Join(t.TempDir(), "certs"), []string{"localhost", "127.0.0.1"})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		clientAuth    string
+		clientCert    bool
+		expectedError bool
+		expectedBody  string
+	}{
+		{
+			name:         "server certificate trusted with the CA",
+			clientAuth:   certs.ClientAuthNone,
+			expectedBody: "no client certificate",
+		},
+		{
+			name:         "client certificate verified",
+			clientAuth:   certs.ClientAuthRequire,
+			clientCert:   true,
+			expectedBody: "server-faker-client",
+		},
+		{
+			name:         "client certificate is optional on request",
+			clientAuth:   certs.ClientAuthRequest,
+			expectedBody: "no client certificate",
+		},
+		{
+			name:          "client certificate required",
+			clientAuth:    certs.ClientAuthRequire,
+			expectedError: true,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			config, err := certs.NewTLSConfig(certs.Options{
+				CertFile:     files.Cert,
+				KeyFile:      files.Key,
+				ClientCAFile: files.CA,
+				ClientAuth:   tc.clientAuth,
+			})
+			require.NoError(t, err)
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if len(r.TLS.PeerCertificates) == 0 {
+					_, _ = io.WriteString(w, "no client certificate")
+					return
+				}
+				_, _ = io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+			}))
+			server.TLS = config
+			server.StartTLS()
+			defer server.Close()
+
+			client := newClient(t, files, tc.clientCert)
+			response, err := client.Get(server.URL)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer response.Body.Close()
+			body, err := io.ReadAll(response.Body)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedBody, string(body))
+		})
+	}
+}
+
+func TestGenerateSelfSigned_Reuse(t *testing.T) {
+	t.Parallel()
+	hosts := []string{"localhost", "127.0.0.1"}
+	testCases := []struct {
+		name     string
+		hosts    []string
+		modify   func(*testing.T, string)
+		expected map[string]bool
+	}{
+		{
+			name:     "valid files reused",
+			hosts:    []string{"127.0.0.1", "localhost"},
+			modify:   func(*testing.T, string) {},
+			expected: map[string]bool{"ca.pem": false, "cert.pem": false, "client.pem": false},
+		},
+		{
+			name:     "server certificate regenerated for changed hosts",
+			hosts:    []string{"localhost", "faker.local"},
+			modify:   func(*testing.T, string) {},
+			expected: map[string]bool{"ca.pem": false, "cert.pem": true, "client.pem": false},
+		},
+		{
+			name:  "missing client certificate regenerated",
+			hosts: hosts,
+			modify: func(t *testing.T, dir string) {
+				require.NoError(t, os.Remove(path.Join(dir, "client.pem")))
+			},
+			expected: map[string]bool{"ca.pem": false, "cert.pem": false, "client.pem": true},
+		},
+		{
+			name:  "all regenerated without the CA key",
+			hosts: hosts,
+			modify: func(t *testing.T, dir string) {
+				require.NoError(t, os.Remove(path.Join(dir, "ca-key.pem")))
+			},
+			expected: map[string]bool{"ca.pem": true, "cert.pem": true, "client.pem": true},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			_, err := certs.GenerateSelfSigned(dir, hosts)
+			require.NoError(t, err)
+			before := readFiles(t, dir, tc.expected)
+			tc.modify(t, dir)
+
+			files, err := certs.GenerateSelfSigned(dir, tc.hosts)
+			require.NoError(t, err)
+			after := readFiles(t, dir, tc.expected)
+			for name, changed := range tc.expected {
+				require.Equal(t, changed, before[name] != after[name], name)
+			}
+			_, err = certs.NewTLSConfig(certs.Options{CertFile: files.Cert, KeyFile: files.Key, ClientCAFile: files.CA})
+			require.NoError(t, err)
+		})
+	}
+}
+
+func readFiles(t *testing.T, dir string, names map[string]bool) map[string]string {
+	t.Helper()
+	rval := make(map[string]string, len(names))
+	for name := range names {
+		content, err := os.ReadFile(path.Join(dir, name))
+		require.NoError(t, err)
+		rval[name] = string(content)
+	}
+	return rval
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	t.Parallel()
+	files, err := certs.GenerateSelfSigned(t.TempDir(), []string{"localhost"})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name               string
+		options            certs.Options
+		expectedError      error
+		expectedClientAuth tls.ClientAuthType
+	}{
+		{
+			name:               "client CA requires the certificate by default",
+			options:            certs.Options{CertFile: files.Cert, KeyFile: files.Key, ClientCAFile: files.CA},
+			expectedClientAuth: tls.RequireAndVerifyClientCert,
+		},
+		{
+			name:               "no client auth without client CA",
+			options:            certs.Options{CertFile: files.Cert, KeyFile: files.Key},
+			expectedClientAuth: tls.NoClientCert,
+		},
+		{
+			name:          "error on missing certificate",
+			options:       certs.Options{CertFile: "missing.pem", KeyFile: files.Key},
+			expectedError: certs.ErrLoadingCertificate,
+		},
+		{
+			name:          "error on client auth without client CA",
+			options:       certs.Options{CertFile: files.Cert, KeyFile: files.Key, ClientAuth: certs.ClientAuthRequire},
+			expectedError: certs.ErrLoadingCertificate,
+		},
+		{
+			name:          "error on client CA without certificates",
+			options:       certs.Options{CertFile: files.Cert, KeyFile: files.Key, ClientCAFile: files.Key},
+			expectedError: certs.ErrLoadingCertificate,
+		},
+		{
+			name:          "error on unknown client auth",
+			options:       certs.Options{CertFile: files.Cert, KeyFile: files.Key, ClientAuth: "always"},
+			expectedError: certs.ErrUnknownClientAuth,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			config, err := certs.NewTLSConfig(tc.options)
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedClientAuth, config.ClientAuth)
+		})
+	}
+}
+
+func TestNewClientTLSConfig(t *testing.T) {
+	t.Parallel()
+	files, err := certs.GenerateSelfSigned(t.TempDir(), []string{"localhost"})
+	require.NoError(t, err)
+	serverConfig, err := certs.NewTLSConfig(
+		certs.Options{CertFile: files.Cert, KeyFile: files.Key, ClientCAFile: files.CA},
+	)
+	require.NoError(t, err)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		_, _ = rw.Write([]byte("ok"))
+	}))
+	server.TLS = serverConfig
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	testCases := []struct {
+		name          string
+		options       certs.ClientOptions
+		expectedError error
+		requestFails  bool
+	}{
+		{
+			name: "verified upstream with client certificate",
+			options: certs.ClientOptions{
+				CAFile: files.CA, CertFile: files.ClientCert, KeyFile: files.ClientKey, ServerName: "localhost",
+			},
+		},
+		{
+			name:         "upstream rejects missing client certificate",
+			options:      certs.ClientOptions{CAFile: files.CA, ServerName: "localhost"},
+			requestFails: true,
+		},
+		{
+			name:         "unknown upstream CA",
+			options:      certs.ClientOptions{CertFile: files.ClientCert, KeyFile: files.ClientKey, ServerName: "localhost"},
+			requestFails: true,
+		},
+		{
+			name:    "insecure skip verify",
+			options: certs.ClientOptions{CertFile: files.ClientCert, KeyFile: files.ClientKey, InsecureSkipVerify: true},
+		},
+		{
+			name:          "error on missing CA",
+			options:       certs.ClientOptions{CAFile: "missing.pem"},
+			expectedError: certs.ErrLoadingCertificate,
+		},
+		{
+			name:          "error on CA without certificates",
+			options:       certs.ClientOptions{CAFile: files.ClientKey},
+			expectedError: certs.ErrLoadingCertificate,
+		},
+		{
+			name:          "error on missing key",
+			options:       certs.ClientOptions{CertFile: files.ClientCert, KeyFile: "missing.pem"},
+			expectedError: certs.ErrLoadingCertificate,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			config, err := certs.NewClientTLSConfig(tc.options)
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+			response, err := client.Get(server.URL)
+			if tc.requestFails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer response.Body.Close()
+			body, err := io.ReadAll(response.Body)
+			require.NoError(t, err)
+			require.Equal(t, "ok", string(body))
+		})
+	}
+}
+
+func newClient(t *testing.T, files certs.Files, clientCert bool) *http.Client {
+	t.Helper()
+	ca, err := os.ReadFile(files.CA)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	require.True(t, pool.AppendCertsFromPEM(ca))
+	config := &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12, ServerName: "localhost"}
+	if clientCert {
+		certificate, err := tls.LoadX509KeyPair(files.ClientCert, files.ClientKey)
+		require.NoError(t, err)
+		config.Certificates = []tls.Certificate{certificate}
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+}
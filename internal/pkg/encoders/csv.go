@@ -0,0 +1,124 @@
+package encoders
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+type CSVOptions struct {
+	// Columns defines the order of the columns
+	// by default all the keys are used in alphabetical order
+	Columns []string
+	// Separator replaces the default comma
+	Separator string
+	// SkipHeader removes the row with the column names
+	SkipHeader bool
+}
+
+type csvEncoder struct {
+	options CSVOptions
+}
+
+func NewCSVEncoder(options CSVOptions) Encoder {
+	return &csvEncoder{options: options}
+}
+
+// Encode writes array of flat objects as rows
+// nested objects and arrays are written as json
+func (ce *csvEncoder) Encode(value any) ([]byte, error) {
+	rows, err := ce.prepareRows(value)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := ce.options.Columns
+	if len(columns) == 0 {
+		columns = ce.collectColumns(rows)
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if len(ce.options.Separator) > 0 {
+		separator, size := utf8.DecodeRuneInString(ce.options.Separator)
+		if size != len(ce.options.Separator) {
+			return nil, errors.Wrapf(ErrEncodingFailed, "csv: separator '%s' must be single character", ce.options.Separator)
+		}
+		writer.Comma = separator
+	}
+	if !ce.options.SkipHeader {
+		if err := writer.Write(columns); err != nil {
+			return nil, errors.Wrapf(ErrEncodingFailed, "csv: %v", err)
+		}
+	}
+	for i := range rows {
+		record := make([]string, len(columns))
+		for j, column := range columns {
+			cell, err := ce.cellText(rows[i][column])
+			if err != nil {
+				return nil, errors.Wrapf(ErrEncodingFailed, "csv row %d, column %s: %v", i, column, err)
+			}
+			record[j] = cell
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, errors.Wrapf(ErrEncodingFailed, "csv: %v", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, errors.Wrapf(ErrEncodingFailed, "csv: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (ce *csvEncoder) prepareRows(value any) ([]map[string]any, error) {
+	switch val := value.(type) {
+	case map[string]any:
+		return []map[string]any{val}, nil
+	case []any:
+		rows := make([]map[string]any, len(val))
+		for i := range val {
+			row, ok := val[i].(map[string]any)
+			if !ok {
+				return nil, errors.Wrapf(ErrEncodingFailed, "csv: row %d is not an object, got %T", i, val[i])
+			}
+			rows[i] = row
+		}
+		return rows, nil
+	}
+	return nil, errors.Wrapf(ErrEncodingFailed, "csv: only objects and arrays of objects can be encoded, got %T", value)
+}
+
+func (ce *csvEncoder) collectColumns(rows []map[string]any) []string {
+	unique := make(map[string]bool)
+	for i := range rows {
+		for k := range rows[i] {
+			unique[k] = true
+		}
+	}
+	columns := make([]string, 0, len(unique))
+	for k := range unique {
+		columns = append(columns, k)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+func (ce *csvEncoder) cellText(value any) (string, error) {
+	if isScalar(value) {
+		return scalarText(value), nil
+	}
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+func (ce *csvEncoder) ContentType() string {
+	return "text/csv"
+}
@@ -0,0 +1,68 @@
+package encoders_test
+
+import (
+	"testing"
+
+	"github.com/vimek-go/server-faker/internal/pkg/encoders"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVEncoder_Encode(t *testing.T) {
+	t.Parallel()
+	rows := []any{
+		map[string]any{"id": float64(1), "name": "john", "tags": []any{"a"}},
+		map[string]any{"id": float64(2), "name": "doe, jane"},
+	}
+	testCases := []struct {
+		name          string
+		options       encoders.CSVOptions
+		value         any
+		expected      string
+		expectedError error
+	}{
+		{
+			name:     "columns in alphabetical order",
+			value:    rows,
+			expected: "id,name,tags\n1,john,\"[\"\"a\"\"]\"\n2,\"doe, jane\",\n",
+		},
+		{
+			name:     "configured columns without header",
+			options:  encoders.CSVOptions{Columns: []string{"name", "id"}, SkipHeader: true},
+			value:    rows,
+			expected: "john,1\n\"doe, jane\",2\n",
+		},
+		{
+			name:     "single object with separator",
+			options:  encoders.CSVOptions{Separator: ";"},
+			value:    map[string]any{"id": "1", "name": "john"},
+			expected: "id;name\n1;john\n",
+		},
+		{
+			name:          "invalid separator",
+			options:       encoders.CSVOptions{Separator: ";;"},
+			value:         rows,
+			expectedError: encoders.ErrEncodingFailed,
+		},
+		{
+			name:          "array of single values",
+			value:         []any{"a", "b"},
+			expectedError: encoders.ErrEncodingFailed,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			encoder := encoders.NewCSVEncoder(tc.options)
+			encoded, err := encoder.Encode(tc.value)
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, string(encoded))
+			require.Equal(t, "text/csv", encoder.ContentType())
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package encoders
+
+import (
+	"encoding/json"
+
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNotSupportedFormat = errors.New("format not supported by encoder")
+	ErrEncodingFailed     = errors.New("encoding failed")
+)
+
+// Encoder renders the values generated by the valuers
+type Encoder interface {
+	Encode(value any) ([]byte, error)
+	ContentType() string
+}
+
+type Options struct {
+	XML      XMLOptions
+	CSV      CSVOptions
+	Protobuf ProtobufOptions
+}
+
+func New(format enums.ResponseFormat, options Options) (Encoder, error) {
+	switch format {
+	case enums.ResponseFormats.JSON():
+		return &jsonEncoder{}, nil
+	case enums.ResponseFormats.XML():
+		return NewXMLEncoder(options.XML), nil
+	case enums.ResponseFormats.YAML():
+		return &yamlEncoder{}, nil
+	case enums.ResponseFormats.CSV():
+		return NewCSVEncoder(options.CSV), nil
+	case enums.ResponseFormats.NDJSON():
+		return &ndjsonEncoder{}, nil
+	case enums.ResponseFormats.Form():
+		return &formEncoder{}, nil
+	case enums.ResponseFormats.Msgpack():
+		return newMsgpackEncoder(), nil
+	case enums.ResponseFormats.Protobuf():
+		return NewProtobufEncoder(options.Protobuf), nil
+	}
+	return nil, errors.Wrapf(ErrNotSupportedFormat, "format: %s", format)
+}
+
+type jsonEncoder struct{}
+
+func (je *jsonEncoder) Encode(value any) ([]byte, error) {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, errors.Wrapf(ErrEncodingFailed, "json: %v", err)
+	}
+	return bytes, nil
+}
+
+func (je *jsonEncoder) ContentType() string {
+	return "application/json"
+}
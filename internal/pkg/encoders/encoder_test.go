@@ -0,0 +1,115 @@
+package encoders_test
+
+import (
+	"testing"
+
+	"github.com/vimek-go/server-faker/internal/pkg/encoders"
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+)
+
+func TestNew_Encode(t *testing.T) {
+	t.Parallel()
+	object := map[string]any{
+		"name": "john",
+		"age":  float64(30),
+		"tags": []any{"a", "b"},
+		"address": map[string]any{
+			"city": "warsaw",
+		},
+	}
+	testCases := []struct {
+		name                string
+		format              enums.ResponseFormat
+		value               any
+		expected            string
+		expectedContentType string
+		expectedError       error
+	}{
+		{
+			name:                "json",
+			format:              enums.ResponseFormats.JSON(),
+			value:               object,
+			expected:            `{"address":{"city":"warsaw"},"age":30,"name":"john","tags":["a","b"]}`,
+			expectedContentType: "application/json",
+		},
+		{
+			name:                "yaml",
+			format:              enums.ResponseFormats.YAML(),
+			value:               object,
+			expected:            "address:\n    city: warsaw\nage: 30\nname: john\ntags:\n    - a\n    - b\n",
+			expectedContentType: "application/yaml",
+		},
+		{
+			name:                "ndjson array",
+			format:              enums.ResponseFormats.NDJSON(),
+			value:               []any{map[string]any{"id": 1}, map[string]any{"id": 2}},
+			expected:            "{\"id\":1}\n{\"id\":2}\n",
+			expectedContentType: "application/x-ndjson",
+		},
+		{
+			name:                "ndjson single object",
+			format:              enums.ResponseFormats.NDJSON(),
+			value:               map[string]any{"id": 1},
+			expected:            "{\"id\":1}\n",
+			expectedContentType: "application/x-ndjson",
+		},
+		{
+			name:                "form",
+			format:              enums.ResponseFormats.Form(),
+			value:               object,
+			expected:            "address%5Bcity%5D=warsaw&age=30&name=john&tags=a&tags=b",
+			expectedContentType: "application/x-www-form-urlencoded",
+		},
+		{
+			name:          "form from array",
+			format:        enums.ResponseFormats.Form(),
+			value:         []any{"a"},
+			expectedError: encoders.ErrEncodingFailed,
+		},
+		{
+			name:          "not supported format",
+			format:        enums.ResponseFormats.Bytes(),
+			expectedError: encoders.ErrNotSupportedFormat,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			encoder, err := encoders.New(tc.format, encoders.Options{})
+			if err == nil {
+				var encoded []byte
+				encoded, err = encoder.Encode(tc.value)
+				if err == nil {
+					require.Equal(t, tc.expected, string(encoded))
+					require.Equal(t, tc.expectedContentType, encoder.ContentType())
+				}
+			}
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNew_EncodeMsgpack(t *testing.T) {
+	t.Parallel()
+	encoder, err := encoders.New(enums.ResponseFormats.Msgpack(), encoders.Options{})
+	require.NoError(t, err)
+	require.Equal(t, "application/x-msgpack", encoder.ContentType())
+
+	encoded, err := encoder.Encode(map[string]any{"name": "john", "tags": []any{"a"}})
+	require.NoError(t, err)
+
+	handle := &codec.MsgpackHandle{}
+	handle.RawToString = true
+	var decoded map[string]any
+	require.NoError(t, codec.NewDecoderBytes(encoded, handle).Decode(&decoded))
+	require.Equal(t, "john", decoded["name"])
+	require.Equal(t, []any{"a"}, decoded["tags"])
+}
@@ -0,0 +1,53 @@
+package encoders
+
+import (
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// formEncoder writes objects as url encoded forms
+// array values repeat the key and nested objects use the parent[child] keys
+type formEncoder struct{}
+
+func (fe *formEncoder) Encode(value any) ([]byte, error) {
+	object, ok := value.(map[string]any)
+	if !ok {
+		return nil, errors.Wrapf(ErrEncodingFailed, "form: only objects can be encoded, got %T", value)
+	}
+	return []byte(FormValues(object).Encode()), nil
+}
+
+// FormValues flattens the object to the form fields the way the form encoder does
+func FormValues(object map[string]any) url.Values {
+	form := url.Values{}
+	addFormValues(form, "", object)
+	return form
+}
+
+func addFormValues(form url.Values, prefix string, object map[string]any) {
+	for k, v := range object {
+		key := k
+		if len(prefix) > 0 {
+			key = prefix + "[" + k + "]"
+		}
+		addFormValue(form, key, v)
+	}
+}
+
+func addFormValue(form url.Values, key string, value any) {
+	switch val := value.(type) {
+	case map[string]any:
+		addFormValues(form, key, val)
+	case []any:
+		for i := range val {
+			addFormValue(form, key, val[i])
+		}
+	default:
+		form.Add(key, scalarText(val))
+	}
+}
+
+func (fe *formEncoder) ContentType() string {
+	return "application/x-www-form-urlencoded"
+}
@@ -0,0 +1,29 @@
+package encoders
+
+import (
+	"github.com/pkg/errors"
+	"github.com/ugorji/go/codec"
+)
+
+type msgpackEncoder struct {
+	handle *codec.MsgpackHandle
+}
+
+func newMsgpackEncoder() Encoder {
+	handle := &codec.MsgpackHandle{}
+	// use the current spec with separate str and bin types
+	handle.WriteExt = true
+	return &msgpackEncoder{handle: handle}
+}
+
+func (me *msgpackEncoder) Encode(value any) ([]byte, error) {
+	var bytes []byte
+	if err := codec.NewEncoderBytes(&bytes, me.handle).Encode(value); err != nil {
+		return nil, errors.Wrapf(ErrEncodingFailed, "msgpack: %v", err)
+	}
+	return bytes, nil
+}
+
+func (me *msgpackEncoder) ContentType() string {
+	return "application/x-msgpack"
+}
@@ -0,0 +1,31 @@
+package encoders
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// ndjsonEncoder writes every array element in a separate line
+// other values are written as a single line
+type ndjsonEncoder struct{}
+
+func (ne *ndjsonEncoder) Encode(value any) ([]byte, error) {
+	lines, ok := value.([]any)
+	if !ok {
+		lines = []any{value}
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for i := range lines {
+		if err := encoder.Encode(lines[i]); err != nil {
+			return nil, errors.Wrapf(ErrEncodingFailed, "ndjson line %d: %v", i, err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func (ne *ndjsonEncoder) ContentType() string {
+	return "application/x-ndjson"
+}
@@ -0,0 +1,57 @@
+package encoders
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+type ProtobufOptions struct {
+	// Message describes the encoded message, loaded from a .proto file or a descriptor set
+	Message protoreflect.MessageDescriptor
+}
+
+// protobufEncoder maps the generated values to the message with its json representation
+type protobufEncoder struct {
+	message protoreflect.MessageDescriptor
+}
+
+func NewProtobufEncoder(options ProtobufOptions) Encoder {
+	return &protobufEncoder{message: options.Message}
+}
+
+func (pe *protobufEncoder) Encode(value any) ([]byte, error) {
+	message, err := ToMessage(pe.message, value)
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := proto.Marshal(message)
+	if err != nil {
+		return nil, errors.Wrapf(ErrEncodingFailed, "protobuf: %v", err)
+	}
+	return bytes, nil
+}
+
+func (pe *protobufEncoder) ContentType() string {
+	return "application/x-protobuf"
+}
+
+// ToMessage creates the dynamic message of the descriptor from the generated values
+func ToMessage(descriptor protoreflect.MessageDescriptor, value any) (*dynamicpb.Message, error) {
+	if descriptor == nil {
+		return nil, errors.Wrap(ErrEncodingFailed, "protobuf: message descriptor is not configured")
+	}
+	content, err := json.Marshal(value)
+	if err != nil {
+		return nil, errors.Wrapf(ErrEncodingFailed, "protobuf: %v", err)
+	}
+	message := dynamicpb.NewMessage(descriptor)
+	if err := protojson.Unmarshal(content, message); err != nil {
+		return nil, errors.Wrapf(ErrEncodingFailed, "protobuf message %s: %v", descriptor.FullName(), err)
+	}
+	return message, nil
+}
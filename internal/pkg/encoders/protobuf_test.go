@@ -0,0 +1,66 @@
+package encoders_test
+
+import (
+	"testing"
+
+	"github.com/vimek-go/server-faker/internal/pkg/encoders"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestProtobufEncoder_Encode(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name          string
+		options       encoders.ProtobufOptions
+		value         any
+		expected      proto.Message
+		expectedError error
+	}{
+		{
+			name:     "well known timestamp from string",
+			options:  encoders.ProtobufOptions{Message: (&timestamppb.Timestamp{}).ProtoReflect().Descriptor()},
+			value:    "1970-01-01T00:00:10.000000020Z",
+			expected: &timestamppb.Timestamp{Seconds: 10, Nanos: 20},
+		},
+		{
+			name:    "message from generated object",
+			options: encoders.ProtobufOptions{Message: (&structpb.Struct{}).ProtoReflect().Descriptor()},
+			value:   map[string]any{"name": "john"},
+			expected: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"name": structpb.NewStringValue("john"),
+			}},
+		},
+		{
+			name:          "invalid value",
+			options:       encoders.ProtobufOptions{Message: (&timestamppb.Timestamp{}).ProtoReflect().Descriptor()},
+			value:         map[string]any{"minutes": 10},
+			expectedError: encoders.ErrEncodingFailed,
+		},
+		{
+			name:          "message not configured",
+			value:         map[string]any{"seconds": 10},
+			expectedError: encoders.ErrEncodingFailed,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			encoder := encoders.NewProtobufEncoder(tc.options)
+			require.Equal(t, "application/x-protobuf", encoder.ContentType())
+			encoded, err := encoder.Encode(tc.value)
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			decoded := tc.expected.ProtoReflect().New().Interface()
+			require.NoError(t, proto.Unmarshal(encoded, decoded))
+			require.True(t, proto.Equal(tc.expected, decoded))
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package encoders
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/vimek-go/server-faker/internal/pkg/tools"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultXMLRoot = "response"
+	defaultXMLItem = "item"
+)
+
+var xmlNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9._-]*$`)
+
+type XMLOptions struct {
+	// Root is the name of the document element
+	Root string
+	// Item is the default element name of array items
+	Item string
+	// Items overrides the item element name for arrays under the given key
+	Items map[string]string
+	// Attributes lists the keys rendered as attributes of the parent element
+	// only single values can be attributes, objects and arrays are always elements
+	Attributes []string
+}
+
+type xmlEncoder struct {
+	options XMLOptions
+}
+
+func NewXMLEncoder(options XMLOptions) Encoder {
+	if len(options.Root) == 0 {
+		options.Root = defaultXMLRoot
+	}
+	if len(options.Item) == 0 {
+		options.Item = defaultXMLItem
+	}
+	return &xmlEncoder{options: options}
+}
+
+func (xe *xmlEncoder) Encode(value any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	if err := xe.encodeElement(encoder, xe.options.Root, value); err != nil {
+		return nil, err
+	}
+	if err := encoder.Flush(); err != nil {
+		return nil, errors.Wrapf(ErrEncodingFailed, "xml: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (xe *xmlEncoder) ContentType() string {
+	return "application/xml"
+}
+
+func (xe *xmlEncoder) encodeElement(encoder *xml.Encoder, name string, value any) error {
+	if !xmlNamePattern.MatchString(name) {
+		return errors.Wrapf(ErrEncodingFailed, "xml: invalid element name '%s'", name)
+	}
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	switch val := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		children := make([]string, 0, len(keys))
+		for _, k := range keys {
+			if tools.ArrayContains(k, xe.options.Attributes) && isScalar(val[k]) {
+				if !xmlNamePattern.MatchString(k) {
+					return errors.Wrapf(ErrEncodingFailed, "xml: invalid attribute name '%s'", k)
+				}
+				start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: k}, Value: scalarText(val[k])})
+			} else {
+				children = append(children, k)
+			}
+		}
+		if err := encoder.EncodeToken(start); err != nil {
+			return errors.Wrapf(ErrEncodingFailed, "xml: %v", err)
+		}
+		for _, k := range children {
+			if err := xe.encodeElement(encoder, k, val[k]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if err := encoder.EncodeToken(start); err != nil {
+			return errors.Wrapf(ErrEncodingFailed, "xml: %v", err)
+		}
+		item := xe.options.Item
+		if itemName, ok := xe.options.Items[name]; ok {
+			item = itemName
+		}
+		for i := range val {
+			if err := xe.encodeElement(encoder, item, val[i]); err != nil {
+				return err
+			}
+		}
+	default:
+		if err := encoder.EncodeToken(start); err != nil {
+			return errors.Wrapf(ErrEncodingFailed, "xml: %v", err)
+		}
+		if val != nil {
+			if err := encoder.EncodeToken(xml.CharData(scalarText(val))); err != nil {
+				return errors.Wrapf(ErrEncodingFailed, "xml: %v", err)
+			}
+		}
+	}
+	if err := encoder.EncodeToken(start.End()); err != nil {
+		return errors.Wrapf(ErrEncodingFailed, "xml: %v", err)
+	}
+	return nil
+}
+
+func isScalar(value any) bool {
+	switch value.(type) {
+	case map[string]any, []any:
+		return false
+	}
+	return true
+}
+
+// scalarText formats the single values without the exponent notation for floats
+func scalarText(value any) string {
+	switch val := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(val), 'f', -1, 32)
+	case json.Number:
+		return val.String()
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,74 @@
+package encoders_test
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/vimek-go/server-faker/internal/pkg/encoders"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestXMLEncoder_Encode(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name          string
+		options       encoders.XMLOptions
+		value         any
+		expected      string
+		expectedError error
+	}{
+		{
+			name:     "single value with default root",
+			value:    "value",
+			expected: `<response>value</response>`,
+		},
+		{
+			name:     "object with sorted elements",
+			options:  encoders.XMLOptions{Root: "user"},
+			value:    map[string]any{"name": "john", "age": float64(30), "active": true, "note": nil},
+			expected: `<user><active>true</active><age>30</age><name>john</name><note></note></user>`,
+		},
+		{
+			name:    "attributes and array items",
+			options: encoders.XMLOptions{Root: "users", Attributes: []string{"id"}, Items: map[string]string{"tags": "tag"}},
+			value: []any{
+				map[string]any{"id": float64(1), "tags": []any{"a", "b"}},
+				map[string]any{"id": 2.5, "tags": []any{}},
+			},
+			expected: `<users><item id="1"><tags><tag>a</tag><tag>b</tag></tags></item>` +
+				`<item id="2.5"><tags></tags></item></users>`,
+		},
+		{
+			name:     "objects are never attributes",
+			options:  encoders.XMLOptions{Attributes: []string{"id"}},
+			value:    map[string]any{"id": map[string]any{"value": "1"}},
+			expected: `<response><id><value>1</value></id></response>`,
+		},
+		{
+			name:     "escaped text",
+			value:    map[string]any{"text": "<a & b>"},
+			expected: `<response><text>&lt;a &amp; b&gt;</text></response>`,
+		},
+		{
+			name:          "invalid element name",
+			value:         map[string]any{"invalid key": "value"},
+			expectedError: encoders.ErrEncodingFailed,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			encoder := encoders.NewXMLEncoder(tc.options)
+			encoded, err := encoder.Encode(tc.value)
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, xml.Header+tc.expected, string(encoded))
+			require.Equal(t, "application/xml", encoder.ContentType())
+		})
+	}
+}
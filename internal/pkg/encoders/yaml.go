@@ -0,0 +1,20 @@
+package encoders
+
+import (
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+type yamlEncoder struct{}
+
+func (ye *yamlEncoder) Encode(value any) ([]byte, error) {
+	bytes, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, errors.Wrapf(ErrEncodingFailed, "yaml: %v", err)
+	}
+	return bytes, nil
+}
+
+func (ye *yamlEncoder) ContentType() string {
+	return "application/yaml"
+}
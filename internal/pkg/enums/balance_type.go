@@ -0,0 +1,38 @@
+package enums
+
+type BalanceType string
+
+const (
+	// roundRobin selects the upstreams in turn
+	roundRobin BalanceType = "round_robin"
+	random     BalanceType = "random"
+	// weighted spreads the requests smoothly in proportion to the weights of the upstreams
+	weighted BalanceType = "weighted"
+)
+
+func NewBalanceType(val string) BalanceType {
+	if len(val) > 0 {
+		return BalanceType(val)
+	}
+	return roundRobin
+}
+
+func (bt BalanceType) String() string {
+	return string(bt)
+}
+
+func (bt BalanceType) IsValid() bool {
+	switch bt {
+	case roundRobin, random, weighted:
+		return true
+	}
+	return false
+}
+
+type balanceType struct{}
+
+func (balanceType) RoundRobin() BalanceType { return roundRobin }
+func (balanceType) Random() BalanceType     { return random }
+func (balanceType) Weighted() BalanceType   { return weighted }
+
+var BalanceTypes balanceType
@@ -0,0 +1,17 @@
+package enums_test
+
+import (
+	"testing"
+
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+)
+
+func TestBalanceType(t *testing.T) {
+	t.Parallel()
+	testEnum(t, map[StringEnum]bool{
+		enums.BalanceType("least_conn"): false,
+		enums.BalanceTypes.RoundRobin(): true,
+		enums.BalanceTypes.Random():     true,
+		enums.BalanceTypes.Weighted():   true,
+	})
+}
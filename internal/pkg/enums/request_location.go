@@ -6,6 +6,13 @@ const (
 	requestBody  RequestLocation = "body"
 	requestURL   RequestLocation = "url"
 	requestQuery RequestLocation = "query"
+	// requestCertificate is the subject of the client certificate of mutual tls
+	requestCertificate RequestLocation = "certificate"
+	// requestUpstream is the json body of the proxied response
+	requestUpstream RequestLocation = "upstream"
+	requestHeader   RequestLocation = "header"
+	// requestResponse is the json body of the mocked response, it is available once the response is written
+	requestResponse RequestLocation = "response"
 )
 
 func (rl RequestLocation) String() string {
@@ -14,7 +21,7 @@ func (rl RequestLocation) String() string {
 
 func (rl RequestLocation) IsValid() bool {
 	switch rl {
-	case requestBody, requestURL, requestQuery:
+	case requestBody, requestURL, requestQuery, requestCertificate, requestUpstream, requestHeader, requestResponse:
 		return true
 	}
 	return false
@@ -22,8 +29,12 @@ func (rl RequestLocation) IsValid() bool {
 
 type requestLocation struct{}
 
-func (requestLocation) Body() RequestLocation  { return requestBody }
-func (requestLocation) Query() RequestLocation { return requestQuery }
-func (requestLocation) URL() RequestLocation   { return requestURL }
+func (requestLocation) Body() RequestLocation        { return requestBody }
+func (requestLocation) Query() RequestLocation       { return requestQuery }
+func (requestLocation) URL() RequestLocation         { return requestURL }
+func (requestLocation) Certificate() RequestLocation { return requestCertificate }
+func (requestLocation) Upstream() RequestLocation    { return requestUpstream }
+func (requestLocation) Header() RequestLocation      { return requestHeader }
+func (requestLocation) Response() RequestLocation    { return requestResponse }
 
 var RequestLocations requestLocation
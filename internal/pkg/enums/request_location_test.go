@@ -9,9 +9,13 @@ import (
 func TestRequestLocation(t *testing.T) {
 	t.Parallel()
 	testEnum(t, map[StringEnum]bool{
-		enums.RequestLocation("asd"):   false,
-		enums.RequestLocations.Body():  true,
-		enums.RequestLocations.Query(): true,
-		enums.RequestLocations.URL():   true,
+		enums.RequestLocation("asd"):         false,
+		enums.RequestLocations.Body():        true,
+		enums.RequestLocations.Query():       true,
+		enums.RequestLocations.URL():         true,
+		enums.RequestLocations.Certificate(): true,
+		enums.RequestLocations.Upstream():    true,
+		enums.RequestLocations.Response():    true,
+		enums.RequestLocations.Header():      true,
 	})
 }
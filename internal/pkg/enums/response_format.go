@@ -3,9 +3,15 @@ package enums
 type ResponseFormat string
 
 const (
-	json  ResponseFormat = "json"
-	xml   ResponseFormat = "xml"
-	bytes ResponseFormat = "bytes"
+	json     ResponseFormat = "json"
+	xml      ResponseFormat = "xml"
+	bytes    ResponseFormat = "bytes"
+	yaml     ResponseFormat = "yaml"
+	csv      ResponseFormat = "csv"
+	ndjson   ResponseFormat = "ndjson"
+	form     ResponseFormat = "form"
+	msgpack  ResponseFormat = "msgpack"
+	protobuf ResponseFormat = "protobuf"
 )
 
 func (rf ResponseFormat) String() string {
@@ -14,7 +20,7 @@ func (rf ResponseFormat) String() string {
 
 func (rf ResponseFormat) IsValid() bool {
 	switch rf {
-	case json, xml, bytes:
+	case json, xml, bytes, yaml, csv, ndjson, form, msgpack, protobuf:
 		return true
 	}
 	return false
@@ -22,8 +28,14 @@ func (rf ResponseFormat) IsValid() bool {
 
 type responseFormats struct{}
 
-func (responseFormats) JSON() ResponseFormat  { return json }
-func (responseFormats) XML() ResponseFormat   { return xml }
-func (responseFormats) Bytes() ResponseFormat { return bytes }
+func (responseFormats) JSON() ResponseFormat     { return json }
+func (responseFormats) XML() ResponseFormat      { return xml }
+func (responseFormats) Bytes() ResponseFormat    { return bytes }
+func (responseFormats) YAML() ResponseFormat     { return yaml }
+func (responseFormats) CSV() ResponseFormat      { return csv }
+func (responseFormats) NDJSON() ResponseFormat   { return ndjson }
+func (responseFormats) Form() ResponseFormat     { return form }
+func (responseFormats) Msgpack() ResponseFormat  { return msgpack }
+func (responseFormats) Protobuf() ResponseFormat { return protobuf }
 
 var ResponseFormats responseFormats
@@ -9,9 +9,15 @@ import (
 func TestResponseFormat(t *testing.T) {
 	t.Parallel()
 	testEnum(t, map[StringEnum]bool{
-		enums.ResponseFormat("asd"):   false,
-		enums.ResponseFormats.JSON():  true,
-		enums.ResponseFormats.XML():   true,
-		enums.ResponseFormats.Bytes(): true,
+		enums.ResponseFormat("asd"):      false,
+		enums.ResponseFormats.JSON():     true,
+		enums.ResponseFormats.XML():      true,
+		enums.ResponseFormats.Bytes():    true,
+		enums.ResponseFormats.YAML():     true,
+		enums.ResponseFormats.CSV():      true,
+		enums.ResponseFormats.NDJSON():   true,
+		enums.ResponseFormats.Form():     true,
+		enums.ResponseFormats.Msgpack():  true,
+		enums.ResponseFormats.Protobuf(): true,
 	})
 }
@@ -6,6 +6,8 @@ const (
 	static  ResponseType = "static"
 	dynamic ResponseType = "dynamic"
 	custom  ResponseType = "custom"
+	sse     ResponseType = "sse"
+	grpc    ResponseType = "grpc"
 )
 
 func (rt ResponseType) String() string {
@@ -14,7 +16,7 @@ func (rt ResponseType) String() string {
 
 func (rt ResponseType) IsValid() bool {
 	switch rt {
-	case static, dynamic, custom:
+	case static, dynamic, custom, sse, grpc:
 		return true
 	}
 	return false
@@ -35,5 +37,7 @@ type responseTypes struct{}
 func (responseTypes) Static() ResponseType  { return static }
 func (responseTypes) Dynamic() ResponseType { return dynamic }
 func (responseTypes) Custom() ResponseType  { return custom }
+func (responseTypes) SSE() ResponseType     { return sse }
+func (responseTypes) GRPC() ResponseType    { return grpc }
 
 var ResponseTypes responseTypes
@@ -13,5 +13,7 @@ func TestResponseType(t *testing.T) {
 		enums.ResponseTypes.Static():  true,
 		enums.ResponseTypes.Dynamic(): true,
 		enums.ResponseTypes.Custom():  true,
+		enums.ResponseTypes.SSE():     true,
+		enums.ResponseTypes.GRPC():    true,
 	})
 }
@@ -0,0 +1,39 @@
+package enums
+
+type SequencePolicy string
+
+const (
+	// repeatLast keeps responding with the last response of the sequence
+	repeatLast SequencePolicy = "repeat_last"
+	// cycle starts the sequence over
+	cycle SequencePolicy = "cycle"
+	// exhausted responds with an error once all the responses were returned
+	exhausted SequencePolicy = "error"
+)
+
+func NewSequencePolicy(val string) SequencePolicy {
+	if len(val) > 0 {
+		return SequencePolicy(val)
+	}
+	return repeatLast
+}
+
+func (sp SequencePolicy) String() string {
+	return string(sp)
+}
+
+func (sp SequencePolicy) IsValid() bool {
+	switch sp {
+	case repeatLast, cycle, exhausted:
+		return true
+	}
+	return false
+}
+
+type sequencePolicy struct{}
+
+func (sequencePolicy) RepeatLast() SequencePolicy { return repeatLast }
+func (sequencePolicy) Cycle() SequencePolicy      { return cycle }
+func (sequencePolicy) Error() SequencePolicy      { return exhausted }
+
+var SequencePolicies sequencePolicy
@@ -0,0 +1,17 @@
+package enums_test
+
+import (
+	"testing"
+
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+)
+
+func TestSequencePolicy(t *testing.T) {
+	t.Parallel()
+	testEnum(t, map[StringEnum]bool{
+		enums.SequencePolicy("random"):      false,
+		enums.SequencePolicies.RepeatLast(): true,
+		enums.SequencePolicies.Cycle():      true,
+		enums.SequencePolicies.Error():      true,
+	})
+}
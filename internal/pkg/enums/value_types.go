@@ -8,6 +8,9 @@ const (
 	randomValue ValueType = "random"
 	objectValue ValueType = "object"
 	mappedValue ValueType = "mapped"
+	refValue    ValueType = "ref"
+	// storedValue is read from the store the endpoints save the values to
+	storedValue ValueType = "stored"
 )
 
 func (et ValueType) String() string {
@@ -16,7 +19,7 @@ func (et ValueType) String() string {
 
 func (et ValueType) IsValid() bool {
 	switch et {
-	case staticValue, arrayValue, randomValue, objectValue, mappedValue:
+	case staticValue, arrayValue, randomValue, objectValue, mappedValue, refValue, storedValue:
 		return true
 	}
 	return false
@@ -29,5 +32,7 @@ func (valueTypes) Array() ValueType  { return arrayValue }
 func (valueTypes) Random() ValueType { return randomValue }
 func (valueTypes) Object() ValueType { return objectValue }
 func (valueTypes) Mapped() ValueType { return mappedValue }
+func (valueTypes) Ref() ValueType    { return refValue }
+func (valueTypes) Stored() ValueType { return storedValue }
 
 var ValueTypes valueTypes
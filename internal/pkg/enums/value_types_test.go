@@ -15,5 +15,7 @@ func TestValueType(t *testing.T) {
 		enums.ValueTypes.Random(): true,
 		enums.ValueTypes.Object(): true,
 		enums.ValueTypes.Mapped(): true,
+		enums.ValueTypes.Ref():    true,
+		enums.ValueTypes.Stored(): true,
 	})
 }
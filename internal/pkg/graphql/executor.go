@@ -0,0 +1,390 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"strings"
+
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/tools"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/validator"
+)
+
+var ErrInvalidQuery = errors.New("invalid graphql query")
+
+const (
+	typenameField = "__typename"
+	// default boundaries of the generated values
+	defaultListMin   = 1
+	defaultListMax   = 3
+	defaultNumberMax = 1000
+	defaultStringMin = 5
+	defaultStringMax = 15
+	defaultIDLength  = 8
+)
+
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Response follows the graphql response format, data is left out when the request is invalid
+type Response struct {
+	Data   *Object       `json:"data,omitempty"`
+	Errors gqlerror.List `json:"errors,omitempty"`
+}
+
+type Executor interface {
+	// Execute returns the error wrapping ErrInvalidQuery when the query is not executed,
+	// the response holds the errors in graphql format in both cases
+	Execute(c *gin.Context, request Request) (Response, error)
+}
+
+// Options overrides the generated values
+// Types are keyed by the type name, Fields by the type and field name e.g. Query.user
+type Options struct {
+	ListMin int
+	ListMax int
+	Types   map[string]values.Valuer
+	Fields  map[string]values.Valuer
+}
+
+type executor struct {
+	schema   *ast.Schema
+	options  Options
+	defaults map[string]values.Valuer
+	logger   logger.Logger
+}
+
+func NewExecutor(schema *ast.Schema, options Options, logger logger.Logger) (Executor, error) {
+	if options.ListMin == 0 && options.ListMax == 0 {
+		options.ListMin, options.ListMax = defaultListMin, defaultListMax
+	}
+	defaults := make(map[string]values.Valuer)
+	for name, random := range map[string]struct {
+		kind     enums.RandomKind
+		min, max int
+	}{
+		"Int":     {enums.RandomKinds.Integer(), 0, defaultNumberMax},
+		"Float":   {enums.RandomKinds.Float(), 0, defaultNumberMax},
+		"String":  {enums.RandomKinds.StringAll(), defaultStringMin, defaultStringMax},
+		"Boolean": {enums.RandomKinds.Boolean(), 0, 0},
+		"ID":      {enums.RandomKinds.StringNumeric(), defaultIDLength, defaultIDLength},
+	} {
+		valuer, err := values.NewRandomValuer("", random.kind.String(), random.min, random.max)
+		if err != nil {
+			return nil, err
+		}
+		defaults[name] = valuer
+	}
+	return &executor{schema: schema, options: options, defaults: defaults, logger: logger}, nil
+}
+
+func (e *executor) Execute(c *gin.Context, request Request) (Response, error) {
+	document, errs := gqlparser.LoadQuery(e.schema, request.Query)
+	if len(errs) > 0 {
+		return Response{Errors: errs}, errors.Wrap(ErrInvalidQuery, errs.Error())
+	}
+	operation := document.Operations.ForName(request.OperationName)
+	if operation == nil {
+		err := gqlerror.Errorf("operation %q not found in the query", request.OperationName)
+		return Response{Errors: gqlerror.List{err}}, errors.Wrap(ErrInvalidQuery, err.Error())
+	}
+	variables, err := validator.VariableValues(e.schema, operation, request.Variables)
+	if err != nil {
+		gqlErr := gqlerror.WrapIfUnwrapped(err)
+		return Response{Errors: gqlerror.List{gqlErr}}, errors.Wrap(ErrInvalidQuery, err.Error())
+	}
+	var root *ast.Definition
+	switch operation.Operation {
+	case ast.Query:
+		root = e.schema.Query
+	case ast.Mutation:
+		root = e.schema.Mutation
+	}
+	if root == nil {
+		err := gqlerror.Errorf("operation type %s is not supported", operation.Operation)
+		return Response{Errors: gqlerror.List{err}}, errors.Wrap(ErrInvalidQuery, err.Error())
+	}
+
+	x := &execution{executor: e, c: c, document: document, variables: variables}
+	data := x.selectionSet(root, operation.SelectionSet, nil, nil)
+	return Response{Data: data, Errors: x.errors}, nil
+}
+
+// execution holds the state of a single request
+type execution struct {
+	*executor
+	c         *gin.Context
+	document  *ast.QueryDocument
+	variables map[string]any
+	errors    gqlerror.List
+}
+
+func (x *execution) selectionSet(
+	definition *ast.Definition,
+	set ast.SelectionSet,
+	override map[string]any,
+	path ast.Path,
+) *Object {
+	keys, grouped := x.collectFields(definition, set, nil, make(map[string][]*ast.Field), make(map[string]bool))
+	object := &Object{values: make(map[string]any, len(keys))}
+	for _, key := range keys {
+		fields := grouped[key]
+		value, err := x.field(definition, fields, override, extend(path, ast.PathName(key)))
+		if err != nil {
+			x.errors = append(x.errors, err)
+		}
+		object.Set(key, value)
+	}
+	return object
+}
+
+// collectFields groups the selected fields by the response keys, keeping the order of the query
+func (x *execution) collectFields(
+	definition *ast.Definition,
+	set ast.SelectionSet,
+	keys []string,
+	grouped map[string][]*ast.Field,
+	visited map[string]bool,
+) ([]string, map[string][]*ast.Field) {
+	for _, selection := range set {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if !x.included(selection.Directives) {
+				continue
+			}
+			key := selection.Alias
+			if len(key) == 0 {
+				key = selection.Name
+			}
+			if _, ok := grouped[key]; !ok {
+				keys = append(keys, key)
+			}
+			grouped[key] = append(grouped[key], selection)
+		case *ast.InlineFragment:
+			if !x.included(selection.Directives) || !x.applies(definition, selection.TypeCondition) {
+				continue
+			}
+			keys, grouped = x.collectFields(definition, selection.SelectionSet, keys, grouped, visited)
+		case *ast.FragmentSpread:
+			if visited[selection.Name] || !x.included(selection.Directives) {
+				continue
+			}
+			visited[selection.Name] = true
+			fragment := x.document.Fragments.ForName(selection.Name)
+			if fragment == nil || !x.applies(definition, fragment.TypeCondition) {
+				continue
+			}
+			keys, grouped = x.collectFields(definition, fragment.SelectionSet, keys, grouped, visited)
+		}
+	}
+	return keys, grouped
+}
+
+func (x *execution) included(directives ast.DirectiveList) bool {
+	if skip := directives.ForName("skip"); skip != nil && skip.ArgumentMap(x.variables)["if"] == true {
+		return false
+	}
+	if include := directives.ForName("include"); include != nil && include.ArgumentMap(x.variables)["if"] == false {
+		return false
+	}
+	return true
+}
+
+func (x *execution) applies(definition *ast.Definition, condition string) bool {
+	if len(condition) == 0 || condition == definition.Name {
+		return true
+	}
+	for _, possible := range x.schema.GetPossibleTypes(x.schema.Types[condition]) {
+		if possible.Name == definition.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func (x *execution) field(
+	parent *ast.Definition,
+	fields []*ast.Field,
+	override map[string]any,
+	path ast.Path,
+) (any, *gqlerror.Error) {
+	field := fields[0]
+	if field.Name == typenameField {
+		return parent.Name, nil
+	}
+	if strings.HasPrefix(field.Name, "__") {
+		return nil, gqlerror.ErrorPathf(path, "introspection field %s is not supported", field.Name)
+	}
+	arguments := field.ArgumentMap(x.variables)
+	value, found := override[field.Name]
+	if !found {
+		if valuer, ok := x.options.Fields[parent.Name+"."+field.Name]; ok {
+			generated, err := x.generate(valuer, arguments, path)
+			if err != nil {
+				return nil, err
+			}
+			value, found = generated, true
+		}
+	}
+	return x.complete(field.Definition.Type, fields, arguments, value, found, path)
+}
+
+// complete generates the value of the type unless it was provided by the overrides
+// the provided objects are projected on the selection, missing fields are generated
+func (x *execution) complete(
+	typ *ast.Type,
+	fields []*ast.Field,
+	arguments map[string]any,
+	value any,
+	found bool,
+	path ast.Path,
+) (any, *gqlerror.Error) {
+	if found && value == nil {
+		return nil, nil
+	}
+	if typ.Elem != nil {
+		return x.completeList(typ, fields, arguments, value, found, path)
+	}
+	definition := x.schema.Types[typ.NamedType]
+	if !found && definition.Kind != ast.Interface && definition.Kind != ast.Union {
+		if valuer, ok := x.options.Types[definition.Name]; ok {
+			generated, err := x.generate(valuer, arguments, path)
+			if err != nil {
+				return nil, err
+			}
+			if generated == nil {
+				return nil, nil
+			}
+			value, found = generated, true
+		}
+	}
+	switch definition.Kind {
+	case ast.Scalar:
+		if found {
+			return value, nil
+		}
+		if valuer, ok := x.defaults[definition.Name]; ok {
+			return x.generate(valuer, arguments, path)
+		}
+		// custom scalars without override are generated as strings
+		return x.generate(x.defaults["String"], arguments, path)
+	case ast.Enum:
+		if found {
+			return value, nil
+		}
+		return definition.EnumValues[rand.Intn(len(definition.EnumValues))].Name, nil
+	case ast.Interface, ast.Union:
+		concrete, err := x.resolveType(definition, value, path)
+		if err != nil {
+			return nil, err
+		}
+		return x.complete(ast.NamedType(concrete.Name, nil), fields, arguments, value, found, path)
+	}
+
+	var override map[string]any
+	if found {
+		var ok bool
+		if override, ok = value.(map[string]any); !ok {
+			return nil, gqlerror.ErrorPathf(path, "expected object for type %s, got %T", definition.Name, value)
+		}
+	}
+	var set ast.SelectionSet
+	for _, field := range fields {
+		set = append(set, field.SelectionSet...)
+	}
+	return x.selectionSet(definition, set, override, path), nil
+}
+
+func (x *execution) completeList(
+	typ *ast.Type,
+	fields []*ast.Field,
+	arguments map[string]any,
+	value any,
+	found bool,
+	path ast.Path,
+) (any, *gqlerror.Error) {
+	var items []any
+	if found {
+		var ok bool
+		if items, ok = value.([]any); !ok {
+			return nil, gqlerror.ErrorPathf(path, "expected list for type %s, got %T", typ.String(), value)
+		}
+	} else {
+		items = make([]any, tools.GenerateRandomInt(x.options.ListMin, x.options.ListMax))
+	}
+	list := make([]any, len(items))
+	for i := range items {
+		item, err := x.complete(typ.Elem, fields, arguments, items[i], found, extend(path, ast.PathIndex(i)))
+		if err != nil {
+			return nil, err
+		}
+		list[i] = item
+	}
+	return list, nil
+}
+
+// resolveType picks the type named by __typename of the provided value or a random possible type
+func (x *execution) resolveType(definition *ast.Definition, value any, path ast.Path) (*ast.Definition, *gqlerror.Error) {
+	possible := x.schema.GetPossibleTypes(definition)
+	if object, ok := value.(map[string]any); ok {
+		if name, ok := object[typenameField].(string); ok {
+			for _, p := range possible {
+				if p.Name == name {
+					return p, nil
+				}
+			}
+			return nil, gqlerror.ErrorPathf(path, "type %s is not possible for %s", name, definition.Name)
+		}
+	}
+	if len(possible) == 0 {
+		return nil, gqlerror.ErrorPathf(path, "type %s has no possible types", definition.Name)
+	}
+	return possible[rand.Intn(len(possible))], nil
+}
+
+// generate runs the valuer with the field arguments and the query variables exposed as the body
+// {"args": {...}, "variables": {...}}, the result is normalized to the json types
+func (x *execution) generate(valuer values.Valuer, arguments map[string]any, path ast.Path) (any, *gqlerror.Error) {
+	body, err := json.Marshal(map[string]any{"args": arguments, "variables": x.variables})
+	if err != nil {
+		return nil, gqlerror.WrapPath(path, err)
+	}
+	request := x.c.Request.Clone(x.c.Request.Context())
+	request.Body = io.NopCloser(bytes.NewReader(body))
+	request.ContentLength = int64(len(body))
+	request.Header.Set("Content-Type", "application/json")
+	value, err := valuer.Generate(&gin.Context{Request: request, Params: x.c.Params})
+	if err != nil {
+		x.logger.Error(err)
+		return nil, gqlerror.WrapPath(path, err)
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, gqlerror.WrapPath(path, err)
+	}
+	var normalized any
+	if err := json.Unmarshal(encoded, &normalized); err != nil {
+		return nil, gqlerror.WrapPath(path, err)
+	}
+	return normalized, nil
+}
+
+// extend copies the path, the errors keep the paths of the fields they were raised for
+func extend(path ast.Path, element ast.PathElement) ast.Path {
+	extended := make(ast.Path, len(path), len(path)+1)
+	copy(extended, path)
+	return append(extended, element)
+}
@@ -0,0 +1,305 @@
+package graphql_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/graphql"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/tools"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const testSchema = `
+scalar DateTime
+
+enum Role {
+  ADMIN
+  USER
+}
+
+interface Node {
+  id: ID!
+}
+
+type User implements Node {
+  id: ID!
+  name: String!
+  age: Int
+  role: Role!
+  created: DateTime
+  friends: [User!]!
+}
+
+type Post implements Node {
+  id: ID!
+  title: String!
+}
+
+union SearchResult = User | Post
+
+type Query {
+  user(id: ID!): User
+  users: [User!]!
+  search(text: String!): [SearchResult!]!
+  node(id: ID!): Node
+}
+
+type Mutation {
+  rename(id: ID!, name: String!): User!
+}
+`
+
+func loadTestSchema(t *testing.T) *ast.Schema {
+	t.Helper()
+	schemaPath := path.Join(t.TempDir(), "schema.graphql")
+	tools.SaveToAFile(t, testSchema, schemaPath)
+	schema, err := graphql.LoadSchema(schemaPath)
+	require.NoError(t, err)
+	return schema
+}
+
+func mapped(t *testing.T, key, jsonPath string) values.Valuer {
+	t.Helper()
+	valuer, err := values.NewMappedValuer(
+		key, "", jsonPath, "/graphql",
+		enums.RequestLocations.Body(), nil, enums.ConversionTypes.None(), logger.NewTestLogger(),
+	)
+	require.NoError(t, err)
+	return valuer
+}
+
+func TestLoadSchema(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	tools.SaveToAFile(t, "type Query { user: Unknown }", path.Join(dir, "invalid.graphql"))
+
+	_, err := graphql.LoadSchema(path.Join(dir, "invalid.graphql"))
+	require.ErrorIs(t, err, graphql.ErrLoadingSchema)
+	_, err = graphql.LoadSchema(path.Join(dir, "missing.graphql"))
+	require.ErrorIs(t, err, graphql.ErrLoadingSchema)
+}
+
+func TestExecutor_Execute(t *testing.T) {
+	t.Parallel()
+	schema := loadTestSchema(t)
+	testCases := []struct {
+		name          string
+		options       func(t *testing.T) graphql.Options
+		request       graphql.Request
+		expectedError error
+		asserts       func(t *testing.T, response map[string]any)
+	}{
+		{
+			name: "generated values follow the field types",
+			request: graphql.Request{
+				Query: `{ user(id: "1") { __typename id name age role created } }`,
+			},
+			asserts: func(t *testing.T, response map[string]any) {
+				user := response["data"].(map[string]any)["user"].(map[string]any)
+				require.Equal(t, "User", user["__typename"])
+				require.Len(t, user["id"], 8)
+				require.NotEmpty(t, user["name"])
+				require.IsType(t, float64(0), user["age"])
+				require.Contains(t, []any{"ADMIN", "USER"}, user["role"])
+				require.IsType(t, "", user["created"])
+			},
+		},
+		{
+			name: "field override maps the arguments and variables",
+			options: func(t *testing.T) graphql.Options {
+				return graphql.Options{Fields: map[string]values.Valuer{
+					"Mutation.rename": values.NewObjectValuer("", []values.Valuer{
+						mapped(t, "id", "$.args.id"),
+						mapped(t, "name", "$.variables.name"),
+					}),
+				}}
+			},
+			request: graphql.Request{
+				Query:     `mutation Rename($id: ID!, $name: String!) { rename(id: $id, name: $name) { id name role } }`,
+				Variables: map[string]any{"id": "42", "name": "Ann"},
+			},
+			asserts: func(t *testing.T, response map[string]any) {
+				user := response["data"].(map[string]any)["rename"].(map[string]any)
+				require.Equal(t, "42", user["id"])
+				require.Equal(t, "Ann", user["name"])
+				require.Contains(t, []any{"ADMIN", "USER"}, user["role"])
+			},
+		},
+		{
+			name: "type override and list length",
+			options: func(t *testing.T) graphql.Options {
+				return graphql.Options{
+					ListMin: 2,
+					ListMax: 2,
+					Types:   map[string]values.Valuer{"DateTime": values.NewStaticValuer("", "2024-01-01T00:00:00Z")},
+				}
+			},
+			request: graphql.Request{Query: `{ users { created friends { id } } }`},
+			asserts: func(t *testing.T, response map[string]any) {
+				users := response["data"].(map[string]any)["users"].([]any)
+				require.Len(t, users, 2)
+				for _, user := range users {
+					require.Equal(t, "2024-01-01T00:00:00Z", user.(map[string]any)["created"])
+					require.Len(t, user.(map[string]any)["friends"], 2)
+				}
+			},
+		},
+		{
+			name: "override lists and nulls are kept",
+			options: func(t *testing.T) graphql.Options {
+				return graphql.Options{Fields: map[string]values.Valuer{
+					"Query.users": values.NewStaticValuer("", []any{
+						map[string]any{"name": "Ann", "age": nil},
+					}),
+				}}
+			},
+			request: graphql.Request{Query: `{ users { name age } }`},
+			asserts: func(t *testing.T, response map[string]any) {
+				users := response["data"].(map[string]any)["users"].([]any)
+				require.Equal(t, []any{map[string]any{"name": "Ann", "age": nil}}, users)
+			},
+		},
+		{
+			name: "union members are resolved with fragments",
+			options: func(t *testing.T) graphql.Options {
+				return graphql.Options{ListMin: 5, ListMax: 5}
+			},
+			request: graphql.Request{Query: `
+				query { search(text: "a") { __typename ...UserFields ... on Post { title } } }
+				fragment UserFields on User { name }
+			`},
+			asserts: func(t *testing.T, response map[string]any) {
+				results := response["data"].(map[string]any)["search"].([]any)
+				require.Len(t, results, 5)
+				for _, result := range results {
+					item := result.(map[string]any)
+					switch item["__typename"] {
+					case "User":
+						require.Contains(t, item, "name")
+						require.NotContains(t, item, "title")
+					case "Post":
+						require.Contains(t, item, "title")
+						require.NotContains(t, item, "name")
+					default:
+						t.Fatalf("unexpected type %v", item["__typename"])
+					}
+				}
+			},
+		},
+		{
+			name: "interface type selected by __typename of the override",
+			options: func(t *testing.T) graphql.Options {
+				return graphql.Options{Fields: map[string]values.Valuer{
+					"Query.node": values.NewStaticValuer("", map[string]any{"__typename": "Post", "id": "p1"}),
+				}}
+			},
+			request: graphql.Request{Query: `{ node(id: "p1") { __typename id ... on Post { title } } }`},
+			asserts: func(t *testing.T, response map[string]any) {
+				node := response["data"].(map[string]any)["node"].(map[string]any)
+				require.Equal(t, "Post", node["__typename"])
+				require.Equal(t, "p1", node["id"])
+				require.NotEmpty(t, node["title"])
+			},
+		},
+		{
+			name: "mutation with skip and include directives",
+			request: graphql.Request{
+				Query:     `mutation($skip: Boolean!) { rename(id: "1", name: "Bob") { id @skip(if: $skip) name @include(if: $skip) } }`,
+				Variables: map[string]any{"skip": true},
+			},
+			asserts: func(t *testing.T, response map[string]any) {
+				user := response["data"].(map[string]any)["rename"].(map[string]any)
+				require.NotContains(t, user, "id")
+				require.Contains(t, user, "name")
+			},
+		},
+		{
+			name:    "introspection is reported as field error",
+			request: graphql.Request{Query: `{ __schema { queryType { name } } }`},
+			asserts: func(t *testing.T, response map[string]any) {
+				require.Equal(t, map[string]any{"__schema": nil}, response["data"])
+				errs := response["errors"].([]any)
+				require.Len(t, errs, 1)
+				require.Equal(t, []any{"__schema"}, errs[0].(map[string]any)["path"])
+			},
+		},
+		{
+			name:          "error on unknown field",
+			request:       graphql.Request{Query: `{ user(id: "1") { email } }`},
+			expectedError: graphql.ErrInvalidQuery,
+			asserts: func(t *testing.T, response map[string]any) {
+				require.NotContains(t, response, "data")
+				errs := response["errors"].([]any)
+				require.Contains(t, errs[0].(map[string]any)["message"], "Cannot query field \"email\"")
+				require.Contains(t, errs[0].(map[string]any), "locations")
+			},
+		},
+		{
+			name: "error on missing variable",
+			request: graphql.Request{
+				Query: `query($id: ID!) { user(id: $id) { id } }`,
+			},
+			expectedError: graphql.ErrInvalidQuery,
+		},
+		{
+			name: "error on unknown operation",
+			request: graphql.Request{
+				Query:         `query A { users { id } } query B { users { name } }`,
+				OperationName: "C",
+			},
+			expectedError: graphql.ErrInvalidQuery,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var options graphql.Options
+			if tc.options != nil {
+				options = tc.options(t)
+			}
+			executor, err := graphql.NewExecutor(schema, options, logger.NewTestLogger())
+			require.NoError(t, err)
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/graphql", nil)
+
+			response, err := executor.Execute(c, tc.request)
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				require.NotEmpty(t, response.Errors)
+			} else {
+				require.NoError(t, err)
+			}
+			if tc.asserts != nil {
+				encoded, err := json.Marshal(response)
+				require.NoError(t, err)
+				var decoded map[string]any
+				require.NoError(t, json.Unmarshal(encoded, &decoded))
+				tc.asserts(t, decoded)
+			}
+		})
+	}
+}
+
+func TestObject_MarshalJSON(t *testing.T) {
+	t.Parallel()
+	var object graphql.Object
+	object.Set("b", 1)
+	object.Set("a", map[string]any{"c": true})
+	object.Set("b", 2)
+
+	encoded, err := json.Marshal(&object)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"b":2,"a":{"c":true}}`, string(encoded))
+	require.Equal(t, `{"b":2,"a":{"c":true}}`, string(encoded))
+	require.Equal(t, []string{"b", "a"}, object.Keys())
+}
@@ -0,0 +1,24 @@
+package graphql
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+var ErrLoadingSchema = errors.New("failed loading graphql schema")
+
+// LoadSchema parses and validates the SDL file
+func LoadSchema(path string) (*ast.Schema, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(ErrLoadingSchema, "file %s: %v", path, err)
+	}
+	schema, err := gqlparser.LoadSchema(&ast.Source{Name: path, Input: string(content)})
+	if err != nil {
+		return nil, errors.Wrapf(ErrLoadingSchema, "file %s: %v", path, err)
+	}
+	return schema, nil
+}
@@ -0,0 +1,106 @@
+package grpcmock
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	"github.com/vimek-go/server-faker/internal/pkg/encoders"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var ErrGeneratingResponse = errors.New("error generating response")
+
+// MethodHandler responds to the calls of a single method of the service
+type MethodHandler interface {
+	Method() protoreflect.MethodDescriptor
+	Respond(ctx context.Context, request proto.Message) (proto.Message, error)
+}
+
+type methodHandler struct {
+	method protoreflect.MethodDescriptor
+	// static response encoded at load time
+	static proto.Message
+	// valuer generates the response from the request message
+	valuer values.Valuer
+	// status is returned instead of the response if set
+	status *spb.Status
+	logger logger.Logger
+}
+
+func NewStaticHandler(method protoreflect.MethodDescriptor, response proto.Message, logger logger.Logger) MethodHandler {
+	return &methodHandler{method: method, static: response, logger: logger}
+}
+
+func NewDynamicHandler(method protoreflect.MethodDescriptor, valuer values.Valuer, logger logger.Logger) MethodHandler {
+	return &methodHandler{method: method, valuer: valuer, logger: logger}
+}
+
+func NewStatusHandler(method protoreflect.MethodDescriptor, status *spb.Status, logger logger.Logger) MethodHandler {
+	return &methodHandler{method: method, status: status, logger: logger}
+}
+
+func (h *methodHandler) Method() protoreflect.MethodDescriptor {
+	return h.method
+}
+
+func (h *methodHandler) Respond(ctx context.Context, request proto.Message) (proto.Message, error) {
+	if h.status != nil {
+		return nil, status.ErrorProto(h.status)
+	}
+	if h.valuer == nil {
+		return h.static, nil
+	}
+	c, err := h.prepareContext(ctx, request)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	value, err := h.valuer.Generate(c)
+	if err != nil {
+		h.logger.Error(err)
+		return nil, status.Errorf(codes.Internal, "%v: %v", ErrGeneratingResponse, err)
+	}
+	response, err := encoders.ToMessage(h.method.Output(), value)
+	if err != nil {
+		h.logger.Error(err)
+		return nil, status.Errorf(codes.Internal, "%v: %v", ErrGeneratingResponse, err)
+	}
+	return response, nil
+}
+
+// prepareContext exposes the request message as a json body to the valuers
+// so the payload mappers work on the json form of the message, metadata is passed as headers
+func (h *methodHandler) prepareContext(ctx context.Context, request proto.Message) (*gin.Context, error) {
+	body, err := protojson.Marshal(request)
+	if err != nil {
+		return nil, errors.Wrapf(err, "request of %s", h.method.FullName())
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, fullMethodName(h.method), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for key, items := range md {
+			for _, value := range items {
+				httpRequest.Header.Add(key, value)
+			}
+		}
+	}
+	return &gin.Context{Request: httpRequest}, nil
+}
+
+func fullMethodName(method protoreflect.MethodDescriptor) string {
+	return "/" + string(method.Parent().FullName()) + "/" + string(method.Name())
+}
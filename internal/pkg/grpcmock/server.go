@@ -0,0 +1,159 @@
+package grpcmock
+
+import (
+	"io"
+	"net"
+
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	v1reflectiongrpc "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	v1alphareflectiongrpc "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	// registers the google.rpc error details used in the status responses
+	_ "google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+var ErrDuplicatedService = errors.New("duplicated service")
+
+// Service groups the mocked methods with the files the service is declared in
+type Service struct {
+	Descriptor protoreflect.ServiceDescriptor
+	Files      *protoregistry.Files
+	Handlers   []MethodHandler
+}
+
+type Server interface {
+	Serve(listener net.Listener) error
+	Stop()
+}
+
+type server struct {
+	grpcServer *grpc.Server
+	logger     logger.Logger
+}
+
+// NewServer registers the services with the reflection, so the server can be explored with grpcurl
+func NewServer(services []Service, logger logger.Logger) (Server, error) {
+	grpcServer := grpc.NewServer()
+	resolver := &filesResolver{}
+	registered := make(map[protoreflect.FullName]bool, len(services))
+	for _, service := range services {
+		name := service.Descriptor.FullName()
+		if registered[name] {
+			return nil, errors.Wrapf(ErrDuplicatedService, "service %s", name)
+		}
+		registered[name] = true
+		resolver.files = append(resolver.files, service.Files)
+		grpcServer.RegisterService(prepareServiceDesc(service, logger), nil)
+		logger.Infof("registered grpc service %s with %d methods", name, len(service.Handlers))
+	}
+
+	options := reflection.ServerOptions{Services: grpcServer, DescriptorResolver: resolver}
+	v1reflectiongrpc.RegisterServerReflectionServer(grpcServer, reflection.NewServerV1(options))
+	v1alphareflectiongrpc.RegisterServerReflectionServer(grpcServer, reflection.NewServer(options))
+	return &server{grpcServer: grpcServer, logger: logger}, nil
+}
+
+func (s *server) Serve(listener net.Listener) error {
+	s.logger.Infof("grpc server listening on %s", listener.Addr())
+	return s.grpcServer.Serve(listener)
+}
+
+func (s *server) Stop() {
+	s.grpcServer.GracefulStop()
+}
+
+// prepareServiceDesc handles every method as a stream, so unary and streaming calls share the handler
+// streaming requests are read until the client closes the stream and a single response is sent
+// methods without a handler respond with Unimplemented
+func prepareServiceDesc(service Service, logger logger.Logger) *grpc.ServiceDesc {
+	handlers := make(map[protoreflect.Name]MethodHandler, len(service.Handlers))
+	for _, handler := range service.Handlers {
+		handlers[handler.Method().Name()] = handler
+	}
+	desc := &grpc.ServiceDesc{
+		ServiceName: string(service.Descriptor.FullName()),
+		HandlerType: (*any)(nil),
+		Metadata:    service.Descriptor.ParentFile().Path(),
+	}
+	methods := service.Descriptor.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		method := methods.Get(i)
+		handler := handlers[method.Name()]
+		desc.Streams = append(desc.Streams, grpc.StreamDesc{
+			StreamName:    string(method.Name()),
+			ServerStreams: method.IsStreamingServer(),
+			ClientStreams: method.IsStreamingClient(),
+			Handler: func(_ any, stream grpc.ServerStream) error {
+				logger.Infof("[RequestLog] grpc: %s", fullMethodName(method))
+				if handler == nil {
+					return status.Errorf(codes.Unimplemented, "method %s is not mocked", fullMethodName(method))
+				}
+				request, err := receiveRequest(stream, method)
+				if err != nil {
+					return err
+				}
+				response, err := handler.Respond(stream.Context(), request)
+				if err != nil {
+					logger.Infof("[ResponseLog] grpc: %s, status: %s", fullMethodName(method), status.Code(err))
+					return err
+				}
+				logger.Infof("[ResponseLog] grpc: %s, status: %s", fullMethodName(method), codes.OK)
+				return stream.SendMsg(response)
+			},
+		})
+	}
+	return desc
+}
+
+// receiveRequest reads the request, for the client streams the last message is used
+func receiveRequest(stream grpc.ServerStream, method protoreflect.MethodDescriptor) (*dynamicpb.Message, error) {
+	request := dynamicpb.NewMessage(method.Input())
+	if err := stream.RecvMsg(request); err != nil {
+		return nil, err
+	}
+	if !method.IsStreamingClient() {
+		return request, nil
+	}
+	for {
+		next := dynamicpb.NewMessage(method.Input())
+		if err := stream.RecvMsg(next); err != nil {
+			if errors.Is(err, io.EOF) {
+				return request, nil
+			}
+			return nil, err
+		}
+		request = next
+	}
+}
+
+// filesResolver looks up the descriptors in the files of all the services
+type filesResolver struct {
+	files []*protoregistry.Files
+}
+
+func (r *filesResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	for _, files := range r.files {
+		if file, err := files.FindFileByPath(path); err == nil {
+			return file, nil
+		}
+	}
+	return protoregistry.GlobalFiles.FindFileByPath(path)
+}
+
+func (r *filesResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	for _, files := range r.files {
+		if descriptor, err := files.FindDescriptorByName(name); err == nil {
+			return descriptor, nil
+		}
+	}
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
+}
@@ -0,0 +1,173 @@
+package grpcmock_test
+
+import (
+	"context"
+	"net"
+	"path"
+	"testing"
+
+	"github.com/vimek-go/server-faker/internal/pkg/encoders"
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/grpcmock"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/protos"
+	"github.com/vimek-go/server-faker/internal/pkg/tools"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	v1reflectiongrpc "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	v1reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const userProto = `syntax = "proto3";
+package user.v1;
+
+message GetUserRequest { string id = 1; }
+message User { string id = 1; string name = 2; }
+
+service UserService {
+  rpc GetUser(GetUserRequest) returns (User);
+  rpc FindUser(GetUserRequest) returns (User);
+  rpc DeleteUser(GetUserRequest) returns (User);
+  rpc UpdateUser(GetUserRequest) returns (User);
+}
+`
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+	testLogger := logger.NewTestLogger()
+	dir := t.TempDir()
+	tools.SaveToAFile(t, userProto, path.Join(dir, "user.proto"))
+	loader := protos.NewLoader(testLogger)
+	files, err := loader.Load(path.Join(dir, "user.proto"), nil)
+	require.NoError(t, err)
+	service, err := loader.FindService(path.Join(dir, "user.proto"), nil, "user.v1.UserService")
+	require.NoError(t, err)
+	methods := service.Methods()
+
+	static, err := encoders.ToMessage(methods.ByName("GetUser").Output(), map[string]any{"id": "1", "name": "john"})
+	require.NoError(t, err)
+	mapped, err := values.NewMappedValuer("id", "", "$.id", "", enums.RequestLocations.Body(), nil,
+		enums.ConversionTypes.None(), testLogger)
+	require.NoError(t, err)
+	valuer := values.NewObjectValuer("", []values.Valuer{mapped, values.NewStaticValuer("name", "doe")})
+	detail, err := anypb.New(&errdetails.ErrorInfo{Reason: "USER_DELETED"})
+	require.NoError(t, err)
+
+	server, err := grpcmock.NewServer([]grpcmock.Service{{
+		Descriptor: service,
+		Files:      files,
+		Handlers: []grpcmock.MethodHandler{
+			grpcmock.NewStaticHandler(methods.ByName("GetUser"), static, testLogger),
+			grpcmock.NewDynamicHandler(methods.ByName("FindUser"), valuer, testLogger),
+			grpcmock.NewStatusHandler(methods.ByName("DeleteUser"), &spb.Status{
+				Code:    int32(codes.NotFound),
+				Message: "user not found",
+				Details: []*anypb.Any{detail},
+			}, testLogger),
+		},
+	}}, testLogger)
+	require.NoError(t, err)
+	listener := bufconn.Listen(1024 * 1024)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	invoke := func(t *testing.T, method protoreflect.MethodDescriptor) (string, error) {
+		request := dynamicpb.NewMessage(method.Input())
+		require.NoError(t, protojson.Unmarshal([]byte(`{"id": "42"}`), request))
+		response := dynamicpb.NewMessage(method.Output())
+		err := conn.Invoke(context.Background(), "/user.v1.UserService/"+string(method.Name()), request, response)
+		if err != nil {
+			return "", err
+		}
+		content, err := protojson.Marshal(response)
+		require.NoError(t, err)
+		return string(content), nil
+	}
+
+	testCases := []struct {
+		name     string
+		method   string
+		expected string
+		asserts  func(*testing.T, error)
+	}{
+		{
+			name:     "static response",
+			method:   "GetUser",
+			expected: `{"id":"1","name":"john"}`,
+		},
+		{
+			name:     "dynamic response mapped from request",
+			method:   "FindUser",
+			expected: `{"id":"42","name":"doe"}`,
+		},
+		{
+			name:   "status with details",
+			method: "DeleteUser",
+			asserts: func(t *testing.T, err error) {
+				st := status.Convert(err)
+				require.Equal(t, codes.NotFound, st.Code())
+				require.Equal(t, "user not found", st.Message())
+				require.Len(t, st.Details(), 1)
+				require.Equal(t, "USER_DELETED", st.Details()[0].(*errdetails.ErrorInfo).GetReason())
+			},
+		},
+		{
+			name:   "not mocked method",
+			method: "UpdateUser",
+			asserts: func(t *testing.T, err error) {
+				require.Equal(t, codes.Unimplemented, status.Code(err))
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			response, err := invoke(t, methods.ByName(protoreflect.Name(tc.method)))
+			if tc.asserts != nil {
+				require.Error(t, err)
+				tc.asserts(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.JSONEq(t, tc.expected, response)
+		})
+	}
+
+	t.Run("reflection lists services", func(t *testing.T) {
+		t.Parallel()
+		stream, err := v1reflectiongrpc.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&v1reflectionpb.ServerReflectionRequest{
+			MessageRequest: &v1reflectionpb.ServerReflectionRequest_FileContainingSymbol{
+				FileContainingSymbol: "user.v1.UserService",
+			},
+		}))
+		response, err := stream.Recv()
+		require.NoError(t, err)
+		require.NotEmpty(t, response.GetFileDescriptorResponse().GetFileDescriptorProto())
+		require.NoError(t, stream.CloseSend())
+	})
+}
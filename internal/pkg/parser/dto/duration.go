@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidDuration = errors.New("invalid duration")
+
+// Duration is read from strings like "1.5s" or "300ms", numbers are milliseconds
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch val := value.(type) {
+	case float64:
+		*d = Duration(time.Duration(val * float64(time.Millisecond)))
+	case string:
+		parsed, err := time.ParseDuration(val)
+		if err != nil {
+			return errors.Wrapf(ErrInvalidDuration, "%s", val)
+		}
+		*d = Duration(parsed)
+	default:
+		return errors.Wrapf(ErrInvalidDuration, "%s", data)
+	}
+	if *d < 0 {
+		return errors.Wrapf(ErrInvalidDuration, "%s is negative", data)
+	}
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
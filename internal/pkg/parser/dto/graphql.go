@@ -0,0 +1,20 @@
+package dto
+
+// GraphQL answers the queries with the values generated for the types of the schema
+// the endpoint method has to be GET or POST
+type GraphQL struct {
+	// Schema is the path to the SDL file relative to the config
+	Schema string       `json:"schema" validate:"required"`
+	List   *GraphQLList `json:"list"   validate:"omitempty"`
+	// Types override the values generated for the type, e.g. DateTime or User
+	Types map[string]Params `json:"types"`
+	// Fields override the values of the fields keyed by type and field name, e.g. Query.user
+	// the field arguments and query variables are mapped from the body as $.args and $.variables
+	Fields map[string]Params `json:"fields"`
+}
+
+// GraphQLList sets the length of the generated lists
+type GraphQLList struct {
+	Min int `json:"min" validate:"min=0"`
+	Max int `json:"max" validate:"gtefield=Min"`
+}
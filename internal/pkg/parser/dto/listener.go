@@ -0,0 +1,23 @@
+package dto
+
+// Listener serves the endpoints assigned to it on its own port
+// the name default is reserved for the listener started with the command flags
+type Listener struct {
+	Name string       `json:"name" validate:"required,ne=default"`
+	Port int          `json:"port" validate:"required,min=1,max=65535"`
+	TLS  *ListenerTLS `json:"tls"  validate:"omitempty"`
+	// H2C serves cleartext HTTP/2 next to HTTP/1.1, HTTP/2 over tls is always enabled
+	H2C bool `json:"h2c"`
+}
+
+// ListenerTLS serves https with the certificate files relative to the config
+// or the certificates generated at startup to the dir
+type ListenerTLS struct {
+	Cert       string   `json:"cert"        validate:"required_without=SelfSigned,required_with=Key"`
+	Key        string   `json:"key"         validate:"required_with=Cert"`
+	SelfSigned bool     `json:"self_signed" validate:"excluded_with=Cert"`
+	Dir        string   `json:"dir"`
+	Hosts      []string `json:"hosts"`
+	ClientCA   string   `json:"client_ca"`
+	ClientAuth string   `json:"client_auth" validate:"omitempty,oneof=none request require"`
+}
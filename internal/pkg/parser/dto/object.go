@@ -2,40 +2,283 @@ package dto
 
 import (
 	"github.com/vimek-go/server-faker/internal/pkg/enums"
+
+	"google.golang.org/grpc/codes"
 )
 
 //nolint:lll // This is a DTO
 type Response struct {
 	Status  int                `json:"status"                 validate:"required_unless=Type custom"`
-	Type    enums.ResponseType `json:"type"                   validate:"required,oneof=static dynamic custom"`
+	Type    enums.ResponseType `json:"type"                   validate:"required,oneof=static dynamic custom sse"`
 	Headers map[string]string  `json:"headers"`
 	File    string             `json:"file"`
 	// reserved for static object
-	// has priority over file, considered only if Type is static and Format is json
+	// has priority over file, considered only if Type is static and Format is not bytes
+	// the object is encoded to the Format
 	Static      interface{}          `json:"static"`
 	Object      Params               `json:"object"`
-	Format      enums.ResponseFormat `json:"format"                 validate:"required_unless=Type custom,omitempty,oneof=json xml bytes"`
+	Format      enums.ResponseFormat `json:"format"                 validate:"required_unless=Type custom,omitempty,oneof=json xml bytes yaml csv ndjson form msgpack protobuf"`
 	ContentType string               `json:"content_type,omitempty" validate:"required_if=Format bytes"`
+	XML         *XML                 `json:"xml,omitempty"`
+	CSV         *CSV                 `json:"csv,omitempty"`
+	Protobuf    *Protobuf            `json:"protobuf,omitempty"  validate:"required_if=Format protobuf,omitempty"`
+	// alternative formats of dynamic responses chosen with the Accept header
+	// Format is used when the header is missing or nothing matches, unless StrictAccept is set
+	Formats      []enums.ResponseFormat `json:"formats,omitempty"       validate:"omitempty,dive,oneof=json xml yaml csv ndjson form msgpack protobuf"`
+	StrictAccept bool                   `json:"strict_accept,omitempty"`
+	// events streamed by the sse responses
+	SSE *SSE `json:"sse,omitempty" validate:"required_if=Type sse,omitempty"`
+	// Stream slows down the static and dynamic responses
+	Stream *Stream `json:"stream,omitempty" validate:"omitempty"`
+}
+
+// Stream writes the body in chunks with delays between them
+type Stream struct {
+	ChunkSize int      `json:"chunk_size"       validate:"min=0"`
+	Delay     Duration `json:"delay"`
+	// Chunked uses the chunked transfer encoding instead of the content length
+	Chunked        bool `json:"chunked"`
+	BytesPerSecond int  `json:"bytes_per_second" validate:"min=0"`
+}
+
+// XML configures the documents generated for dynamic responses
+type XML struct {
+	Root       string            `json:"root"`
+	Item       string            `json:"item"`
+	Items      map[string]string `json:"items"`
+	Attributes []string          `json:"attributes"`
+}
+
+// CSV configures the documents generated from arrays of objects
+type CSV struct {
+	Columns    []string `json:"columns"`
+	Separator  string   `json:"separator"`
+	SkipHeader bool     `json:"skip_header"`
+}
+
+// Protobuf points to the message encoded in the responses
+// File is a .proto file or a binary descriptor set (protoc --descriptor_set_out)
+type Protobuf struct {
+	File        string   `json:"file"         validate:"required"`
+	ImportPaths []string `json:"import_paths"`
+	Message     string   `json:"message"      validate:"required"`
 }
 
 type Proxy struct {
-	URL         string             `json:"url"          validate:"required"`
-	Method      string             `json:"method"       validate:"required"`
-	Type        enums.ResponseType `json:"type"         validate:"required,oneof=static dynamic"`
+	// URL is the url of the upstream or the list of the upstreams the requests are balanced between
+	URL         Upstreams          `json:"url"          validate:"required,min=1,dive"`
+	Method      string             `json:"method"       validate:"required_unless=Type grpc"`
+	Type        enums.ResponseType `json:"type"         validate:"required,oneof=static dynamic grpc"`
 	Query       Params             `json:"query_params"`
 	URLParams   Params             `json:"url_params"`
 	ContentType string             `json:"content_type"`
 	Headers     map[string]string  `json:"headers"`
 	Object      Params             `json:"object"`
+	// MergeBody adds the generated body to the incoming one instead of replacing it
+	MergeBody bool `json:"merge_body"`
+	// HeaderParams generate the headers sent upstream, e.g. mapped from the incoming headers
+	HeaderParams Params `json:"header_params"`
+	// ForwardHeaders limit the incoming headers sent upstream, all of them are sent when empty
+	ForwardHeaders []string `json:"forward_headers"`
+	// RenameHeaders send the incoming headers under the new names
+	RenameHeaders map[string]string `json:"rename_headers"`
+	RemoveHeaders []string          `json:"remove_headers"`
+	// Balance selects the upstream, round_robin by default
+	Balance enums.BalanceType `json:"balance" validate:"omitempty,oneof=round_robin random weighted"`
+	Health  *ProxyHealth      `json:"health,omitempty" validate:"omitempty"`
+	Mirror  *ProxyMirror      `json:"mirror,omitempty" validate:"omitempty"`
+	// Response rewrites the upstream response, it is copied untouched when empty
+	Response *ProxyResponse `json:"response,omitempty" validate:"omitempty"`
+	// Fallback is served when the upstream is unreachable or responds with 5xx
+	Fallback *Response `json:"fallback,omitempty" validate:"omitempty"`
+	// ConnectTimeout limits connecting to the upstream, ResponseTimeout waiting for its response headers
+	ConnectTimeout  Duration    `json:"connect_timeout"`
+	ResponseTimeout Duration    `json:"response_timeout"`
+	Retry           *ProxyRetry `json:"retry,omitempty" validate:"omitempty"`
+	TLS             *ProxyTLS   `json:"tls,omitempty"   validate:"omitempty"`
+	// HTTPProxy is the proxy the requests are sent through, the HTTP_PROXY variables are used when empty
+	HTTPProxy string `json:"http_proxy" validate:"omitempty,url"`
+	// GRPC is the method of the upstream the requests of the grpc proxy are converted to
+	GRPC *ProxyGRPC `json:"grpc,omitempty" validate:"required_if=Type grpc,omitempty"`
+}
+
+// ProxyGRPC is the unary method of the service declared in a .proto file or a descriptor set
+type ProxyGRPC struct {
+	File        string   `json:"file"         validate:"required"`
+	ImportPaths []string `json:"import_paths"`
+	Service     string   `json:"service"      validate:"required"`
+	Method      string   `json:"method"       validate:"required"`
+}
+
+// ProxyHealth ejects the upstream failing max fails times in a row for the cooldown
+type ProxyHealth struct {
+	MaxFails int      `json:"max_fails" validate:"min=0"`
+	Cooldown Duration `json:"cooldown"`
+}
+
+// ProxyMirror receives the copies of the proxied requests, its responses are compared with the upstream ones
+type ProxyMirror struct {
+	URL     string            `json:"url"     validate:"required,url"`
+	Headers map[string]string `json:"headers"`
+	// CompareLimit is the number of the bytes of the bodies compared, 1 MiB when not set
+	CompareLimit int `json:"compare_limit" validate:"min=0"`
+}
+
+// ProxyRetry repeats the requests of the idempotent methods failing to connect or responding with the statuses
+type ProxyRetry struct {
+	// Attempts is the number of the requests sent, including the first one
+	Attempts int      `json:"attempts" validate:"required,min=1"`
+	Backoff  Duration `json:"backoff"`
+	// Statuses are retried, 502, 503 and 504 when empty
+	Statuses []int `json:"statuses" validate:"dive,min=100,max=599"`
+}
+
+// ProxyTLS verifies the upstream with the CA and sends the client certificate,
+// the files are relative to the config
+type ProxyTLS struct {
+	CA                 string `json:"ca"`
+	Cert               string `json:"cert"                 validate:"required_with=Key"`
+	Key                string `json:"key"                  validate:"required_with=Cert"`
+	ServerName         string `json:"server_name"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+}
+
+// ProxyResponse maps the upstream status codes, sets and removes the headers
+// and rewrites the json body, the fields are deleted before the override is merged
+type ProxyResponse struct {
+	// Status maps the upstream status code to the returned one
+	Status        map[int]int       `json:"status"`
+	Headers       map[string]string `json:"headers"`
+	RemoveHeaders []string          `json:"remove_headers"`
+	// Delete are the paths of the removed fields, like $.user.password or $.items[*].id
+	Delete []string `json:"delete"`
+	// Override is merged into the body, mappings from upstream read the original upstream body
+	Override Params `json:"override"`
+}
+
+// CatchAll forwards the requests keeping their method, path and query
+type CatchAll struct {
+	URL     string            `json:"url"     validate:"required,url"`
+	Headers map[string]string `json:"headers"`
+	// CompareLimit is the number of the bytes of the bodies compared, 1 MiB when not set
+	CompareLimit int `json:"compare_limit" validate:"min=0"`
+}
+
+// RequestMatch selects the requests served by the response of the endpoint,
+// all the set conditions have to match, the other requests are proxied or not found
+type RequestMatch struct {
+	Query   map[string]string `json:"query"`
+	Headers map[string]string `json:"headers"`
+	// Path is the json path of the body compared with the Value
+	Path  string      `json:"path"  validate:"required_with=Value"`
+	Value interface{} `json:"value"`
+}
+
+// GRPCService mocks the methods of the service declared in a .proto file or a descriptor set
+type GRPCService struct {
+	File        string       `json:"file"         validate:"required"`
+	ImportPaths []string     `json:"import_paths"`
+	Service     string       `json:"service"      validate:"required"`
+	Methods     []GRPCMethod `json:"methods"      validate:"required,dive"`
+}
+
+// GRPCMethod responds with the static message, the generated object or the error status
+// the request message is mapped from the body in its json form
+type GRPCMethod struct {
+	Name   string             `json:"name"   validate:"required"`
+	Type   enums.ResponseType `json:"type"   validate:"required_without=Status,omitempty,oneof=static dynamic"`
+	Static interface{}        `json:"static"`
+	Object Params             `json:"object"`
+	Status *GRPCStatus        `json:"status" validate:"omitempty"`
+}
+
+// GRPCStatus is returned instead of the response message
+// code accepts the names, like NOT_FOUND, or the numbers of the codes
+type GRPCStatus struct {
+	Code    codes.Code   `json:"code"    validate:"required"`
+	Message string       `json:"message"`
+	Details []GRPCDetail `json:"details" validate:"dive"`
+}
+
+// GRPCDetail is the json form of the message, like google.rpc.ErrorInfo, attached to the status
+type GRPCDetail struct {
+	Type  string      `json:"type"  validate:"required"`
+	Value interface{} `json:"value"`
+}
+
+// Environment declares the values for ${VAR} placeholders in the config
+// the selected profile overlays the variables
+type Environment struct {
+	Variables map[string]string            `json:"variables,omitempty"`
+	Profiles  map[string]map[string]string `json:"profiles,omitempty"`
 }
 
 type Endpoints struct {
-	Endpoints []Endpoint `json:"endpoints"`
+	Environment
+	// paths or glob patterns of other config files, relative to this file
+	Include []string `json:"include,omitempty"`
+	// named params reusable in any endpoint through the ref param
+	Definitions map[string]Params `json:"definitions,omitempty"`
+	Endpoints   []Endpoint        `json:"endpoints"`
+	// services served by the grpc server
+	GRPC []GRPCService `json:"grpc,omitempty"`
+	// additional ports the endpoints can be served on
+	Listeners []Listener `json:"listeners,omitempty"`
+	// CatchAll proxies the requests to the routes which are not mocked
+	CatchAll *CatchAll `json:"catch_all,omitempty" validate:"omitempty"`
 }
 
+//nolint:lll // This is a DTO
 type Endpoint struct {
-	URL      string    `json:"url"      validate:"required,startswith=/"`
-	Method   string    `json:"method"   validate:"required"`
-	Response *Response `json:"response" validate:"required_without=Proxy,omitempty"`
-	Proxy    *Proxy    `json:"proxy"    validate:"required_without=Response,omitempty"`
+	URL       string     `json:"url"       validate:"required,startswith=/"`
+	Method    string     `json:"method"    validate:"required"`
+	Response  *Response  `json:"response"  validate:"required_without_all=Proxy WebSocket GraphQL Sequence,excluded_with=WebSocket GraphQL,omitempty"`
+	Proxy     *Proxy     `json:"proxy"     validate:"required_without_all=Response WebSocket GraphQL Sequence,excluded_with=WebSocket GraphQL,omitempty"`
+	WebSocket *WebSocket `json:"websocket,omitempty" validate:"required_without_all=Response Proxy GraphQL Sequence,excluded_with=Response Proxy GraphQL,omitempty"`
+	GraphQL   *GraphQL   `json:"graphql,omitempty"   validate:"required_without_all=Response Proxy WebSocket Sequence,excluded_with=Response Proxy WebSocket,omitempty"`
+	// Sequence returns its responses in order on the successive calls
+	Sequence *Sequence `json:"sequence,omitempty" validate:"excluded_with=Response Proxy WebSocket GraphQL,omitempty"`
+	// Match is required when the endpoint has both the response and the proxy
+	Match *RequestMatch `json:"match,omitempty" validate:"required_with_all=Response Proxy,excluded_without=Response,omitempty"`
+	// Listeners are the names of the listeners serving the endpoint, the default listener when empty
+	Listeners []string `json:"listeners,omitempty"`
+	// Hosts limit the endpoint to the requests with the Host header, any host when empty
+	Hosts []string `json:"hosts,omitempty" validate:"dive,hostname_rfc1123"`
+	// Scenario makes the endpoint respond in the state of the scenario,
+	// many endpoints of the same route can respond in the different states
+	Scenario *Scenario `json:"scenario,omitempty" validate:"omitempty"`
+	// Store saves the values generated from the request and the response once the endpoint responded
+	Store []StoreEntry `json:"store,omitempty" validate:"dive"`
+}
+
+// StoreEntry saves the value in the named store, the stored param of the other endpoints reads it
+type StoreEntry struct {
+	Name string `json:"name" validate:"required"`
+	// Key and Value are checked while building the valuers like the other params, the empty key when not set
+	Key   *Param `json:"key,omitempty" validate:"-"`
+	Value Param  `json:"value"         validate:"-"`
+	// TTL is the time the value is kept for, it does not expire when not set
+	TTL Duration `json:"ttl"`
+}
+
+// Sequence is the ordered list of the responses returned on the successive calls of the endpoint
+type Sequence struct {
+	Responses []Response `json:"responses" validate:"required,min=1,dive"`
+	// After is returned once all the responses were returned, repeat_last by default
+	After enums.SequencePolicy `json:"after" validate:"omitempty,oneof=repeat_last cycle error"`
+	// Key counts the calls per the request value, e.g. mapped from the user id header,
+	// it is checked while building the valuer like the other params
+	Key *Param `json:"key,omitempty" validate:"-"`
+}
+
+// Scenario is the state the named scenario has to be in and the state it moves to after the response
+type Scenario struct {
+	Name string `json:"name" validate:"required"`
+	// State is required for the endpoint to respond, any state when empty
+	State string `json:"state"`
+	// Next is the state after the response, the state does not change when empty
+	Next string `json:"next"`
+	// Key keeps the states per the request value, e.g. mapped from the user id header,
+	// it is checked while building the valuer like the other params
+	Key *Param `json:"key,omitempty" validate:"-"`
 }
@@ -13,9 +13,9 @@ var ErrParamNotValid = errors.New("param is not valid")
 type Params []Param
 
 type Array struct {
-	Min     int     `json:"min"     validate:"required"`
-	Max     int     `json:"max"     validate:"required"`
-	Element []Param `json:"element" validate:"required"`
+	Min     int     `json:"min"     validate:"min=0"`
+	Max     int     `json:"max"     validate:"gtefield=Min"`
+	Element []Param `json:"element" validate:"required,dive"`
 }
 
 type Static struct {
@@ -28,12 +28,28 @@ type Random struct {
 	Max  int    `json:"max"`
 }
 
+//nolint:lll // This is a DTO
 type Mapped struct {
-	From  enums.RequestLocation `json:"from"  validate:"required, oneof=body url query"`
-	Param string                `json:"param" validate:"required_unless=Form body,omitempty"`
-	Index *int                  `json:"index" validate:"omitempty"`
-	Path  string                `json:"path"  validate:"required_if=From body,omitempty"`
-	As    string                `json:"as"    validate:"oneof=number string,omitempty"`
+	From  enums.RequestLocation `json:"from"  validate:"required,oneof=body url query certificate upstream header response"`
+	Param string                `json:"param" validate:"required_if=From url,required_if=From query,required_if=From header,required_if=From certificate"`
+	Index *int                  `json:"index" validate:"omitempty,min=0"`
+	Path  string                `json:"path"  validate:"required_if=From body,required_if=From upstream,required_if=From response"`
+	As    string                `json:"as"    validate:"omitempty,oneof=number text none"`
+}
+
+// Ref points to a named entry of the top level definitions section.
+// Depth allows the definition to reference itself that many times,
+// which is required for recursive trees like categories or comments.
+type Ref struct {
+	Name  string `json:"name"  validate:"required"`
+	Depth int    `json:"depth" validate:"omitempty,min=0"`
+}
+
+// Stored reads the value saved by the store of another endpoint under the key generated from the request
+type Stored struct {
+	Name string `json:"name" validate:"required"`
+	// Key is checked while building the valuer like the other params, the empty key when not set
+	Key *Param `json:"key,omitempty" validate:"-"`
 }
 
 type Param struct {
@@ -42,7 +58,9 @@ type Param struct {
 	Static *Static `json:"static,omitempty" validate:"omitempty"`
 	Array  *Array  `json:"array,omitempty"  validate:"omitempty"`
 	Mapped *Mapped `json:"mapped,omitempty" validate:"omitempty"`
-	Object Params  `json:"object,omitempty" validate:"omitempty"`
+	Object Params  `json:"object,omitempty" validate:"omitempty,dive"`
+	Ref    *Ref    `json:"ref,omitempty"    validate:"omitempty"`
+	Stored *Stored `json:"stored,omitempty" validate:"omitempty"`
 }
 
 func (p *Param) ValueType() (enums.ValueType, error) {
@@ -61,6 +79,12 @@ func (p *Param) ValueType() (enums.ValueType, error) {
 	if len(p.Object) > 0 {
 		return enums.ValueTypes.Object(), nil
 	}
+	if p.Ref != nil {
+		return enums.ValueTypes.Ref(), nil
+	}
+	if p.Stored != nil {
+		return enums.ValueTypes.Stored(), nil
+	}
 	return "", errors.Wrapf(ErrParamNotValid, "param with key %s is not valid", p.Key)
 }
 
@@ -87,5 +111,11 @@ func (p *Param) Details() string {
 			p.Mapped.As,
 		)
 	}
+	if p.Ref != nil {
+		return fmt.Sprintf("ref to %s with depth %d", p.Ref.Name, p.Ref.Depth)
+	}
+	if p.Stored != nil {
+		return fmt.Sprintf("stored in %s", p.Stored.Name)
+	}
 	return "unknown"
 }
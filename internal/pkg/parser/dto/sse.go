@@ -0,0 +1,27 @@
+package dto
+
+// SSE streams the listed events or the events generated from the response object
+// the data of the events is encoded with the response format, strings are sent as they are
+type SSE struct {
+	Events []SSEEvent `json:"events"   validate:"dive"`
+	// Interval is the delay between the events
+	Interval Duration `json:"interval"`
+	// Event is the name of the generated events
+	Event string `json:"event"`
+	// IDs numbers the generated events starting from 1
+	IDs bool `json:"ids"`
+	// Retry hints the client the reconnection time
+	Retry Duration `json:"retry"`
+	// Count ends the stream after the number of generated events, the listed events are sent once
+	Count int `json:"count"    validate:"min=0"`
+	// Duration ends the stream after the time passes
+	Duration Duration `json:"duration"`
+	// Done is sent as the last event data, like [DONE] of the LLM streams
+	Done interface{} `json:"done"`
+}
+
+type SSEEvent struct {
+	Event string      `json:"event"`
+	ID    string      `json:"id"`
+	Data  interface{} `json:"data"`
+}
@@ -0,0 +1,49 @@
+package dto
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidUpstreams = errors.New("invalid upstreams")
+
+// Upstream is the target of the proxy, the weight is used by the weighted balance
+type Upstream struct {
+	URL    string `json:"url"    validate:"required"`
+	Weight int    `json:"weight" validate:"min=0"`
+}
+
+// Upstreams are read from the single url, the list of the urls or the list of the upstream objects
+type Upstreams []Upstream
+
+func (u *Upstreams) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*u = Upstreams{{URL: single}}
+		return nil
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(data, &list); err != nil {
+		return errors.Wrapf(ErrInvalidUpstreams, "%s has to be the url or the list of the upstreams", data)
+	}
+	upstreams := make(Upstreams, len(list))
+	for i := range list {
+		if err := json.Unmarshal(list[i], &upstreams[i].URL); err == nil {
+			continue
+		}
+		if err := json.Unmarshal(list[i], &upstreams[i]); err != nil {
+			return errors.Wrapf(ErrInvalidUpstreams, "%s: %v", list[i], err)
+		}
+	}
+	*u = upstreams
+	return nil
+}
+
+// MarshalJSON writes the single upstream without the weight as the url
+func (u Upstreams) MarshalJSON() ([]byte, error) {
+	if len(u) == 1 && u[0].Weight == 0 {
+		return json.Marshal(u[0].URL)
+	}
+	return json.Marshal([]Upstream(u))
+}
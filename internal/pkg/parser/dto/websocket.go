@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+)
+
+// WebSocket describes the messages exchanged with the clients connected to the endpoint
+// the endpoint method has to be GET
+type WebSocket struct {
+	OnConnect []WebSocketMessage `json:"on_connect" validate:"dive"`
+	Replies   []WebSocketReply   `json:"replies"    validate:"dive"`
+	Pushes    []WebSocketPush    `json:"pushes"     validate:"dive"`
+	Close     *WebSocketClose    `json:"close"      validate:"omitempty"`
+}
+
+// WebSocketMessage is sent as text, static strings are sent as they are, other values as json
+// dynamic replies map the body from the incoming message
+type WebSocketMessage struct {
+	Type   enums.ResponseType `json:"type"   validate:"required,oneof=static dynamic"`
+	Static interface{}        `json:"static"`
+	Object Params             `json:"object"`
+}
+
+// WebSocketMatch selects the incoming messages the reply is sent to
+// all the set conditions have to match, empty match accepts any message
+type WebSocketMatch struct {
+	Text  string      `json:"text"`
+	Regex string      `json:"regex"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+type WebSocketReply struct {
+	Match    WebSocketMatch     `json:"match"`
+	Messages []WebSocketMessage `json:"messages" validate:"dive"`
+	Close    *WebSocketClose    `json:"close"    validate:"omitempty"`
+}
+
+type WebSocketPush struct {
+	Interval Duration         `json:"interval" validate:"required"`
+	Count    int              `json:"count"    validate:"min=0"`
+	Message  WebSocketMessage `json:"message"`
+}
+
+type WebSocketClose struct {
+	After         Duration `json:"after"`
+	AfterMessages int      `json:"after_messages" validate:"min=0"`
+	Code          int      `json:"code"           validate:"omitempty,min=1000,max=4999"`
+	Reason        string   `json:"reason"         validate:"max=123"`
+}
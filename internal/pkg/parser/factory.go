@@ -1,22 +1,29 @@
 package parser
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"encoding/xml"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 
 	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/certs"
+	"github.com/vimek-go/server-faker/internal/pkg/encoders"
 	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/grpcmock"
 	"github.com/vimek-go/server-faker/internal/pkg/logger"
 	"github.com/vimek-go/server-faker/internal/pkg/parser/dto"
 	"github.com/vimek-go/server-faker/internal/pkg/plugins"
+	"github.com/vimek-go/server-faker/internal/pkg/protos"
 	"github.com/vimek-go/server-faker/internal/pkg/tools"
 	"github.com/vimek-go/server-faker/internal/pkg/values"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -24,17 +31,43 @@ var (
 	ErrEmptyKey         = errors.New("empty param key")
 	ErrDuplicatedKey    = errors.New("dupliacted param key")
 	ErrNotHandledValuer = errors.New("valuer type is not handled")
+	ErrUnknownRef       = errors.New("unknown definition reference")
+	ErrCyclicRef        = errors.New("cyclic definition reference")
+	ErrRefTooDeep       = errors.New("definition references nested too deep")
 )
 
+// maxRefDepth limits the nesting of references regardless of the depth
+// requested in the config, so misconfigured trees do not explode.
+const maxRefDepth = 32
+
 type factory struct {
-	loader plugins.Loader
-	logger logger.Logger
+	loader      plugins.Loader
+	protos      protos.Loader
+	logger      logger.Logger
+	definitions map[string]dto.Params
+	// resolving counts the references currently being built
+	// it is used to detect cycles while building the valuers
+	resolving map[string]int
+	refDepth  int
+	// scenarios keep the states of the scenarios of all the endpoints
+	scenarios api.Scenarios
+	// sequences restart the sequences of all the endpoints
+	sequences api.Sequences
+	// store keeps the values saved by the endpoints for the stored params
+	store values.Store
 }
 
 type Factory interface {
+	SetDefinitions(definitions map[string]dto.Params)
 	CreateEndpoint(endpoint dto.Endpoint, baseDir string) (api.Handler, error)
 	CreateResponseEndpoint(endpoint dto.Endpoint, baseDir string) (api.ResponseHandler, error)
-	CreateProxyEndpoint(endpoint dto.Endpoint) (api.Handler, error)
+	CreateProxyEndpoint(endpoint dto.Endpoint, baseDir string) (api.Handler, error)
+	CreateCatchAll(catchAll dto.CatchAll) (api.Handler, error)
+	CreateWebSocketEndpoint(endpoint dto.Endpoint) (api.Handler, error)
+	CreateGraphQLEndpoint(endpoint dto.Endpoint, baseDir string) (api.Handler, error)
+	CreateGRPCService(service dto.GRPCService, baseDir string) (grpcmock.Service, error)
+	Scenarios() api.Scenarios
+	Sequences() api.Sequences
 }
 
 type pluginLoader interface {
@@ -42,13 +75,57 @@ type pluginLoader interface {
 }
 
 func NewFactory(loader pluginLoader, logger logger.Logger) Factory {
-	return &factory{loader: loader, logger: logger}
+	return &factory{
+		loader:    loader,
+		protos:    protos.NewLoader(logger),
+		logger:    logger,
+		resolving: make(map[string]int),
+		scenarios: api.NewScenarios(),
+		sequences: api.NewSequences(),
+		store:     values.NewStore(),
+	}
+}
+
+func (f *factory) SetDefinitions(definitions map[string]dto.Params) {
+	f.definitions = definitions
+}
+
+func (f *factory) Scenarios() api.Scenarios {
+	return f.scenarios
+}
+
+func (f *factory) Sequences() api.Sequences {
+	return f.sequences
 }
 
 func (f *factory) CreateEndpoint(endpoint dto.Endpoint, baseDir string) (api.Handler, error) {
+	if endpoint.Scenario != nil {
+		f.logger.Info("Attempting creation of scenario endpoint")
+		return f.createScenarioEndpoint(endpoint, baseDir)
+	}
+	if len(endpoint.Store) > 0 {
+		f.logger.Info("Attempting creation of storing endpoint")
+		return f.createStoreEndpoint(endpoint, baseDir)
+	}
+	if endpoint.Sequence != nil {
+		f.logger.Info("Attempting creation of sequence endpoint")
+		return f.createSequenceEndpoint(endpoint, baseDir)
+	}
+	if endpoint.Match != nil {
+		f.logger.Info("Attempting creation of matched endpoint")
+		return f.createMatchedEndpoint(endpoint, baseDir)
+	}
 	if endpoint.Proxy != nil {
 		f.logger.Info("Attempting creation of proxy endpoint")
-		return f.CreateProxyEndpoint(endpoint)
+		return f.CreateProxyEndpoint(endpoint, baseDir)
+	}
+	if endpoint.WebSocket != nil {
+		f.logger.Info("Attempting creation of websocket endpoint")
+		return f.CreateWebSocketEndpoint(endpoint)
+	}
+	if endpoint.GraphQL != nil {
+		f.logger.Info("Attempting creation of graphql endpoint")
+		return f.CreateGraphQLEndpoint(endpoint, baseDir)
 	}
 	if endpoint.Response != nil {
 		f.logger.Info("Attempting creation of response endpoint")
@@ -57,19 +134,156 @@ func (f *factory) CreateEndpoint(endpoint dto.Endpoint, baseDir string) (api.Han
 	return nil, errors.Wrapf(ErrNotHandled, "creation requested for endpoint %+v", endpoint)
 }
 
+// createScenarioEndpoint responds with the endpoint in the state of its scenario
+func (f *factory) createScenarioEndpoint(endpoint dto.Endpoint, baseDir string) (api.ScenarioHandler, error) {
+	scenario := endpoint.Scenario
+	endpoint.Scenario = nil
+	handler, err := f.CreateEndpoint(endpoint, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	key, err := f.prepareKey(scenario.Key, endpoint.URL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "scenario %s", scenario.Name)
+	}
+	step := api.ScenarioStep{Name: scenario.Name, State: scenario.State, Next: scenario.Next, Key: key}
+	return api.NewScenarioHandler(handler, f.scenarios, step, f.logger), nil
+}
+
+// createStoreEndpoint saves the values of the store entries once the endpoint responded
+func (f *factory) createStoreEndpoint(endpoint dto.Endpoint, baseDir string) (api.Handler, error) {
+	store := endpoint.Store
+	endpoint.Store = nil
+	handler, err := f.CreateEndpoint(endpoint, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]api.StoreEntry, len(store))
+	for i, entry := range store {
+		key, err := f.prepareKey(entry.Key, endpoint.URL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "store %s", entry.Name)
+		}
+		value, err := f.buildValuer(entry.Value, endpoint.URL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "value of store %s", entry.Name)
+		}
+		entries[i] = api.StoreEntry{Name: entry.Name, Key: key, Value: value, TTL: entry.TTL.Duration()}
+	}
+	return api.NewStoreHandler(handler, f.store, entries, f.logger), nil
+}
+
+// createSequenceEndpoint returns the responses of the sequence in order
+func (f *factory) createSequenceEndpoint(endpoint dto.Endpoint, baseDir string) (api.Handler, error) {
+	sequence := endpoint.Sequence
+	endpoint.Sequence = nil
+	responses := make([]api.Handler, len(sequence.Responses))
+	for i := range sequence.Responses {
+		endpoint.Response = &sequence.Responses[i]
+		handler, err := f.CreateResponseEndpoint(endpoint, baseDir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "response %d of sequence", i)
+		}
+		responses[i] = handler
+	}
+	key, err := f.prepareKey(sequence.Key, endpoint.URL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "sequence of endpoint %s %s", endpoint.Method, endpoint.URL)
+	}
+	return api.NewSequenceHandler(
+		endpoint.Method,
+		endpoint.URL,
+		responses,
+		enums.NewSequencePolicy(sequence.After.String()),
+		key,
+		f.sequences,
+		f.logger,
+	), nil
+}
+
+// prepareKey builds the valuer of the request value the state is kept per, nil when the key is not set
+func (f *factory) prepareKey(key *dto.Param, url string) (values.Valuer, error) {
+	if key == nil {
+		return nil, nil
+	}
+	valueType, err := key.ValueType()
+	if err != nil {
+		return nil, err
+	}
+	if valueType == enums.ValueTypes.Array() || valueType == enums.ValueTypes.Object() {
+		return nil, errors.Wrapf(ErrNotHandled, "%s key", valueType)
+	}
+	// set param key to be empty to generate only value
+	p := *key
+	p.Key = ""
+	return f.buildValuer(p, url)
+}
+
+// createMatchedEndpoint serves the matching requests with the response, the others are proxied when the proxy is set
+func (f *factory) createMatchedEndpoint(endpoint dto.Endpoint, baseDir string) (api.Handler, error) {
+	handler, err := f.CreateResponseEndpoint(endpoint, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	var fallback api.Handler
+	if endpoint.Proxy != nil {
+		if fallback, err = f.CreateProxyEndpoint(endpoint, baseDir); err != nil {
+			return nil, err
+		}
+	}
+	match := endpoint.Match
+	return api.NewMatchedHandler(handler, api.RequestMatch{
+		Query:   match.Query,
+		Headers: match.Headers,
+		Path:    match.Path,
+		Value:   match.Value,
+	}, fallback), nil
+}
+
 func (f *factory) CreateResponseEndpoint(
 	endpoint dto.Endpoint,
 	baseDir string,
+) (api.ResponseHandler, error) {
+	stream := endpoint.Response.Stream
+	if stream != nil && !tools.ArrayContains(
+		endpoint.Response.Type,
+		[]enums.ResponseType{enums.ResponseTypes.Static(), enums.ResponseTypes.Dynamic()},
+	) {
+		return nil, errors.Wrapf(
+			ErrNotHandled,
+			"endpoint %s %s, stream is supported only in static and dynamic responses",
+			endpoint.Method,
+			endpoint.URL,
+		)
+	}
+	handler, err := f.createResponseHandler(endpoint, baseDir)
+	if err != nil || stream == nil {
+		return handler, err
+	}
+	return api.NewStreamedHandler(handler, api.StreamOptions{
+		ChunkSize:      stream.ChunkSize,
+		Delay:          stream.Delay.Duration(),
+		Chunked:        stream.Chunked,
+		BytesPerSecond: stream.BytesPerSecond,
+	}), nil
+}
+
+func (f *factory) createResponseHandler(
+	endpoint dto.Endpoint,
+	baseDir string,
 ) (handler api.ResponseHandler, err error) {
 	f.logger.Infof("processing endpoint type: %s, url: %s", endpoint.Response.Type, endpoint.URL)
 	switch endpoint.Response.Type {
 	case enums.ResponseTypes.Static():
-		responseBytes, err := f.prepareStaticBytes(
-			baseDir,
-			endpoint.Response.File,
-			endpoint.Response.Static,
-			endpoint.Response.Format,
-		)
+		if len(endpoint.Response.Formats) > 0 {
+			return nil, errors.Wrapf(
+				api.ErrNotSupportedFormat,
+				"endpoint %s %s, formats are supported only in dynamic responses",
+				endpoint.Method,
+				endpoint.URL,
+			)
+		}
+		responseBytes, err := f.prepareStaticBytes(baseDir, endpoint.Response)
 		if err != nil {
 			return nil, errors.Wrapf(err, "ertor parsing endpoint %s %s to json", endpoint.Method, endpoint.URL)
 		}
@@ -87,15 +301,35 @@ func (f *factory) CreateResponseEndpoint(
 		if err != nil {
 			return nil, err
 		}
+		options, err := f.prepareEncoderOptions(endpoint.Response, baseDir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "endpoint %s %s", endpoint.Method, endpoint.URL)
+		}
+		if len(endpoint.Response.Formats) > 0 {
+			return api.NewNegotiatedHandler(
+				endpoint.Response.Format,
+				endpoint.Response.Formats,
+				endpoint.Response.StrictAccept,
+				endpoint.Method,
+				endpoint.URL,
+				endpoint.Response.Status,
+				valuer,
+				options,
+				f.logger,
+			)
+		}
 		return api.NewDynamicHandler(
 			endpoint.Response.Format,
 			endpoint.Method,
 			endpoint.URL,
 			endpoint.Response.Status,
 			valuer,
+			options,
 			f.logger,
 		)
 
+	case enums.ResponseTypes.SSE():
+		return f.createSSEEndpoint(endpoint, baseDir)
 	case enums.ResponseTypes.Custom():
 		responseFunction, err := f.loader.Load(filepath.Join(baseDir, endpoint.Response.File))
 		if err != nil {
@@ -108,16 +342,99 @@ func (f *factory) CreateResponseEndpoint(
 	}
 }
 
-func (f *factory) CreateProxyEndpoint(endpoint dto.Endpoint) (handler api.Handler, err error) {
+func (f *factory) prepareEncoderOptions(response *dto.Response, baseDir string) (encoders.Options, error) {
+	var options encoders.Options
+	if response.XML != nil {
+		options.XML = encoders.XMLOptions{
+			Root:       response.XML.Root,
+			Item:       response.XML.Item,
+			Items:      response.XML.Items,
+			Attributes: response.XML.Attributes,
+		}
+	}
+	if response.CSV != nil {
+		options.CSV = encoders.CSVOptions{
+			Columns:    response.CSV.Columns,
+			Separator:  response.CSV.Separator,
+			SkipHeader: response.CSV.SkipHeader,
+		}
+	}
+	if response.Protobuf != nil {
+		importPaths := make([]string, len(response.Protobuf.ImportPaths))
+		for i := range response.Protobuf.ImportPaths {
+			importPaths[i] = filepath.Join(baseDir, response.Protobuf.ImportPaths[i])
+		}
+		message, err := f.protos.FindMessage(
+			filepath.Join(baseDir, response.Protobuf.File),
+			importPaths,
+			response.Protobuf.Message,
+		)
+		if err != nil {
+			return options, err
+		}
+		options.Protobuf = encoders.ProtobufOptions{Message: message}
+	}
+	return options, nil
+}
+
+func (f *factory) CreateProxyEndpoint(endpoint dto.Endpoint, baseDir string) (handler api.Handler, err error) {
 	proxy := endpoint.Proxy
+	if proxy.Type == enums.ResponseTypes.GRPC() {
+		if err := checkGRPCProxy(endpoint); err != nil {
+			f.logger.Error(err)
+			return nil, err
+		}
+	}
+	rewriter, err := f.prepareResponseRewriter(proxy.Response, endpoint.URL)
+	if err != nil {
+		f.logger.Error(err)
+		return nil, err
+	}
+	var fallback api.Handler
+	if proxy.Fallback != nil {
+		fallbackEndpoint := dto.Endpoint{URL: endpoint.URL, Method: endpoint.Method, Response: proxy.Fallback}
+		if fallback, err = f.CreateResponseEndpoint(fallbackEndpoint, baseDir); err != nil {
+			f.logger.Error(err)
+			return nil, err
+		}
+	}
+	transport, err := f.prepareProxyTransport(proxy, baseDir)
+	if err != nil {
+		f.logger.Error(err)
+		return nil, err
+	}
+	upstreams, err := f.prepareUpstreams(proxy)
+	if err != nil {
+		f.logger.Error(err)
+		return nil, err
+	}
+	headers, err := f.prepareProxyHeaders(proxy, endpoint.URL)
+	if err != nil {
+		f.logger.Error(err)
+		return nil, err
+	}
+	var mirror api.Mirror
+	if proxy.Mirror != nil {
+		mirror, err = api.NewMirror(
+			proxy.Mirror.URL, proxy.Mirror.Headers, proxy.Mirror.CompareLimit, transport, f.logger,
+		)
+		if err != nil {
+			f.logger.Error(err)
+			return nil, err
+		}
+	}
 	switch proxy.Type {
 	case enums.ResponseTypes.Static():
 		handler = api.NewStaticProxy(
 			endpoint.Method,
 			endpoint.URL,
 			proxy.Method,
-			proxy.URL,
-			proxy.Headers,
+			upstreams,
+			headers,
+			transport,
+			rewriter,
+			fallback,
+			mirror,
 			f.logger,
 		)
 	case enums.ResponseTypes.Dynamic():
@@ -144,34 +461,155 @@ func (f *factory) CreateProxyEndpoint(endpoint dto.Endpoint) (handler api.Handle
 			f.logger.Error(err)
 			return nil, err
 		}
+		body, err := api.NewProxyBody(payloadValuer, proxy.ContentType, proxy.MergeBody)
+		if err != nil {
+			f.logger.Error(err)
+			return nil, err
+		}
 		handler = api.NewDynamicProxy(
 			endpoint.Method,
 			endpoint.URL,
 			proxy.Method,
-			proxy.URL,
+			upstreams,
 			urlValuers,
 			queryValuers,
-			payloadValuer,
-			proxy.Headers,
+			body,
+			headers,
+			transport,
+			rewriter,
+			fallback,
+			mirror,
 			f.logger,
 		)
+	case enums.ResponseTypes.GRPC():
+		return f.createGRPCProxy(endpoint, baseDir, upstreams, headers, fallback)
+	}
+	return handler, nil
+}
+
+// prepareProxyHeaders generates the header params from the request values, like the url params
+func (f *factory) prepareProxyHeaders(proxy *dto.Proxy, fakerURL string) (api.ProxyHeaders, error) {
+	params, err := f.prepareProxyValuersMap(
+		proxy.HeaderParams,
+		[]enums.ValueType{enums.ValueTypes.Array(), enums.ValueTypes.Object()},
+		fakerURL,
+	)
+	if err != nil {
+		return api.ProxyHeaders{}, errors.Wrapf(err, "header params of %s", fakerURL)
+	}
+	return api.ProxyHeaders{
+		Forward: proxy.ForwardHeaders,
+		Rename:  proxy.RenameHeaders,
+		Remove:  proxy.RemoveHeaders,
+		Set:     proxy.Headers,
+		Params:  params,
+	}, nil
+}
+
+// prepareUpstreams creates the pool balancing the requests between the upstreams of the proxy
+func (f *factory) prepareUpstreams(proxy *dto.Proxy) (api.UpstreamPool, error) {
+	upstreams := make([]api.Upstream, len(proxy.URL))
+	for i := range proxy.URL {
+		upstreams[i] = api.Upstream{URL: proxy.URL[i].URL, Weight: proxy.URL[i].Weight}
+	}
+	var health api.HealthCheck
+	if proxy.Health != nil {
+		health = api.HealthCheck{MaxFails: proxy.Health.MaxFails, Cooldown: proxy.Health.Cooldown.Duration()}
+	}
+	return api.NewUpstreamPool(upstreams, enums.NewBalanceType(proxy.Balance.String()), health, f.logger)
+}
+
+// prepareProxyTransport creates the transport of the endpoint, the tls files are relative to the baseDir
+func (f *factory) prepareProxyTransport(proxy *dto.Proxy, baseDir string) (http.RoundTripper, error) {
+	options := api.ProxyTransportOptions{
+		ConnectTimeout:  proxy.ConnectTimeout.Duration(),
+		ResponseTimeout: proxy.ResponseTimeout.Duration(),
+		HTTPProxy:       proxy.HTTPProxy,
+	}
+	if proxy.Retry != nil {
+		options.Retry = &api.RetryPolicy{
+			Attempts: proxy.Retry.Attempts,
+			Backoff:  proxy.Retry.Backoff.Duration(),
+			Statuses: proxy.Retry.Statuses,
+		}
 	}
-	return handler, err
+	config, err := f.prepareProxyTLS(proxy, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	options.TLS = config
+	return api.NewProxyTransport(options, f.logger)
 }
 
-func (f *factory) prepareStaticBytes(
-	baseDir, pathToFile string,
-	object any,
-	responseFormat enums.ResponseFormat,
-) ([]byte, error) {
-	if object != nil && responseFormat == enums.ResponseFormats.JSON() {
-		bytes, err := json.Marshal(object)
+// prepareProxyTLS returns nil when the system roots verify the upstream, the tls files are relative to the baseDir
+func (f *factory) prepareProxyTLS(proxy *dto.Proxy, baseDir string) (*tls.Config, error) {
+	if proxy.TLS == nil {
+		return nil, nil
+	}
+	relative := func(path string) string {
+		if len(path) == 0 || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(baseDir, path)
+	}
+	return certs.NewClientTLSConfig(certs.ClientOptions{
+		CAFile:             relative(proxy.TLS.CA),
+		CertFile:           relative(proxy.TLS.Cert),
+		KeyFile:            relative(proxy.TLS.Key),
+		ServerName:         proxy.TLS.ServerName,
+		InsecureSkipVerify: proxy.TLS.InsecureSkipVerify,
+	})
+}
+
+// CreateCatchAll proxies the requests to the routes which are not mocked
+func (f *factory) CreateCatchAll(catchAll dto.CatchAll) (api.Handler, error) {
+	return api.NewPassThroughProxy(catchAll.URL, catchAll.Headers, f.logger)
+}
+
+// prepareResponseRewriter returns nil when the upstream responses are not rewritten
+func (f *factory) prepareResponseRewriter(response *dto.ProxyResponse, url string) (api.ResponseRewriter, error) {
+	if response == nil {
+		return nil, nil
+	}
+	override, err := f.PrepareValuer(response.Override, url)
+	if err != nil {
+		return nil, err
+	}
+	return api.NewResponseRewriter(
+		response.Status,
+		response.Headers,
+		response.RemoveHeaders,
+		response.Delete,
+		override,
+		f.logger,
+	)
+}
+
+func (f *factory) prepareStaticBytes(baseDir string, response *dto.Response) ([]byte, error) {
+	isProtobuf := response.Format == enums.ResponseFormats.Protobuf()
+	if response.Format == enums.ResponseFormats.Bytes() || (response.Static == nil && !isProtobuf) {
+		return f.loadFile(baseDir, response.File, response.Format)
+	}
+	static := response.Static
+	if static == nil {
+		// protobuf files are written as json and encoded with the message
+		content, err := f.loadFile(baseDir, response.File, enums.ResponseFormats.JSON())
 		if err != nil {
 			return nil, err
 		}
-		return bytes, nil
+		if err := json.Unmarshal(content, &static); err != nil {
+			return nil, err
+		}
+	}
+	options, err := f.prepareEncoderOptions(response, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	encoder, err := encoders.New(response.Format, options)
+	if err != nil {
+		return nil, err
 	}
-	return f.loadFile(baseDir, pathToFile, responseFormat)
+	return encoder.Encode(static)
 }
 
 func (f *factory) loadFile(baseDir, pathToFile string, responseFormat enums.ResponseFormat) ([]byte, error) {
@@ -197,6 +635,16 @@ func (f *factory) loadFile(baseDir, pathToFile string, responseFormat enums.Resp
 		validateFunc = json.Unmarshal
 	case enums.ResponseFormats.XML():
 		validateFunc = xml.Unmarshal
+	case enums.ResponseFormats.YAML():
+		validateFunc = yaml.Unmarshal
+	case enums.ResponseFormats.CSV():
+		validateFunc = unmarshalCSV
+	case enums.ResponseFormats.NDJSON():
+		validateFunc = unmarshalNDJSON
+	case enums.ResponseFormats.Form():
+		validateFunc = unmarshalForm
+	case enums.ResponseFormats.Msgpack():
+		validateFunc = unmarshalMsgpack
 	}
 	if validateFunc != nil {
 		var response interface{}
@@ -253,10 +701,64 @@ func (f *factory) buildValuer(param dto.Param, url string) (values.Valuer, error
 			enums.NewConvertsionType(param.Mapped.As),
 			f.logger,
 		)
+	case enums.ValueTypes.Ref():
+		return f.buildRefValuer(param, url)
+	case enums.ValueTypes.Stored():
+		key, err := f.prepareKey(param.Stored.Key, url)
+		if err != nil {
+			return nil, errors.Wrapf(err, "stored %s", param.Stored.Name)
+		}
+		return values.NewStoredValuer(param.Key, param.Stored.Name, key, f.store), nil
 	}
 	return nil, errors.New("not implemented yet")
 }
 
+func (f *factory) buildRefValuer(param dto.Param, url string) (values.Valuer, error) {
+	name := param.Ref.Name
+	definition, ok := f.definitions[name]
+	if !ok {
+		return nil, errors.Wrapf(ErrUnknownRef, "endpoint url %s, definition: %s", url, name)
+	}
+	if f.refDepth >= maxRefDepth {
+		return nil, errors.Wrapf(
+			ErrRefTooDeep,
+			"endpoint url %s, definition: %s exceeds %d nested references",
+			url,
+			name,
+			maxRefDepth,
+		)
+	}
+	if visits := f.resolving[name]; visits > 0 {
+		if param.Ref.Depth == 0 {
+			return nil, errors.Wrapf(
+				ErrCyclicRef,
+				"endpoint url %s, definition: %s references itself, set depth to allow recursion",
+				url,
+				name,
+			)
+		}
+		if visits > param.Ref.Depth {
+			f.logger.Debugf("definition %s reached depth %d", name, param.Ref.Depth)
+			return values.NewNilValuer(param.Key), nil
+		}
+	}
+
+	f.resolving[name]++
+	f.refDepth++
+	defer func() {
+		f.resolving[name]--
+		f.refDepth--
+	}()
+
+	// single value definition without a key takes the key of the reference
+	if len(definition) == 1 && len(definition[0].Key) == 0 {
+		p := definition[0]
+		p.Key = param.Key
+		return f.buildValuer(p, url)
+	}
+	return f.buildObjectValuer(dto.Param{Key: param.Key, Object: definition}, url)
+}
+
 func (f *factory) prepareProxyValuersMap(
 	params dto.Params,
 	disabledTypes []enums.ValueType,
@@ -1,12 +1,16 @@
 package parser_test
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"path"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/certs"
 	"github.com/vimek-go/server-faker/internal/pkg/enums"
 	"github.com/vimek-go/server-faker/internal/pkg/logger"
 	"github.com/vimek-go/server-faker/internal/pkg/parser"
@@ -87,6 +91,175 @@ func TestFactory_CreateResponseEndpoint(t *testing.T) {
 			},
 			expectedError: "no such file or directory",
 		},
+		{
+			name: "static csv response from object",
+			endpoint: dto.Endpoint{
+				Method: http.MethodGet,
+				URL:    "/test",
+				Response: &dto.Response{
+					Type:   enums.ResponseTypes.Static(),
+					Status: http.StatusOK,
+					Format: enums.ResponseFormats.CSV(),
+					CSV:    &dto.CSV{Columns: []string{"name", "id"}},
+					Static: []any{
+						map[string]any{"id": 1, "name": "john"},
+					},
+				},
+			},
+			setup: func(*testing.T, string) {},
+			asserts: func(t *testing.T, handler api.ResponseHandler) {
+				rr := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(rr)
+				handler.Respond(c)
+				require.Equal(t, http.StatusOK, rr.Code)
+				require.Equal(t, "text/csv", rr.Header().Get("Content-Type"))
+				require.Equal(t, "name,id\njohn,1\n", rr.Body.String())
+			},
+		},
+		{
+			name: "error on invalid static yaml file",
+			endpoint: dto.Endpoint{
+				Method: http.MethodGet,
+				URL:    "/test",
+				Response: &dto.Response{
+					Type:   enums.ResponseTypes.Static(),
+					Status: http.StatusOK,
+					File:   "test.yaml",
+					Format: enums.ResponseFormats.YAML(),
+				},
+			},
+			setup: func(t *testing.T, dir string) {
+				tools.SaveToAFile(t, "key: [value", path.Join(dir, "test.yaml"))
+			},
+			expectedError: "yaml",
+		},
+		{
+			name: "static protobuf response from json file",
+			endpoint: dto.Endpoint{
+				Method: http.MethodGet,
+				URL:    "/test",
+				Response: &dto.Response{
+					Type:     enums.ResponseTypes.Static(),
+					Status:   http.StatusOK,
+					File:     "user.json",
+					Format:   enums.ResponseFormats.Protobuf(),
+					Protobuf: &dto.Protobuf{File: "user.proto", Message: "test.User"},
+				},
+			},
+			setup: func(t *testing.T, dir string) {
+				tools.SaveToAFile(
+					t,
+					`syntax = "proto3"; package test; message User { string name = 1; }`,
+					path.Join(dir, "user.proto"),
+				)
+				tools.SaveToAFile(t, `{"name": "john"}`, path.Join(dir, "user.json"))
+			},
+			asserts: func(t *testing.T, handler api.ResponseHandler) {
+				rr := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(rr)
+				handler.Respond(c)
+				require.Equal(t, http.StatusOK, rr.Code)
+				require.Equal(t, "application/x-protobuf", rr.Header().Get("Content-Type"))
+				// field 1, length delimited, "john"
+				require.Equal(t, []byte{0x0a, 0x04, 'j', 'o', 'h', 'n'}, rr.Body.Bytes())
+			},
+		},
+		{
+			name: "error on unknown protobuf message",
+			endpoint: dto.Endpoint{
+				Method: http.MethodGet,
+				URL:    "/test",
+				Response: &dto.Response{
+					Type:     enums.ResponseTypes.Dynamic(),
+					Status:   http.StatusOK,
+					Format:   enums.ResponseFormats.Protobuf(),
+					Protobuf: &dto.Protobuf{File: "user.proto", Message: "test.Missing"},
+					Object: dto.Params{
+						dto.Param{Key: "name", Static: &dto.Static{Value: "john"}},
+					},
+				},
+			},
+			setup: func(t *testing.T, dir string) {
+				tools.SaveToAFile(
+					t,
+					`syntax = "proto3"; package test; message User { string name = 1; }`,
+					path.Join(dir, "user.proto"),
+				)
+			},
+			expectedError: "message not found",
+		},
+		{
+			name: "sse response with listed events",
+			endpoint: dto.Endpoint{
+				Method: http.MethodGet,
+				URL:    "/events",
+				Response: &dto.Response{
+					Type:   enums.ResponseTypes.SSE(),
+					Status: http.StatusOK,
+					Format: enums.ResponseFormats.JSON(),
+					SSE: &dto.SSE{
+						Events: []dto.SSEEvent{{Event: "greeting", Data: "hello"}},
+					},
+				},
+			},
+			setup: func(*testing.T, string) {},
+			asserts: func(t *testing.T, handler api.ResponseHandler) {
+				rr := CreateTestResponseRecorder()
+				c, _ := gin.CreateTestContext(rr)
+				c.Request = httptest.NewRequest(http.MethodGet, "/events", nil)
+				handler.Respond(c)
+				require.Equal(t, "event:greeting\ndata:hello\n\n", rr.Body.String())
+			},
+		},
+		{
+			name: "error on sse response without events",
+			endpoint: dto.Endpoint{
+				Method: http.MethodGet,
+				URL:    "/events",
+				Response: &dto.Response{
+					Type:   enums.ResponseTypes.SSE(),
+					Status: http.StatusOK,
+					Format: enums.ResponseFormats.JSON(),
+					SSE:    &dto.SSE{Count: 3},
+				},
+			},
+			setup:         func(*testing.T, string) {},
+			expectedError: "needs the events or the object",
+		},
+		{
+			name: "error on generated sse events without interval and count",
+			endpoint: dto.Endpoint{
+				Method: http.MethodGet,
+				URL:    "/events",
+				Response: &dto.Response{
+					Type:   enums.ResponseTypes.SSE(),
+					Status: http.StatusOK,
+					Format: enums.ResponseFormats.JSON(),
+					Object: dto.Params{
+						dto.Param{Key: "name", Static: &dto.Static{Value: "john"}},
+					},
+					SSE: &dto.SSE{Duration: dto.Duration(time.Second)},
+				},
+			},
+			setup:         func(*testing.T, string) {},
+			expectedError: "needs the interval or the count",
+		},
+		{
+			name: "error on stream in sse response",
+			endpoint: dto.Endpoint{
+				Method: http.MethodGet,
+				URL:    "/events",
+				Response: &dto.Response{
+					Type:   enums.ResponseTypes.SSE(),
+					Status: http.StatusOK,
+					Format: enums.ResponseFormats.JSON(),
+					SSE:    &dto.SSE{Events: []dto.SSEEvent{{Data: "hello"}}},
+					Stream: &dto.Stream{ChunkSize: 1},
+				},
+			},
+			setup:         func(*testing.T, string) {},
+			expectedError: "stream is supported only in static and dynamic responses",
+		},
 		{
 			name: "dynamic response endpoint creation",
 			endpoint: dto.Endpoint{
@@ -231,7 +404,7 @@ func TestFactory_CreateProxyEndpoint(t *testing.T) {
 				Proxy: &dto.Proxy{
 					Type:   enums.ResponseTypes.Static(),
 					Method: http.MethodGet,
-					URL:    server.URL,
+					URL:    dto.Upstreams{{URL: server.URL}},
 				},
 			},
 			asserts: func(t *testing.T, handler api.Handler) {
@@ -245,6 +418,54 @@ func TestFactory_CreateProxyEndpoint(t *testing.T) {
 				require.Equal(t, http.StatusOK, rr.Code)
 			},
 		},
+		{
+			name: "static proxy with header mapped from the request",
+			endpoint: dto.Endpoint{
+				Method: http.MethodGet,
+				URL:    "/test",
+				Proxy: &dto.Proxy{
+					Type:          enums.ResponseTypes.Static(),
+					Method:        http.MethodGet,
+					URL:           dto.Upstreams{{URL: server.URL}},
+					RemoveHeaders: []string{"X-Caller-Token"},
+					HeaderParams: dto.Params{
+						{Key: "Authorization", Mapped: &dto.Mapped{
+							From: enums.RequestLocations.Header(), Param: "X-Caller-Token",
+						}},
+					},
+				},
+			},
+			asserts: func(t *testing.T, handler api.Handler) {
+				rr := CreateTestResponseRecorder()
+				c, _ := gin.CreateTestContext(rr)
+				c.Request = httptest.NewRequest(http.MethodGet, "/test", nil)
+				handler.Respond(c)
+				require.Equal(t, http.StatusBadRequest, rr.Code)
+
+				rr = CreateTestResponseRecorder()
+				c, _ = gin.CreateTestContext(rr)
+				c.Request = httptest.NewRequest(http.MethodGet, "/test", nil)
+				c.Request.Header.Set("X-Caller-Token", "Bearer abc")
+				handler.Respond(c)
+				require.Equal(t, http.StatusOK, rr.Code)
+			},
+		},
+		{
+			name: "error on object header param",
+			endpoint: dto.Endpoint{
+				Method: http.MethodGet,
+				URL:    "/test",
+				Proxy: &dto.Proxy{
+					Type:   enums.ResponseTypes.Static(),
+					Method: http.MethodGet,
+					URL:    dto.Upstreams{{URL: server.URL}},
+					HeaderParams: dto.Params{
+						{Key: "X-User", Object: dto.Params{{Key: "id", Static: &dto.Static{Value: 1}}}},
+					},
+				},
+			},
+			expectedError: parser.ErrNotHandled,
+		},
 		{
 			name: "dynamic proxy endpoint creation",
 			endpoint: dto.Endpoint{
@@ -253,7 +474,7 @@ func TestFactory_CreateProxyEndpoint(t *testing.T) {
 				Proxy: &dto.Proxy{
 					Type:   enums.ResponseTypes.Dynamic(),
 					Method: http.MethodGet,
-					URL:    server.URL,
+					URL:    dto.Upstreams{{URL: server.URL}},
 					URLParams: dto.Params{
 						dto.Param{
 							Key: "url",
@@ -294,13 +515,68 @@ func TestFactory_CreateProxyEndpoint(t *testing.T) {
 				require.Equal(t, http.StatusOK, rr.Code)
 			},
 		},
+		{
+			name: "error on invalid path of the deleted field",
+			endpoint: dto.Endpoint{
+				Method: http.MethodGet,
+				URL:    "/test",
+				Proxy: &dto.Proxy{
+					Type:     enums.ResponseTypes.Static(),
+					Method:   http.MethodGet,
+					URL:      dto.Upstreams{{URL: server.URL}},
+					Response: &dto.ProxyResponse{Delete: []string{"user.password"}},
+				},
+			},
+			expectedError: api.ErrInvalidFieldPath,
+		},
+		{
+			name: "error on not supported proxy content type",
+			endpoint: dto.Endpoint{
+				Method: http.MethodPatch,
+				URL:    "/test",
+				Proxy: &dto.Proxy{
+					Type:        enums.ResponseTypes.Dynamic(),
+					Method:      http.MethodPatch,
+					URL:         dto.Upstreams{{URL: server.URL}},
+					ContentType: "application/msgpack",
+				},
+			},
+			expectedError: api.ErrUnsupportedContentType,
+		},
+		{
+			name: "error on relative proxy url",
+			endpoint: dto.Endpoint{
+				Method: http.MethodGet,
+				URL:    "/test",
+				Proxy: &dto.Proxy{
+					Type:   enums.ResponseTypes.Static(),
+					Method: http.MethodGet,
+					URL:    dto.Upstreams{{URL: "/users"}},
+				},
+			},
+			expectedError: api.ErrInvalidProxyURL,
+		},
+		{
+			name: "error on missing upstream CA",
+			endpoint: dto.Endpoint{
+				Method: http.MethodGet,
+				URL:    "/test",
+				Proxy: &dto.Proxy{
+					Type:   enums.ResponseTypes.Static(),
+					Method: http.MethodGet,
+					URL:    dto.Upstreams{{URL: server.URL}},
+					TLS:    &dto.ProxyTLS{CA: "missing-ca.pem"},
+				},
+			},
+			expectedError: certs.ErrLoadingCertificate,
+		},
 	}
 	for i := range testCases {
 		tc := testCases[i]
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 			f := parser.NewFactory(nil, logger.NewTestLogger())
-			handler, err := f.CreateProxyEndpoint(tc.endpoint)
+			handler, err := f.CreateProxyEndpoint(tc.endpoint, "")
 			if tc.expectedError != nil {
 				require.Error(t, err)
 				require.ErrorIs(t, err, tc.expectedError)
@@ -311,3 +587,347 @@ func TestFactory_CreateProxyEndpoint(t *testing.T) {
 		})
 	}
 }
+
+func TestFactory_CreateResponseEndpointWithDefinitions(t *testing.T) {
+	t.Parallel()
+	definitions := map[string]dto.Params{
+		"user": {
+			{Key: "name", Static: &dto.Static{Value: "john"}},
+			{Key: "address", Ref: &dto.Ref{Name: "address"}},
+		},
+		"address": {
+			{Key: "city", Static: &dto.Static{Value: "warsaw"}},
+		},
+		"id": {
+			{Static: &dto.Static{Value: "id-value"}},
+		},
+		"category": {
+			{Key: "name", Static: &dto.Static{Value: "category"}},
+			{Key: "children", Array: &dto.Array{Min: 1, Max: 1, Element: dto.Params{
+				{Ref: &dto.Ref{Name: "category", Depth: 1}},
+			}}},
+		},
+		"cyclic": {
+			{Key: "self", Ref: &dto.Ref{Name: "cyclic"}},
+		},
+	}
+	testCases := []struct {
+		name          string
+		object        dto.Params
+		expected      string
+		expectedError error
+	}{
+		{
+			name: "nested object references",
+			object: dto.Params{
+				{Key: "user", Ref: &dto.Ref{Name: "user"}},
+			},
+			expected: `{"user":{"address":{"city":"warsaw"},"name":"john"}}`,
+		},
+		{
+			name: "single value reference takes the key of the param",
+			object: dto.Params{
+				{Key: "id", Ref: &dto.Ref{Name: "id"}},
+				{Key: "other", Ref: &dto.Ref{Name: "id"}},
+			},
+			expected: `{"id":"id-value","other":"id-value"}`,
+		},
+		{
+			name: "recursive reference limited by depth",
+			object: dto.Params{
+				{Ref: &dto.Ref{Name: "category"}},
+			},
+			expected: `{"children":[{"children":[],"name":"category"}],"name":"category"}`,
+		},
+		{
+			name: "cyclic reference without depth",
+			object: dto.Params{
+				{Ref: &dto.Ref{Name: "cyclic"}},
+			},
+			expectedError: parser.ErrCyclicRef,
+		},
+		{
+			name: "unknown reference",
+			object: dto.Params{
+				{Key: "missing", Ref: &dto.Ref{Name: "missing"}},
+			},
+			expectedError: parser.ErrUnknownRef,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			f := parser.NewFactory(nil, logger.NewTestLogger())
+			f.SetDefinitions(definitions)
+			handler, err := f.CreateResponseEndpoint(dto.Endpoint{
+				Method: http.MethodGet,
+				URL:    "/test",
+				Response: &dto.Response{
+					Type:   enums.ResponseTypes.Dynamic(),
+					Status: http.StatusOK,
+					Format: enums.ResponseFormats.JSON(),
+					Object: tc.object,
+				},
+			}, t.TempDir())
+			if tc.expectedError != nil {
+				require.Error(t, err)
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rr)
+			handler.Respond(c)
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Equal(t, tc.expected, rr.Body.String())
+		})
+	}
+}
+
+func TestFactory_CreateScenarioEndpoint(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name          string
+		key           *dto.Param
+		expectedState map[string]string
+		expectedError error
+	}{
+		{
+			name:          "single instance of the scenario",
+			expectedState: map[string]string{"": "approved"},
+		},
+		{
+			name: "states kept per header value",
+			key: &dto.Param{Mapped: &dto.Mapped{
+				From: enums.RequestLocations.Header(), Param: "X-User",
+			}},
+			expectedState: map[string]string{"7": "approved"},
+		},
+		{
+			name: "named key kept by the value only",
+			key: &dto.Param{Key: "user", Mapped: &dto.Mapped{
+				From: enums.RequestLocations.Header(), Param: "X-User",
+			}},
+			expectedState: map[string]string{"7": "approved"},
+		},
+		{
+			name:          "object key",
+			key:           &dto.Param{Object: dto.Params{{Key: "id", Static: &dto.Static{Value: 1}}}},
+			expectedError: parser.ErrNotHandled,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			f := parser.NewFactory(nil, logger.NewTestLogger())
+			handler, err := f.CreateEndpoint(dto.Endpoint{
+				Method: http.MethodPost,
+				URL:    "/orders/approve",
+				Response: &dto.Response{
+					Type:   enums.ResponseTypes.Static(),
+					Status: http.StatusNoContent,
+					Format: enums.ResponseFormats.JSON(),
+					Static: map[string]any{},
+				},
+				Scenario: &dto.Scenario{Name: "order", Next: "approved", Key: tc.key},
+			}, t.TempDir())
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Implements(t, (*api.ScenarioHandler)(nil), handler)
+			rr := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rr)
+			c.Request = httptest.NewRequest(http.MethodPost, "/orders/approve", nil)
+			c.Request.Header.Set("X-User", "7")
+			handler.Respond(c)
+			require.Equal(t, tc.expectedState, f.Scenarios().States()["order"])
+		})
+	}
+}
+
+func TestFactory_CreateSequenceEndpoint(t *testing.T) {
+	t.Parallel()
+	response := func(status int) dto.Response {
+		return dto.Response{
+			Type:   enums.ResponseTypes.Static(),
+			Status: status,
+			Format: enums.ResponseFormats.JSON(),
+			Static: map[string]any{},
+		}
+	}
+	testCases := []struct {
+		name     string
+		sequence dto.Sequence
+		// urls are called in order, /jobs/1 for every expected status when empty
+		urls             []string
+		expectedStatuses []int
+		expectedError    error
+	}{
+		{
+			name: "last response repeated by default",
+			sequence: dto.Sequence{
+				Responses: []dto.Response{response(http.StatusServiceUnavailable), response(http.StatusOK)},
+			},
+			expectedStatuses: []int{http.StatusServiceUnavailable, http.StatusOK, http.StatusOK},
+		},
+		{
+			name: "cycled responses",
+			sequence: dto.Sequence{
+				Responses: []dto.Response{response(http.StatusServiceUnavailable), response(http.StatusOK)},
+				After:     enums.SequencePolicies.Cycle(),
+			},
+			expectedStatuses: []int{http.StatusServiceUnavailable, http.StatusOK, http.StatusServiceUnavailable},
+		},
+		{
+			name: "responses counted per value of the named key",
+			sequence: dto.Sequence{
+				Responses: []dto.Response{response(http.StatusServiceUnavailable), response(http.StatusOK)},
+				Key:       &dto.Param{Key: "job", Mapped: &dto.Mapped{From: enums.RequestLocations.URL(), Param: "id"}},
+			},
+			urls:             []string{"/jobs/1", "/jobs/1", "/jobs/2"},
+			expectedStatuses: []int{http.StatusServiceUnavailable, http.StatusOK, http.StatusServiceUnavailable},
+		},
+		{
+			name: "error on invalid response",
+			sequence: dto.Sequence{
+				Responses: []dto.Response{response(http.StatusOK), {
+					Type:    enums.ResponseTypes.Static(),
+					Status:  http.StatusOK,
+					Format:  enums.ResponseFormats.JSON(),
+					Formats: []enums.ResponseFormat{enums.ResponseFormats.XML()},
+				}},
+			},
+			expectedError: api.ErrNotSupportedFormat,
+		},
+		{
+			name: "error on array key",
+			sequence: dto.Sequence{
+				Responses: []dto.Response{response(http.StatusOK)},
+				Key:       &dto.Param{Array: &dto.Array{Min: 1, Max: 1}},
+			},
+			expectedError: parser.ErrNotHandled,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			f := parser.NewFactory(nil, logger.NewTestLogger())
+			handler, err := f.CreateEndpoint(dto.Endpoint{
+				Method:   http.MethodGet,
+				URL:      "/jobs/:id",
+				Sequence: &tc.sequence,
+			}, t.TempDir())
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			for i, expectedStatus := range tc.expectedStatuses {
+				url := "/jobs/1"
+				if len(tc.urls) > 0 {
+					url = tc.urls[i]
+				}
+				rr := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(rr)
+				c.Request = httptest.NewRequest(http.MethodGet, url, nil)
+				c.Params = gin.Params{{Key: "id", Value: strings.TrimPrefix(url, "/jobs/")}}
+				handler.Respond(c)
+				require.Equal(t, expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestFactory_CreateStoreEndpoint(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name string
+		// storeKey and storedKey are the names of the key params, only their values are used
+		storeKey  string
+		storedKey string
+	}{
+		{name: "keys without names"},
+		{name: "keys with different names", storeKey: "session", storedKey: "token"},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			f := parser.NewFactory(nil, logger.NewTestLogger())
+			login, err := f.CreateEndpoint(dto.Endpoint{
+				Method: http.MethodPost,
+				URL:    "/login",
+				Response: &dto.Response{
+					Type:   enums.ResponseTypes.Dynamic(),
+					Status: http.StatusOK,
+					Format: enums.ResponseFormats.JSON(),
+					Object: dto.Params{{Key: "token", Random: &dto.Random{Type: "string-all", Min: 8, Max: 8}}},
+				},
+				Store: []dto.StoreEntry{{
+					Name: "sessions",
+					Key: &dto.Param{
+						Key:    tc.storeKey,
+						Mapped: &dto.Mapped{From: enums.RequestLocations.Response(), Path: "$.token"},
+					},
+					Value: dto.Param{Mapped: &dto.Mapped{From: enums.RequestLocations.Body(), Path: "$.username"}},
+				}},
+			}, t.TempDir())
+			require.NoError(t, err)
+			me, err := f.CreateEndpoint(dto.Endpoint{
+				Method: http.MethodGet,
+				URL:    "/me",
+				Response: &dto.Response{
+					Type:   enums.ResponseTypes.Dynamic(),
+					Status: http.StatusOK,
+					Format: enums.ResponseFormats.JSON(),
+					Object: dto.Params{{Key: "username", Stored: &dto.Stored{
+						Name: "sessions",
+						Key: &dto.Param{
+							Key:    tc.storedKey,
+							Mapped: &dto.Mapped{From: enums.RequestLocations.Header(), Param: "X-Token"},
+						},
+					}}},
+				},
+			}, t.TempDir())
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rr)
+			c.Request = httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"username":"john"}`))
+			c.Request.Header.Set("Content-Type", "application/json")
+			login.Respond(c)
+			require.Equal(t, http.StatusOK, rr.Code)
+			var session map[string]string
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &session))
+
+			for token, expected := range map[string]int{session["token"]: http.StatusOK, "unknown": http.StatusNotFound} {
+				rr = httptest.NewRecorder()
+				c, _ = gin.CreateTestContext(rr)
+				c.Request = httptest.NewRequest(http.MethodGet, "/me", nil)
+				c.Request.Header.Set("X-Token", token)
+				me.Respond(c)
+				require.Equal(t, expected, rr.Code)
+				if expected == http.StatusOK {
+					require.JSONEq(t, `{"username":"john"}`, rr.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestFactory_CreateStoreEndpointWithInvalidValue(t *testing.T) {
+	t.Parallel()
+	f := parser.NewFactory(nil, logger.NewTestLogger())
+	_, err := f.CreateEndpoint(dto.Endpoint{
+		Method:   http.MethodPost,
+		URL:      "/login",
+		Response: &dto.Response{Type: enums.ResponseTypes.Static(), Status: http.StatusOK, Format: "json", Static: "ok"},
+		Store:    []dto.StoreEntry{{Name: "sessions", Value: dto.Param{}}},
+	}, t.TempDir())
+	require.ErrorIs(t, err, dto.ErrParamNotValid)
+}
@@ -0,0 +1,48 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"github.com/ugorji/go/codec"
+)
+
+// maxNDJSONLine is the longest line accepted in ndjson files
+const maxNDJSONLine = 10 * 1024 * 1024
+
+// the functions below validate the static files of the formats
+// without the unmarshal function in the standard library
+
+func unmarshalCSV(data []byte, _ any) error {
+	_, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	return err
+}
+
+func unmarshalNDJSON(data []byte, v any) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, maxNDJSONLine)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(scanner.Bytes(), v); err != nil {
+			return errors.Wrapf(err, "line %d", line)
+		}
+	}
+	return scanner.Err()
+}
+
+func unmarshalForm(data []byte, _ any) error {
+	_, err := url.ParseQuery(string(data))
+	return err
+}
+
+func unmarshalMsgpack(data []byte, v any) error {
+	return codec.NewDecoderBytes(data, &codec.MsgpackHandle{}).Decode(v)
+}
@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/graphql"
+	"github.com/vimek-go/server-faker/internal/pkg/parser/dto"
+	"github.com/vimek-go/server-faker/internal/pkg/tools"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/pkg/errors"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+var (
+	ErrUnknownGraphQLType  = errors.New("unknown graphql type")
+	ErrUnknownGraphQLField = errors.New("unknown graphql field")
+)
+
+func (f *factory) CreateGraphQLEndpoint(endpoint dto.Endpoint, baseDir string) (api.Handler, error) {
+	if !tools.ArrayContains(endpoint.Method, []string{http.MethodGet, http.MethodPost}) {
+		return nil, errors.Wrapf(
+			ErrNotHandled,
+			"graphql endpoint %s has to use method GET or POST, got %s",
+			endpoint.URL,
+			endpoint.Method,
+		)
+	}
+	config := endpoint.GraphQL
+	schema, err := graphql.LoadSchema(filepath.Join(baseDir, config.Schema))
+	if err != nil {
+		return nil, errors.Wrapf(err, "graphql endpoint %s", endpoint.URL)
+	}
+
+	options := graphql.Options{
+		Types:  make(map[string]values.Valuer, len(config.Types)),
+		Fields: make(map[string]values.Valuer, len(config.Fields)),
+	}
+	if config.List != nil {
+		options.ListMin, options.ListMax = config.List.Min, config.List.Max
+	}
+	for name, params := range config.Types {
+		if _, ok := schema.Types[name]; !ok {
+			return nil, errors.Wrapf(ErrUnknownGraphQLType, "graphql endpoint %s, type %s", endpoint.URL, name)
+		}
+		if options.Types[name], err = f.prepareGraphQLValuer(params, endpoint.URL, name); err != nil {
+			return nil, err
+		}
+	}
+	for name, params := range config.Fields {
+		if err := validateGraphQLField(schema, name); err != nil {
+			return nil, errors.Wrapf(err, "graphql endpoint %s", endpoint.URL)
+		}
+		if options.Fields[name], err = f.prepareGraphQLValuer(params, endpoint.URL, name); err != nil {
+			return nil, err
+		}
+	}
+
+	executor, err := graphql.NewExecutor(schema, options, f.logger)
+	if err != nil {
+		return nil, errors.Wrapf(err, "graphql endpoint %s", endpoint.URL)
+	}
+	return api.NewGraphQLHandler(endpoint.Method, endpoint.URL, executor, f.logger), nil
+}
+
+func (f *factory) prepareGraphQLValuer(params dto.Params, url, name string) (values.Valuer, error) {
+	valuer, err := f.PrepareValuer(params, url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "graphql endpoint %s, override %s", url, name)
+	}
+	if valuer == nil {
+		return nil, errors.Wrapf(ErrValidation, "graphql endpoint %s, override %s without params", url, name)
+	}
+	return valuer, nil
+}
+
+// validateGraphQLField checks the field keyed by type and field name, e.g. Query.user
+func validateGraphQLField(schema *ast.Schema, name string) error {
+	typeName, fieldName, found := strings.Cut(name, ".")
+	if !found {
+		return errors.Wrapf(ErrUnknownGraphQLField, "field %s has to be named as Type.field", name)
+	}
+	definition, ok := schema.Types[typeName]
+	if !ok {
+		return errors.Wrapf(ErrUnknownGraphQLType, "type %s of field %s", typeName, name)
+	}
+	if definition.Fields.ForName(fieldName) == nil || strings.HasPrefix(fieldName, "__") {
+		return errors.Wrapf(ErrUnknownGraphQLField, "field %s", name)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package parser_test
+
+import (
+	"net/http"
+	"path"
+	"testing"
+
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/parser"
+	"github.com/vimek-go/server-faker/internal/pkg/parser/dto"
+	"github.com/vimek-go/server-faker/internal/pkg/tools"
+
+	"github.com/stretchr/testify/require"
+)
+
+const userSchema = `
+scalar DateTime
+type User { id: ID! name: String! created: DateTime }
+type Query { user(id: ID!): User }
+`
+
+func TestFactory_CreateGraphQLEndpoint(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	tools.SaveToAFile(t, userSchema, path.Join(dir, "schema.graphql"))
+	static := dto.Params{{Static: &dto.Static{Value: "2024-01-01"}}}
+	testCases := []struct {
+		name          string
+		method        string
+		graphql       dto.GraphQL
+		expectedError string
+	}{
+		{
+			name:   "graphql endpoint creation",
+			method: http.MethodPost,
+			graphql: dto.GraphQL{
+				Schema: "schema.graphql",
+				List:   &dto.GraphQLList{Min: 1, Max: 2},
+				Types:  map[string]dto.Params{"DateTime": static},
+				Fields: map[string]dto.Params{"Query.user": {
+					{Key: "id", Mapped: &dto.Mapped{From: enums.RequestLocations.Body(), Path: "$.args.id"}},
+				}},
+			},
+		},
+		{
+			name:          "error on method other than GET or POST",
+			method:        http.MethodPut,
+			graphql:       dto.GraphQL{Schema: "schema.graphql"},
+			expectedError: "has to use method GET or POST",
+		},
+		{
+			name:          "error on missing schema",
+			method:        http.MethodPost,
+			graphql:       dto.GraphQL{Schema: "missing.graphql"},
+			expectedError: "failed loading graphql schema",
+		},
+		{
+			name:   "error on unknown type",
+			method: http.MethodPost,
+			graphql: dto.GraphQL{
+				Schema: "schema.graphql",
+				Types:  map[string]dto.Params{"Post": static},
+			},
+			expectedError: "unknown graphql type",
+		},
+		{
+			name:   "error on unknown field",
+			method: http.MethodGet,
+			graphql: dto.GraphQL{
+				Schema: "schema.graphql",
+				Fields: map[string]dto.Params{"User.email": static},
+			},
+			expectedError: "unknown graphql field",
+		},
+		{
+			name:   "error on field without type",
+			method: http.MethodGet,
+			graphql: dto.GraphQL{
+				Schema: "schema.graphql",
+				Fields: map[string]dto.Params{"user": static},
+			},
+			expectedError: "has to be named as Type.field",
+		},
+		{
+			name:   "error on override without params",
+			method: http.MethodGet,
+			graphql: dto.GraphQL{
+				Schema: "schema.graphql",
+				Types:  map[string]dto.Params{"User": {}},
+			},
+			expectedError: "override User without params",
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			f := parser.NewFactory(nil, logger.NewTestLogger())
+			handler, err := f.CreateEndpoint(dto.Endpoint{URL: "/graphql", Method: tc.method, GraphQL: &tc.graphql}, dir)
+			if len(tc.expectedError) > 0 {
+				require.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.method, handler.Method())
+			require.Equal(t, "/graphql", handler.URL())
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package parser
+
+import (
+	"path/filepath"
+
+	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/encoders"
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/grpcmock"
+	"github.com/vimek-go/server-faker/internal/pkg/parser/dto"
+
+	"github.com/pkg/errors"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+var (
+	ErrUnknownMethod      = errors.New("unknown grpc method")
+	ErrUnknownMessageType = errors.New("unknown message type")
+)
+
+func (f *factory) CreateGRPCService(service dto.GRPCService, baseDir string) (grpcmock.Service, error) {
+	filePath := filepath.Join(baseDir, service.File)
+	importPaths := make([]string, len(service.ImportPaths))
+	for i := range service.ImportPaths {
+		importPaths[i] = filepath.Join(baseDir, service.ImportPaths[i])
+	}
+	files, err := f.protos.Load(filePath, importPaths)
+	if err != nil {
+		return grpcmock.Service{}, err
+	}
+	descriptor, err := f.protos.FindService(filePath, importPaths, service.Service)
+	if err != nil {
+		return grpcmock.Service{}, err
+	}
+
+	rval := grpcmock.Service{Descriptor: descriptor, Files: files}
+	for _, method := range service.Methods {
+		f.logger.Infof("processing grpc method %s/%s", service.Service, method.Name)
+		methodDescriptor := descriptor.Methods().ByName(protoreflect.Name(method.Name))
+		if methodDescriptor == nil {
+			return grpcmock.Service{}, errors.Wrapf(ErrUnknownMethod, "method %s of service %s", method.Name, service.Service)
+		}
+		handler, err := f.createGRPCMethod(methodDescriptor, method, files)
+		if err != nil {
+			return grpcmock.Service{}, errors.Wrapf(err, "method %s of service %s", method.Name, service.Service)
+		}
+		rval.Handlers = append(rval.Handlers, handler)
+	}
+	return rval, nil
+}
+
+func (f *factory) createGRPCMethod(
+	descriptor protoreflect.MethodDescriptor,
+	method dto.GRPCMethod,
+	files *protoregistry.Files,
+) (grpcmock.MethodHandler, error) {
+	if method.Status != nil {
+		status, err := f.prepareGRPCStatus(method.Status, files)
+		if err != nil {
+			return nil, err
+		}
+		return grpcmock.NewStatusHandler(descriptor, status, f.logger), nil
+	}
+	switch method.Type {
+	case enums.ResponseTypes.Static():
+		response, err := encoders.ToMessage(descriptor.Output(), method.Static)
+		if err != nil {
+			return nil, err
+		}
+		return grpcmock.NewStaticHandler(descriptor, response, f.logger), nil
+	case enums.ResponseTypes.Dynamic():
+		valuer, err := f.PrepareValuer(method.Object, "/"+string(descriptor.Parent().FullName())+"/"+method.Name)
+		if err != nil {
+			return nil, err
+		}
+		return grpcmock.NewDynamicHandler(descriptor, valuer, f.logger), nil
+	}
+	return nil, errors.Wrapf(ErrNotHandled, "grpc response type %s", method.Type)
+}
+
+func (f *factory) prepareGRPCStatus(status *dto.GRPCStatus, files *protoregistry.Files) (*spb.Status, error) {
+	rval := &spb.Status{Code: int32(status.Code), Message: status.Message}
+	for _, detail := range status.Details {
+		descriptor, err := files.FindDescriptorByName(protoreflect.FullName(detail.Type))
+		if err != nil {
+			// the google.rpc error details are always available
+			descriptor, err = protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(detail.Type))
+		}
+		messageDescriptor, ok := descriptor.(protoreflect.MessageDescriptor)
+		if err != nil || !ok {
+			return nil, errors.Wrapf(ErrUnknownMessageType, "status detail %s", detail.Type)
+		}
+		message, err := encoders.ToMessage(messageDescriptor, detail.Value)
+		if err != nil {
+			return nil, err
+		}
+		value, err := anypb.New(message)
+		if err != nil {
+			return nil, errors.Wrapf(err, "status detail %s", detail.Type)
+		}
+		rval.Details = append(rval.Details, value)
+	}
+	return rval, nil
+}
+
+// checkGRPCProxy rejects the options of the http proxies, it runs before any part of the proxy is built
+func checkGRPCProxy(endpoint dto.Endpoint) error {
+	proxy := endpoint.Proxy
+	if proxy.GRPC == nil {
+		return errors.Wrapf(ErrNotHandled, "grpc proxy of %s without the grpc method", endpoint.URL)
+	}
+	if proxy.Mirror != nil || proxy.Response != nil || proxy.Retry != nil || len(proxy.HTTPProxy) > 0 {
+		return errors.Wrapf(ErrNotHandled, "mirror, response, retry and http_proxy of the grpc proxy %s", endpoint.URL)
+	}
+	if len(proxy.URLParams) > 0 || len(proxy.Query) > 0 || len(proxy.ContentType) > 0 {
+		return errors.Wrapf(
+			ErrNotHandled, "url_params, query_params and content_type of the grpc proxy %s", endpoint.URL,
+		)
+	}
+	return nil
+}
+
+// createGRPCProxy converts the requests of the endpoint to the calls of the upstream grpc method,
+// the request message is the generated object or the incoming body when the object is empty
+func (f *factory) createGRPCProxy(
+	endpoint dto.Endpoint,
+	baseDir string,
+	upstreams api.UpstreamPool,
+	headers api.ProxyHeaders,
+	fallback api.Handler,
+) (api.Handler, error) {
+	proxy := endpoint.Proxy
+	filePath := filepath.Join(baseDir, proxy.GRPC.File)
+	importPaths := make([]string, len(proxy.GRPC.ImportPaths))
+	for i := range proxy.GRPC.ImportPaths {
+		importPaths[i] = filepath.Join(baseDir, proxy.GRPC.ImportPaths[i])
+	}
+	files, err := f.protos.Load(filePath, importPaths)
+	if err != nil {
+		return nil, err
+	}
+	service, err := f.protos.FindService(filePath, importPaths, proxy.GRPC.Service)
+	if err != nil {
+		return nil, err
+	}
+	method := service.Methods().ByName(protoreflect.Name(proxy.GRPC.Method))
+	if method == nil {
+		return nil, errors.Wrapf(ErrUnknownMethod, "method %s of service %s", proxy.GRPC.Method, proxy.GRPC.Service)
+	}
+
+	var body api.ProxyBody
+	if len(proxy.Object) > 0 {
+		valuer, err := f.PrepareValuer(proxy.Object, endpoint.URL)
+		if err != nil {
+			return nil, err
+		}
+		if body, err = api.NewProxyBody(valuer, "", proxy.MergeBody); err != nil {
+			return nil, err
+		}
+	}
+	config, err := f.prepareProxyTLS(proxy, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	options := api.GRPCProxyOptions{
+		ConnectTimeout:  proxy.ConnectTimeout.Duration(),
+		ResponseTimeout: proxy.ResponseTimeout.Duration(),
+		TLS:             config,
+	}
+	return api.NewGRPCProxy(
+		endpoint.Method, endpoint.URL, method, files, upstreams, body, headers, options, fallback, f.logger,
+	)
+}
@@ -0,0 +1,228 @@
+package parser_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+
+	"github.com/vimek-go/server-faker/internal/pkg/encoders"
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/grpcmock"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/parser"
+	"github.com/vimek-go/server-faker/internal/pkg/parser/dto"
+	"github.com/vimek-go/server-faker/internal/pkg/tools"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const greeterProto = `syntax = "proto3";
+package greeter;
+message HelloRequest { string name = 1; }
+message HelloReply { string message = 1; }
+service Greeter { rpc SayHello(HelloRequest) returns (HelloReply); }
+`
+
+func TestFactory_CreateGRPCService(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name          string
+		method        dto.GRPCMethod
+		asserts       func(*testing.T, grpcmock.MethodHandler)
+		expectedError error
+	}{
+		{
+			name: "dynamic response mapped from request",
+			method: dto.GRPCMethod{
+				Name: "SayHello",
+				Type: enums.ResponseTypes.Dynamic(),
+				Object: dto.Params{
+					{Key: "message", Mapped: &dto.Mapped{From: enums.RequestLocations.Body(), Path: "$.name"}},
+				},
+			},
+			asserts: func(t *testing.T, handler grpcmock.MethodHandler) {
+				request := dynamicpb.NewMessage(handler.Method().Input())
+				require.NoError(t, protojson.Unmarshal([]byte(`{"name": "john"}`), request))
+				response, err := handler.Respond(context.Background(), request)
+				require.NoError(t, err)
+				content, err := protojson.Marshal(response)
+				require.NoError(t, err)
+				require.JSONEq(t, `{"message": "john"}`, string(content))
+			},
+		},
+		{
+			name: "status with error details",
+			method: dto.GRPCMethod{
+				Name: "SayHello",
+				Status: &dto.GRPCStatus{
+					Code:    codes.PermissionDenied,
+					Message: "denied",
+					Details: []dto.GRPCDetail{
+						{Type: "google.rpc.ErrorInfo", Value: map[string]any{"reason": "NO_ACCESS"}},
+					},
+				},
+			},
+			asserts: func(t *testing.T, handler grpcmock.MethodHandler) {
+				_, err := handler.Respond(context.Background(), dynamicpb.NewMessage(handler.Method().Input()))
+				st := status.Convert(err)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+				require.Len(t, st.Details(), 1)
+				require.Equal(t, "NO_ACCESS", st.Details()[0].(*errdetails.ErrorInfo).GetReason())
+			},
+		},
+		{
+			name:          "unknown method",
+			method:        dto.GRPCMethod{Name: "SayGoodbye", Type: enums.ResponseTypes.Static()},
+			expectedError: parser.ErrUnknownMethod,
+		},
+		{
+			name: "unknown status detail type",
+			method: dto.GRPCMethod{
+				Name:   "SayHello",
+				Status: &dto.GRPCStatus{Code: codes.Internal, Details: []dto.GRPCDetail{{Type: "greeter.Missing"}}},
+			},
+			expectedError: parser.ErrUnknownMessageType,
+		},
+		{
+			name: "static response not matching the message",
+			method: dto.GRPCMethod{
+				Name:   "SayHello",
+				Type:   enums.ResponseTypes.Static(),
+				Static: map[string]any{"text": "hello"},
+			},
+			expectedError: encoders.ErrEncodingFailed,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			tools.SaveToAFile(t, greeterProto, path.Join(dir, "greeter.proto"))
+			f := parser.NewFactory(nil, logger.NewTestLogger())
+			service, err := f.CreateGRPCService(dto.GRPCService{
+				File:    "greeter.proto",
+				Service: "greeter.Greeter",
+				Methods: []dto.GRPCMethod{tc.method},
+			}, dir)
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, service.Handlers, 1)
+			tc.asserts(t, service.Handlers[0])
+		})
+	}
+}
+
+func TestFactory_CreateGRPCProxy(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	tools.SaveToAFile(t, greeterProto, path.Join(dir, "greeter.proto"))
+	f := parser.NewFactory(nil, logger.NewTestLogger())
+	service, err := f.CreateGRPCService(dto.GRPCService{
+		File:    "greeter.proto",
+		Service: "greeter.Greeter",
+		Methods: []dto.GRPCMethod{{
+			Name: "SayHello",
+			Type: enums.ResponseTypes.Dynamic(),
+			Object: dto.Params{
+				{Key: "message", Mapped: &dto.Mapped{From: enums.RequestLocations.Body(), Path: "$.name"}},
+			},
+		}},
+	}, dir)
+	require.NoError(t, err)
+	server, err := grpcmock.NewServer([]grpcmock.Service{service}, logger.NewTestLogger())
+	require.NoError(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	testCases := []struct {
+		name          string
+		grpc          dto.ProxyGRPC
+		mirror        *dto.ProxyMirror
+		query         dto.Params
+		contentType   string
+		expectedError error
+	}{
+		{
+			name: "query converted to the request message",
+			grpc: dto.ProxyGRPC{File: "greeter.proto", Service: "greeter.Greeter", Method: "SayHello"},
+		},
+		{
+			name:          "unknown method",
+			grpc:          dto.ProxyGRPC{File: "greeter.proto", Service: "greeter.Greeter", Method: "SayGoodbye"},
+			expectedError: parser.ErrUnknownMethod,
+		},
+		{
+			name:          "mirror not handled",
+			grpc:          dto.ProxyGRPC{File: "greeter.proto", Service: "greeter.Greeter", Method: "SayHello"},
+			mirror:        &dto.ProxyMirror{URL: "http://127.0.0.1:1"},
+			expectedError: parser.ErrNotHandled,
+		},
+		{
+			name:          "invalid mirror rejected before it is built",
+			grpc:          dto.ProxyGRPC{File: "greeter.proto", Service: "greeter.Greeter", Method: "SayHello"},
+			mirror:        &dto.ProxyMirror{URL: "://mirror"},
+			expectedError: parser.ErrNotHandled,
+		},
+		{
+			name:          "query params not handled",
+			grpc:          dto.ProxyGRPC{File: "greeter.proto", Service: "greeter.Greeter", Method: "SayHello"},
+			query:         dto.Params{{Key: "page", Static: &dto.Static{Value: "1"}}},
+			expectedError: parser.ErrNotHandled,
+		},
+		{
+			name:          "content type not handled",
+			grpc:          dto.ProxyGRPC{File: "greeter.proto", Service: "greeter.Greeter", Method: "SayHello"},
+			contentType:   "application/xml",
+			expectedError: parser.ErrNotHandled,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			handler, err := f.CreateProxyEndpoint(dto.Endpoint{
+				URL:    "/hello",
+				Method: http.MethodGet,
+				Proxy: &dto.Proxy{
+					Type: enums.ResponseTypes.GRPC(),
+					URL:  dto.Upstreams{{URL: "http://" + listener.Addr().String()}},
+					GRPC: &tc.grpc,
+					Object: dto.Params{
+						{Key: "name", Mapped: &dto.Mapped{From: enums.RequestLocations.Query(), Param: "name"}},
+					},
+					Mirror:      tc.mirror,
+					Query:       tc.query,
+					ContentType: tc.contentType,
+				},
+			}, dir)
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			rr := CreateTestResponseRecorder()
+			c, _ := gin.CreateTestContext(rr)
+			c.Request = httptest.NewRequest(http.MethodGet, "/hello?name=john", nil)
+			handler.Respond(c)
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.JSONEq(t, `{"message":"john"}`, rr.Body.String())
+		})
+	}
+}
@@ -4,6 +4,7 @@ package mocks
 
 import (
 	api "github.com/vimek-go/server-faker/internal/pkg/api"
+	grpcmock "github.com/vimek-go/server-faker/internal/pkg/grpcmock"
 	dto "github.com/vimek-go/server-faker/internal/pkg/parser/dto"
 
 	mock "github.com/stretchr/testify/mock"
@@ -14,6 +15,36 @@ type FactoryMock struct {
 	mock.Mock
 }
 
+// CreateCatchAll provides a mock function with given fields: catchAll
+func (_m *FactoryMock) CreateCatchAll(catchAll dto.CatchAll) (api.Handler, error) {
+	ret := _m.Called(catchAll)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateCatchAll")
+	}
+
+	var r0 api.Handler
+	var r1 error
+	if rf, ok := ret.Get(0).(func(dto.CatchAll) (api.Handler, error)); ok {
+		return rf(catchAll)
+	}
+	if rf, ok := ret.Get(0).(func(dto.CatchAll) api.Handler); ok {
+		r0 = rf(catchAll)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(api.Handler)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(dto.CatchAll) error); ok {
+		r1 = rf(catchAll)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateEndpoint provides a mock function with given fields: endpoint, baseDir
 func (_m *FactoryMock) CreateEndpoint(endpoint dto.Endpoint, baseDir string) (api.Handler, error) {
 	ret := _m.Called(endpoint, baseDir)
@@ -44,9 +75,67 @@ func (_m *FactoryMock) CreateEndpoint(endpoint dto.Endpoint, baseDir string) (ap
 	return r0, r1
 }
 
-// CreateProxyEndpoint provides a mock function with given fields: endpoint
-func (_m *FactoryMock) CreateProxyEndpoint(endpoint dto.Endpoint) (api.Handler, error) {
-	ret := _m.Called(endpoint)
+// CreateGRPCService provides a mock function with given fields: service, baseDir
+func (_m *FactoryMock) CreateGRPCService(service dto.GRPCService, baseDir string) (grpcmock.Service, error) {
+	ret := _m.Called(service, baseDir)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateGRPCService")
+	}
+
+	var r0 grpcmock.Service
+	var r1 error
+	if rf, ok := ret.Get(0).(func(dto.GRPCService, string) (grpcmock.Service, error)); ok {
+		return rf(service, baseDir)
+	}
+	if rf, ok := ret.Get(0).(func(dto.GRPCService, string) grpcmock.Service); ok {
+		r0 = rf(service, baseDir)
+	} else {
+		r0 = ret.Get(0).(grpcmock.Service)
+	}
+
+	if rf, ok := ret.Get(1).(func(dto.GRPCService, string) error); ok {
+		r1 = rf(service, baseDir)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateGraphQLEndpoint provides a mock function with given fields: endpoint, baseDir
+func (_m *FactoryMock) CreateGraphQLEndpoint(endpoint dto.Endpoint, baseDir string) (api.Handler, error) {
+	ret := _m.Called(endpoint, baseDir)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateGraphQLEndpoint")
+	}
+
+	var r0 api.Handler
+	var r1 error
+	if rf, ok := ret.Get(0).(func(dto.Endpoint, string) (api.Handler, error)); ok {
+		return rf(endpoint, baseDir)
+	}
+	if rf, ok := ret.Get(0).(func(dto.Endpoint, string) api.Handler); ok {
+		r0 = rf(endpoint, baseDir)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(api.Handler)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(dto.Endpoint, string) error); ok {
+		r1 = rf(endpoint, baseDir)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateProxyEndpoint provides a mock function with given fields: endpoint, baseDir
+func (_m *FactoryMock) CreateProxyEndpoint(endpoint dto.Endpoint, baseDir string) (api.Handler, error) {
+	ret := _m.Called(endpoint, baseDir)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateProxyEndpoint")
@@ -54,19 +143,19 @@ func (_m *FactoryMock) CreateProxyEndpoint(endpoint dto.Endpoint) (api.Handler,
 
 	var r0 api.Handler
 	var r1 error
-	if rf, ok := ret.Get(0).(func(dto.Endpoint) (api.Handler, error)); ok {
-		return rf(endpoint)
+	if rf, ok := ret.Get(0).(func(dto.Endpoint, string) (api.Handler, error)); ok {
+		return rf(endpoint, baseDir)
 	}
-	if rf, ok := ret.Get(0).(func(dto.Endpoint) api.Handler); ok {
-		r0 = rf(endpoint)
+	if rf, ok := ret.Get(0).(func(dto.Endpoint, string) api.Handler); ok {
+		r0 = rf(endpoint, baseDir)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(api.Handler)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(dto.Endpoint) error); ok {
-		r1 = rf(endpoint)
+	if rf, ok := ret.Get(1).(func(dto.Endpoint, string) error); ok {
+		r1 = rf(endpoint, baseDir)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -104,6 +193,81 @@ func (_m *FactoryMock) CreateResponseEndpoint(endpoint dto.Endpoint, baseDir str
 	return r0, r1
 }
 
+// CreateWebSocketEndpoint provides a mock function with given fields: endpoint
+func (_m *FactoryMock) CreateWebSocketEndpoint(endpoint dto.Endpoint) (api.Handler, error) {
+	ret := _m.Called(endpoint)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWebSocketEndpoint")
+	}
+
+	var r0 api.Handler
+	var r1 error
+	if rf, ok := ret.Get(0).(func(dto.Endpoint) (api.Handler, error)); ok {
+		return rf(endpoint)
+	}
+	if rf, ok := ret.Get(0).(func(dto.Endpoint) api.Handler); ok {
+		r0 = rf(endpoint)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(api.Handler)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(dto.Endpoint) error); ok {
+		r1 = rf(endpoint)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Scenarios provides a mock function with given fields:
+func (_m *FactoryMock) Scenarios() api.Scenarios {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Scenarios")
+	}
+
+	var r0 api.Scenarios
+	if rf, ok := ret.Get(0).(func() api.Scenarios); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(api.Scenarios)
+		}
+	}
+
+	return r0
+}
+
+// Sequences provides a mock function with given fields:
+func (_m *FactoryMock) Sequences() api.Sequences {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Sequences")
+	}
+
+	var r0 api.Sequences
+	if rf, ok := ret.Get(0).(func() api.Sequences); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(api.Sequences)
+		}
+	}
+
+	return r0
+}
+
+// SetDefinitions provides a mock function with given fields: definitions
+func (_m *FactoryMock) SetDefinitions(definitions map[string]dto.Params) {
+	_m.Called(definitions)
+}
+
 // NewFactoryMock creates a new instance of FactoryMock. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewFactoryMock(t interface {
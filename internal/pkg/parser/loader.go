@@ -5,8 +5,11 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/grpcmock"
 	"github.com/vimek-go/server-faker/internal/pkg/logger"
 	"github.com/vimek-go/server-faker/internal/pkg/parser/dto"
 	"github.com/vimek-go/server-faker/internal/pkg/tools"
@@ -16,83 +19,363 @@ import (
 	"github.com/pkg/errors"
 )
 
-var ErrValidation = errors.New("validation failed")
+var (
+	ErrValidation         = errors.New("validation failed")
+	ErrIncludeNotFound    = errors.New("included file not found")
+	ErrDuplicatedEndpoint = errors.New("duplicated endpoint")
+	ErrDuplicatedRef      = errors.New("duplicated definition")
+	ErrDuplicatedListener = errors.New("duplicated listener")
+	ErrUnknownListener    = errors.New("unknown listener")
+	ErrDuplicatedCatchAll = errors.New("duplicated catch all proxy")
+	ErrReservedURL        = errors.New("url reserved for the admin api")
+)
+
+const (
+	configExtension = ".json"
+	// DefaultListener is started with the command flags, it serves the endpoints without listeners
+	DefaultListener = "default"
+	anyHost         = "*"
+)
 
 type loader struct {
 	validator *validator.Validate
 	factory   Factory
+	profile   string
+	lookupEnv func(string) (string, bool)
 	logger    logger.Logger
 }
 
 type Loader interface {
-	LoadConfig(filePath string) ([]api.Handler, error)
+	LoadConfig(filePath string) (*Config, error)
+	LoadDir(dirPath string) (*Config, error)
+}
+
+// Config is everything the servers are started with
+type Config struct {
+	// Handlers are served by the default listener
+	Handlers []api.Handler
+	// Services are served by the grpc server, when there are any
+	Services []grpcmock.Service
+	// Listeners declared in the config, the tls files are resolved relative to the config
+	Listeners []Listener
+	// CatchAll serves the routes which are not mocked on all the listeners, nil responds with not found
+	CatchAll api.Handler
+}
+
+// Listener is served on its own port with the handlers assigned to it
+type Listener struct {
+	dto.Listener
+	Handlers []api.Handler
+}
+
+// configFile keeps the directory of the file the endpoints come from
+// files referenced by the endpoints are relative to it
+type configFile struct {
+	path      string
+	baseDir   string
+	endpoints dto.Endpoints
+}
+
+type loadState struct {
+	visited      map[string]bool
+	profileFound bool
+}
+
+func NewLoader(factory Factory, profile string, logger logger.Logger) Loader {
+	return &loader{
+		validator: validator.New(validator.WithRequiredStructEnabled()),
+		factory:   factory,
+		profile:   profile,
+		lookupEnv: os.LookupEnv,
+		logger:    logger,
+	}
 }
 
-func NewLoader(factory Factory, logger logger.Logger) Loader {
-	return &loader{validator: validator.New(validator.WithRequiredStructEnabled()), factory: factory, logger: logger}
+func (l *loader) LoadConfig(filePath string) (*Config, error) {
+	state := &loadState{visited: make(map[string]bool)}
+	files, err := l.readConfig(filePath, nil, state)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.checkProfile(state); err != nil {
+		return nil, err
+	}
+	return l.processConfigs(files)
+}
+
+func (l *loader) LoadDir(dirPath string) (*Config, error) {
+	paths, err := filepath.Glob(filepath.Join(dirPath, "*"+configExtension))
+	if err != nil {
+		return nil, tools.LogAndReturnError(l.logger, err, "unable to list directory %s", dirPath)
+	}
+	if len(paths) == 0 {
+		return nil, tools.LogAndReturnError(l.logger, ErrIncludeNotFound, "no config files in directory %s", dirPath)
+	}
+	var files []configFile
+	state := &loadState{visited: make(map[string]bool)}
+	for _, path := range paths {
+		loaded, err := l.readConfig(path, nil, state)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, loaded...)
+	}
+	if err := l.checkProfile(state); err != nil {
+		return nil, err
+	}
+	return l.processConfigs(files)
+}
+
+func (l *loader) checkProfile(state *loadState) error {
+	if len(l.profile) > 0 && !state.profileFound {
+		return tools.LogAndReturnError(l.logger, ErrUnknownProfile, "profile %s is not declared", l.profile)
+	}
+	return nil
 }
 
-func (l *loader) LoadConfig(filePath string) ([]api.Handler, error) {
-	configFile, err := os.Open(filePath)
+// readConfig reads the file and all the files it includes
+// visited files are skipped, so every file is loaded once
+func (l *loader) readConfig(
+	filePath string,
+	inherited map[string]string,
+	state *loadState,
+) ([]configFile, error) {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, tools.LogAndReturnError(l.logger, err, "unable to resolve path %s", filePath)
+	}
+	if state.visited[absPath] {
+		l.logger.Debugf("file %s already loaded", filePath)
+		return nil, nil
+	}
+	state.visited[absPath] = true
+
+	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, tools.LogAndReturnError(l.logger, err, "unable to open file %s", filePath)
 	}
-	defer configFile.Close()
-	byteValue, err := io.ReadAll(configFile)
+	defer file.Close()
+	byteValue, err := io.ReadAll(file)
 	if err != nil {
 		return nil, tools.LogAndReturnError(l.logger, err, "unable to read file %s", filePath)
 	}
-	var endpoints dto.Endpoints
+	var environment dto.Environment
+	err = json.Unmarshal(byteValue, &environment)
+	if err != nil {
+		return nil, tools.LogAndReturnError(l.logger, err, "unable to unmarshal file %s", filePath)
+	}
+	variables := l.prepareVariables(environment, inherited, state)
+	byteValue, err = l.substituteVariables(byteValue, variables)
+	if err != nil {
+		return nil, tools.LogAndReturnError(l.logger, err, "unable to substitute variables in file %s", filePath)
+	}
 
+	var endpoints dto.Endpoints
 	err = json.Unmarshal(byteValue, &endpoints)
 	if err != nil {
-		return nil, tools.LogAndReturnError(l.logger, err, "unable to unmarshal")
+		return nil, tools.LogAndReturnError(l.logger, err, "unable to unmarshal file %s", filePath)
 	}
 
 	baseDir := filepath.Dir(filePath)
-	rval, err := l.processConfig(baseDir, endpoints)
-	if err != nil {
-		return nil, err
+	rval := []configFile{{path: filePath, baseDir: baseDir, endpoints: endpoints}}
+	for _, include := range endpoints.Include {
+		pattern := include
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(baseDir, pattern)
+		}
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, tools.LogAndReturnError(l.logger, err, "invalid include %s in file %s", include, filePath)
+		}
+		if len(paths) == 0 {
+			return nil, tools.LogAndReturnError(
+				l.logger,
+				ErrIncludeNotFound,
+				"include %s in file %s",
+				include,
+				filePath,
+			)
+		}
+		for _, path := range paths {
+			included, err := l.readConfig(path, variables, state)
+			if err != nil {
+				return nil, err
+			}
+			rval = append(rval, included...)
+		}
 	}
-	l.logger.Infof("prepared endpoints count %d", len(rval))
 	return rval, nil
 }
 
-func (l *loader) processConfig(baseDir string, endpoints dto.Endpoints) ([]api.Handler, error) {
+func (l *loader) processConfigs(files []configFile) (*Config, error) {
 	// loop once to validate all the endpoints format
-	for i := range endpoints.Endpoints {
-		errs := l.validator.Struct(&endpoints.Endpoints[i])
-		if errs != nil {
-			l.logger.Errorf("validation errors for url %s", endpoints.Endpoints[i].URL)
-			var invalidValidationError *validator.InvalidValidationError
-			if errors.As(errs, &invalidValidationError) {
-				l.logger.Errorf("invalid validation error")
-				return nil, ErrValidation
-			}
+	for _, file := range files {
+		if err := l.validateEndpoints(file.endpoints); err != nil {
+			return nil, err
+		}
+	}
 
-			var validationErrors validator.ValidationErrors
-			if errors.As(errs, &validationErrors) {
-				for _, err := range validationErrors {
-					l.logger.Errorf(
-						"[Validation error]: Key: '%v': failed validation for '%v' on the '%v' tag",
-						err.Field(),
-						err.Value(),
-						err.ActualTag(),
-					)
+	var mergeErrors *multierror.Error
+	definitions := make(map[string]dto.Params)
+	definitionFiles := make(map[string]string)
+	endpointFiles := make(map[string]string)
+	// scenarioGroups are the groups of the routes served by the scenario endpoints
+	scenarioGroups := make(map[string]string)
+	listeners, listenerIndexes, err := l.mergeListeners(files)
+	if err != nil {
+		mergeErrors = multierror.Append(mergeErrors, err)
+	}
+	var catchAll *dto.CatchAll
+	var catchAllFile string
+	for _, file := range files {
+		if file.endpoints.CatchAll != nil {
+			if catchAll != nil {
+				mergeErrors = multierror.Append(mergeErrors, errors.Wrapf(
+					ErrDuplicatedCatchAll,
+					"catch all in file %s already defined in file %s",
+					file.path,
+					catchAllFile,
+				))
+			}
+			catchAll, catchAllFile = file.endpoints.CatchAll, file.path
+		}
+		for name, definition := range file.endpoints.Definitions {
+			if previous, ok := definitionFiles[name]; ok {
+				mergeErrors = multierror.Append(mergeErrors, errors.Wrapf(
+					ErrDuplicatedRef,
+					"definition %s in file %s already defined in file %s",
+					name,
+					file.path,
+					previous,
+				))
+				continue
+			}
+			definitionFiles[name] = file.path
+			definitions[name] = definition
+		}
+		for _, e := range file.endpoints.Endpoints {
+			if isAdminURL(e.URL) {
+				mergeErrors = multierror.Append(mergeErrors, errors.Wrapf(
+					ErrReservedURL,
+					"endpoint %s %s in file %s, the urls starting with %s are reserved",
+					e.Method,
+					e.URL,
+					file.path,
+					api.AdminPrefix,
+				))
+				continue
+			}
+			for _, listener := range endpointListeners(e) {
+				if _, ok := listenerIndexes[listener]; !ok && listener != DefaultListener {
+					mergeErrors = multierror.Append(mergeErrors, errors.Wrapf(
+						ErrUnknownListener,
+						"endpoint %s %s in file %s, listener %s",
+						e.Method,
+						e.URL,
+						file.path,
+						listener,
+					))
+					continue
 				}
+				for _, host := range endpointHosts(e) {
+					key := e.Method + " " + e.URL
+					if listener != DefaultListener || host != anyHost {
+						key += " (listener " + listener + ", host " + host + ")"
+					}
+					if previous, ok := endpointFiles[key]; ok {
+						if e.Scenario != nil && scenarioGroups[key] == scenarioGroup(e) {
+							continue
+						}
+						mergeErrors = multierror.Append(mergeErrors, errors.Wrapf(
+							ErrDuplicatedEndpoint,
+							"endpoint %s in file %s already defined in file %s",
+							key,
+							file.path,
+							previous,
+						))
+						continue
+					}
+					endpointFiles[key] = file.path
+					if e.Scenario != nil {
+						scenarioGroups[key] = scenarioGroup(e)
+					}
+				}
+			}
+		}
+		for _, service := range file.endpoints.GRPC {
+			key := "grpc " + service.Service
+			if previous, ok := endpointFiles[key]; ok {
+				mergeErrors = multierror.Append(mergeErrors, errors.Wrapf(
+					ErrDuplicatedEndpoint,
+					"service %s in file %s already defined in file %s",
+					service.Service,
+					file.path,
+					previous,
+				))
+				continue
 			}
-			return nil, ErrValidation
+			endpointFiles[key] = file.path
 		}
 	}
+	if mergeErrors != nil {
+		for _, err := range mergeErrors.Errors {
+			l.logger.Error(err)
+		}
+		return nil, mergeErrors
+	}
+	if err := l.validateDefinitions(definitions); err != nil {
+		return nil, err
+	}
+
+	l.factory.SetDefinitions(definitions)
 
 	var fileErrors *multierror.Error
-	rval := make([]api.Handler, len(endpoints.Endpoints))
-	for i, e := range endpoints.Endpoints {
-		l.logger.Infof("processing endpoint %s %s", e.Method, e.URL)
-		if apiHandler, err := l.factory.CreateEndpoint(e, baseDir); err != nil {
+	rval := &Config{Listeners: listeners}
+	if catchAll != nil {
+		if rval.CatchAll, err = l.factory.CreateCatchAll(*catchAll); err != nil {
 			fileErrors = multierror.Append(fileErrors, err)
-		} else {
-			rval[i] = apiHandler
+		}
+	}
+	scenarioRoutes := make(map[string]api.ScenarioRoute)
+	hasSequences := false
+	for _, file := range files {
+		for _, e := range file.endpoints.Endpoints {
+			l.logger.Infof("processing endpoint %s %s", e.Method, e.URL)
+			hasSequences = hasSequences || e.Sequence != nil
+			apiHandler, err := l.factory.CreateEndpoint(e, file.baseDir)
+			if err != nil {
+				fileErrors = multierror.Append(fileErrors, err)
+				continue
+			}
+			if scenarioHandler, ok := apiHandler.(api.ScenarioHandler); ok {
+				// the endpoints of the same route are served by one handler in the state of their scenarios
+				group := scenarioGroup(e)
+				if route, ok := scenarioRoutes[group]; ok {
+					route.Add(scenarioHandler)
+					continue
+				}
+				scenarioRoutes[group] = api.NewScenarioRoute(scenarioHandler)
+				apiHandler = scenarioRoutes[group]
+			}
+			if len(e.Hosts) > 0 {
+				apiHandler = api.NewHostHandler(apiHandler, e.Hosts)
+			}
+			for _, listener := range endpointListeners(e) {
+				if listener == DefaultListener {
+					rval.Handlers = append(rval.Handlers, apiHandler)
+					continue
+				}
+				index := listenerIndexes[listener]
+				rval.Listeners[index].Handlers = append(rval.Listeners[index].Handlers, apiHandler)
+			}
+		}
+		for _, service := range file.endpoints.GRPC {
+			l.logger.Infof("processing grpc service %s", service.Service)
+			if grpcService, err := l.factory.CreateGRPCService(service, file.baseDir); err != nil {
+				fileErrors = multierror.Append(fileErrors, err)
+			} else {
+				rval.Services = append(rval.Services, grpcService)
+			}
 		}
 	}
 	if fileErrors != nil {
@@ -101,6 +384,150 @@ func (l *loader) processConfig(baseDir string, endpoints dto.Endpoints) ([]api.H
 		}
 		return nil, fileErrors
 	}
-	l.logger.Infof("parsed endpoins count %d", len(rval))
+	if len(scenarioRoutes) > 0 {
+		rval.Handlers = append(rval.Handlers, api.NewScenarioAdmin(l.factory.Scenarios(), l.logger)...)
+	}
+	if hasSequences {
+		rval.Handlers = append(rval.Handlers, api.NewSequenceAdmin(l.factory.Sequences(), l.logger)...)
+	}
+	l.logger.Infof(
+		"prepared endpoints count %d, grpc services count %d, listeners count %d",
+		len(rval.Handlers),
+		len(rval.Services),
+		len(rval.Listeners),
+	)
 	return rval, nil
 }
+
+// isAdminURL reports whether the url is served by the admin api, it is reserved on all the listeners
+func isAdminURL(url string) bool {
+	return url == api.AdminPrefix || strings.HasPrefix(url, api.AdminPrefix+"/")
+}
+
+// mergeListeners collects the listeners declared in all the files, the names have to be unique
+func (l *loader) mergeListeners(files []configFile) ([]Listener, map[string]int, error) {
+	var rval []Listener
+	indexes := make(map[string]int)
+	listenerFiles := make(map[string]string)
+	var mergeErrors *multierror.Error
+	for _, file := range files {
+		for _, listener := range file.endpoints.Listeners {
+			if previous, ok := listenerFiles[listener.Name]; ok {
+				mergeErrors = multierror.Append(mergeErrors, errors.Wrapf(
+					ErrDuplicatedListener,
+					"listener %s in file %s already defined in file %s",
+					listener.Name,
+					file.path,
+					previous,
+				))
+				continue
+			}
+			listenerFiles[listener.Name] = file.path
+			if listener.TLS != nil {
+				listener.TLS = resolveTLSFiles(*listener.TLS, file.baseDir)
+			}
+			indexes[listener.Name] = len(rval)
+			rval = append(rval, Listener{Listener: listener})
+		}
+	}
+	return rval, indexes, mergeErrors.ErrorOrNil()
+}
+
+func resolveTLSFiles(listenerTLS dto.ListenerTLS, baseDir string) *dto.ListenerTLS {
+	for _, path := range []*string{&listenerTLS.Cert, &listenerTLS.Key, &listenerTLS.ClientCA, &listenerTLS.Dir} {
+		if len(*path) > 0 && !filepath.IsAbs(*path) {
+			*path = filepath.Join(baseDir, *path)
+		}
+	}
+	return &listenerTLS
+}
+
+func endpointListeners(e dto.Endpoint) []string {
+	if len(e.Listeners) == 0 {
+		return []string{DefaultListener}
+	}
+	return e.Listeners
+}
+
+func endpointHosts(e dto.Endpoint) []string {
+	if len(e.Hosts) == 0 {
+		return []string{anyHost}
+	}
+	return e.Hosts
+}
+
+// scenarioGroup is the route of the scenario endpoint with its listeners and hosts,
+// the scenario endpoints of the same group share the route
+func scenarioGroup(e dto.Endpoint) string {
+	listeners := slices.Clone(endpointListeners(e))
+	hosts := slices.Clone(endpointHosts(e))
+	slices.Sort(listeners)
+	slices.Sort(hosts)
+	return e.Method + " " + e.URL + " " + strings.Join(listeners, ",") + " " + strings.Join(hosts, ",")
+}
+
+func (l *loader) validateEndpoints(endpoints dto.Endpoints) error {
+	for i := range endpoints.Endpoints {
+		if err := l.validateStruct(&endpoints.Endpoints[i], "url "+endpoints.Endpoints[i].URL); err != nil {
+			return err
+		}
+	}
+	for i := range endpoints.GRPC {
+		if err := l.validateStruct(&endpoints.GRPC[i], "grpc service "+endpoints.GRPC[i].Service); err != nil {
+			return err
+		}
+	}
+	for i := range endpoints.Listeners {
+		if err := l.validateStruct(&endpoints.Listeners[i], "listener "+endpoints.Listeners[i].Name); err != nil {
+			return err
+		}
+	}
+	if endpoints.CatchAll != nil {
+		return l.validateStruct(endpoints.CatchAll, "catch all")
+	}
+	return nil
+}
+
+// validateDefinitions checks the params of the merged definitions, sorted by name to report the same one every run
+func (l *loader) validateDefinitions(definitions map[string]dto.Params) error {
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		definition := definitions[name]
+		for i := range definition {
+			if err := l.validateStruct(&definition[i], "definition "+name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (l *loader) validateStruct(value any, name string) error {
+	errs := l.validator.Struct(value)
+	if errs == nil {
+		return nil
+	}
+	l.logger.Errorf("validation errors for %s", name)
+	var invalidValidationError *validator.InvalidValidationError
+	if errors.As(errs, &invalidValidationError) {
+		l.logger.Errorf("invalid validation error")
+		return ErrValidation
+	}
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(errs, &validationErrors) {
+		for _, err := range validationErrors {
+			l.logger.Errorf(
+				"[Validation error]: Key: '%v': failed validation for '%v' on the '%v' tag",
+				err.Field(),
+				err.Value(),
+				err.ActualTag(),
+			)
+		}
+	}
+	return ErrValidation
+}
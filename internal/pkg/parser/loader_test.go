@@ -1,13 +1,17 @@
 package parser_test
 
 import (
+	"os"
 	"path"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/grpcmock"
 	"github.com/vimek-go/server-faker/internal/pkg/logger"
 	"github.com/vimek-go/server-faker/internal/pkg/parser"
+	"github.com/vimek-go/server-faker/internal/pkg/parser/dto"
 	"github.com/vimek-go/server-faker/internal/pkg/parser/internal/mocks"
 	"github.com/vimek-go/server-faker/internal/pkg/tools"
 
@@ -60,6 +64,7 @@ func TestLoader_LoadConfig(t *testing.T) {
 			name: "no validation errors, handler creation error",
 			factory: func(dir string) *mocks.FactoryMock {
 				factory := mocks.NewFactoryMock(t)
+				factory.On("SetDefinitions", mock.Anything).Return()
 				factory.On("CreateEndpoint", mock.AnythingOfType("dto.Endpoint"), dir).Return(nil, parser.ErrNotHandled)
 				return factory
 			},
@@ -70,6 +75,7 @@ func TestLoader_LoadConfig(t *testing.T) {
 			name: "no validation errors, handler creation success",
 			factory: func(dir string) *mocks.FactoryMock {
 				factory := mocks.NewFactoryMock(t)
+				factory.On("SetDefinitions", mock.Anything).Return()
 				factory.On("CreateEndpoint", mock.AnythingOfType("dto.Endpoint"), dir).Return(&mocks.HandlerMock{}, nil)
 				return factory
 			},
@@ -83,15 +89,795 @@ func TestLoader_LoadConfig(t *testing.T) {
 			t.Parallel()
 			file := path.Join(dir, strings.ReplaceAll(tc.name, " ", "_")+".json")
 			tools.SaveToAFile(t, tc.jsonConfig, file)
-			loader := parser.NewLoader(tc.factory(dir), logger.NewTestLogger())
-			handlers, err := loader.LoadConfig(file)
+			loader := parser.NewLoader(tc.factory(dir), "", logger.NewTestLogger())
+			config, err := loader.LoadConfig(file)
 			if tc.expectedError != nil {
 				require.Error(t, err)
 				require.ErrorIs(t, err, tc.expectedError)
 			} else {
 				require.NoError(t, err)
-				require.NotNil(t, handlers)
+				require.NotNil(t, config.Handlers)
 			}
 		})
 	}
 }
+
+func TestLoader_LoadConfigWithIncludes(t *testing.T) {
+	t.Parallel()
+	endpointJSON := func(url string, include ...string) string {
+		includes := ""
+		if len(include) > 0 {
+			includes = `"include": ["` + strings.Join(include, `","`) + `"],`
+		}
+		return `{` + includes + `"endpoints": [{"url": "` + url + `", "method": "GET", "response": {
+			"status": 200, "type": "static", "file": "test.json", "format": "json"}}]}`
+	}
+	testCases := []struct {
+		name          string
+		files         map[string]string
+		load          func(parser.Loader, string) (*parser.Config, error)
+		expectedCount int
+		expectedError error
+	}{
+		{
+			name: "include with glob pattern",
+			files: map[string]string{
+				"main.json":           endpointJSON("/main", "services/*.json"),
+				"services/users.json": endpointJSON("/users"),
+				"services/auth.json":  endpointJSON("/auth"),
+			},
+			load: func(l parser.Loader, dir string) (*parser.Config, error) {
+				return l.LoadConfig(path.Join(dir, "main.json"))
+			},
+			expectedCount: 3,
+		},
+		{
+			name: "include cycle loads every file once",
+			files: map[string]string{
+				"main.json":  endpointJSON("/main", "other.json"),
+				"other.json": endpointJSON("/other", "main.json"),
+			},
+			load: func(l parser.Loader, dir string) (*parser.Config, error) {
+				return l.LoadConfig(path.Join(dir, "main.json"))
+			},
+			expectedCount: 2,
+		},
+		{
+			name: "missing include",
+			files: map[string]string{
+				"main.json": endpointJSON("/main", "missing.json"),
+			},
+			load: func(l parser.Loader, dir string) (*parser.Config, error) {
+				return l.LoadConfig(path.Join(dir, "main.json"))
+			},
+			expectedError: parser.ErrIncludeNotFound,
+		},
+		{
+			name: "duplicated endpoint in included file",
+			files: map[string]string{
+				"main.json":  endpointJSON("/main", "other.json"),
+				"other.json": endpointJSON("/main"),
+			},
+			load: func(l parser.Loader, dir string) (*parser.Config, error) {
+				return l.LoadConfig(path.Join(dir, "main.json"))
+			},
+			expectedError: parser.ErrDuplicatedEndpoint,
+		},
+		{
+			name: "load directory",
+			files: map[string]string{
+				"users.json":         endpointJSON("/users", "shared/*.json"),
+				"auth.json":          endpointJSON("/auth"),
+				"shared/common.json": endpointJSON("/common"),
+			},
+			load: func(l parser.Loader, dir string) (*parser.Config, error) {
+				return l.LoadDir(dir)
+			},
+			expectedCount: 3,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			require.NoError(t, os.MkdirAll(path.Join(dir, "services"), 0o755))
+			require.NoError(t, os.MkdirAll(path.Join(dir, "shared"), 0o755))
+			for name, content := range tc.files {
+				tools.SaveToAFile(t, content, path.Join(dir, name))
+			}
+			factory := mocks.NewFactoryMock(t)
+			if tc.expectedError == nil {
+				factory.On("SetDefinitions", mock.Anything).Return()
+				factory.On("CreateEndpoint", mock.AnythingOfType("dto.Endpoint"), mock.AnythingOfType("string")).
+					Return(&mocks.HandlerMock{}, nil)
+			}
+			config, err := tc.load(parser.NewLoader(factory, "", logger.NewTestLogger()), dir)
+			if tc.expectedError != nil {
+				require.Error(t, err)
+				require.ErrorIs(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, config.Handlers, tc.expectedCount)
+			}
+		})
+	}
+}
+
+func TestLoader_LoadConfigWithGRPC(t *testing.T) {
+	t.Parallel()
+	serviceJSON := `{"file": "user.proto", "service": "user.v1.UserService", "methods": [
+		{"name": "GetUser", "type": "static", "static": {"name": "john"}}]}`
+	testCases := []struct {
+		name          string
+		files         map[string]string
+		expectedCount int
+		expectedError error
+	}{
+		{
+			name: "grpc services from included file",
+			files: map[string]string{
+				"main.json": `{"include": ["grpc.json"], "endpoints": []}`,
+				"grpc.json": `{"endpoints": [], "grpc": [` + serviceJSON + `]}`,
+			},
+			expectedCount: 1,
+		},
+		{
+			name: "duplicated grpc service",
+			files: map[string]string{
+				"main.json": `{"include": ["grpc.json"], "endpoints": [], "grpc": [` + serviceJSON + `]}`,
+				"grpc.json": `{"endpoints": [], "grpc": [` + serviceJSON + `]}`,
+			},
+			expectedError: parser.ErrDuplicatedEndpoint,
+		},
+		{
+			name: "method without response",
+			files: map[string]string{
+				"main.json": `{"endpoints": [], "grpc": [{"file": "user.proto", "service": "user.v1.UserService",
+					"methods": [{"name": "GetUser"}]}]}`,
+			},
+			expectedError: parser.ErrValidation,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			for name, content := range tc.files {
+				tools.SaveToAFile(t, content, path.Join(dir, name))
+			}
+			factory := mocks.NewFactoryMock(t)
+			if tc.expectedError == nil {
+				factory.On("SetDefinitions", mock.Anything).Return()
+				factory.On("CreateGRPCService", mock.AnythingOfType("dto.GRPCService"), dir).
+					Return(grpcmock.Service{}, nil)
+			}
+			config, err := parser.NewLoader(factory, "", logger.NewTestLogger()).LoadConfig(path.Join(dir, "main.json"))
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Empty(t, config.Handlers)
+			require.Len(t, config.Services, tc.expectedCount)
+		})
+	}
+}
+
+func TestLoader_LoadConfigWithVariables(t *testing.T) {
+	t.Parallel()
+	testJSON := `
+	{
+		"variables": {"HOST": "localhost", "NAME": "base"},
+		"profiles": {"ci": {"HOST": "ci-host"}},
+		"endpoints": [
+			{
+				"url": "/${NAME}/${MISSING:-default}/$${literal}",
+				"method": "GET",
+				"proxy": {
+					"type": "static",
+					"method": "GET",
+					"url": "http://${HOST}:8080",
+					"headers": {"Authorization": "Bearer ${TOKEN:-}"}
+				}
+			}
+		]
+	}`
+	testCases := []struct {
+		name          string
+		profile       string
+		jsonConfig    string
+		expectedURL   string
+		expectedProxy string
+		expectedError error
+	}{
+		{
+			name:          "variables and defaults",
+			jsonConfig:    testJSON,
+			expectedURL:   "/base/default/${literal}",
+			expectedProxy: "http://localhost:8080",
+		},
+		{
+			name:          "profile overlays variables",
+			profile:       "ci",
+			jsonConfig:    testJSON,
+			expectedURL:   "/base/default/${literal}",
+			expectedProxy: "http://ci-host:8080",
+		},
+		{
+			name:          "unknown profile",
+			profile:       "unknown",
+			jsonConfig:    testJSON,
+			expectedError: parser.ErrUnknownProfile,
+		},
+		{
+			name: "unresolved variable",
+			jsonConfig: `
+			{
+				"endpoints": [
+					{
+						"url": "/test",
+						"method": "GET",
+						"proxy": {"type": "static", "method": "GET", "url": "${UNRESOLVED_PROXY_URL}"}
+					}
+				]
+			}`,
+			expectedError: parser.ErrUnresolvedVariable,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			file := path.Join(t.TempDir(), "config.json")
+			tools.SaveToAFile(t, tc.jsonConfig, file)
+			factory := mocks.NewFactoryMock(t)
+			if tc.expectedError == nil {
+				factory.On("SetDefinitions", mock.Anything).Return()
+				factory.On("CreateEndpoint", mock.MatchedBy(func(e dto.Endpoint) bool {
+					return e.URL == tc.expectedURL && len(e.Proxy.URL) == 1 && e.Proxy.URL[0].URL == tc.expectedProxy &&
+						e.Proxy.Headers["Authorization"] == "Bearer "
+				}), mock.AnythingOfType("string")).Return(&mocks.HandlerMock{}, nil)
+			}
+			_, err := parser.NewLoader(factory, tc.profile, logger.NewTestLogger()).LoadConfig(file)
+			if tc.expectedError != nil {
+				require.Error(t, err)
+				require.ErrorIs(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+//nolint:paralleltest // environment variables cannot be set in parallel tests
+func TestLoader_LoadConfigWithEnvironment(t *testing.T) {
+	t.Setenv("FAKER_TEST_HOST", "env-host")
+	testCases := []struct {
+		name          string
+		profile       string
+		jsonConfig    string
+		expectedProxy string
+	}{
+		{
+			name: "environment as fallback",
+			jsonConfig: `
+			{
+				"endpoints": [
+					{
+						"url": "/test",
+						"method": "GET",
+						"proxy": {"type": "static", "method": "GET", "url": "http://${FAKER_TEST_HOST}"}
+					}
+				]
+			}`,
+			expectedProxy: "http://env-host",
+		},
+		{
+			name: "variables over environment",
+			jsonConfig: `
+			{
+				"variables": {"FAKER_TEST_HOST": "localhost"},
+				"endpoints": [
+					{
+						"url": "/test",
+						"method": "GET",
+						"proxy": {"type": "static", "method": "GET", "url": "http://${FAKER_TEST_HOST}"}
+					}
+				]
+			}`,
+			expectedProxy: "http://localhost",
+		},
+		{
+			name:    "profile over environment",
+			profile: "ci",
+			jsonConfig: `
+			{
+				"profiles": {"ci": {"FAKER_TEST_HOST": "ci-host"}},
+				"endpoints": [
+					{
+						"url": "/test",
+						"method": "GET",
+						"proxy": {"type": "static", "method": "GET", "url": "http://${FAKER_TEST_HOST}"}
+					}
+				]
+			}`,
+			expectedProxy: "http://ci-host",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			file := path.Join(t.TempDir(), "config.json")
+			tools.SaveToAFile(t, tc.jsonConfig, file)
+			factory := mocks.NewFactoryMock(t)
+			factory.On("SetDefinitions", mock.Anything).Return()
+			factory.On("CreateEndpoint", mock.MatchedBy(func(e dto.Endpoint) bool {
+				return len(e.Proxy.URL) == 1 && e.Proxy.URL[0].URL == tc.expectedProxy
+			}), mock.AnythingOfType("string")).Return(&mocks.HandlerMock{}, nil)
+			_, err := parser.NewLoader(factory, tc.profile, logger.NewTestLogger()).LoadConfig(file)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestLoader_LoadConfigWithoutVariables(t *testing.T) {
+	t.Parallel()
+	file := path.Join(t.TempDir(), "config.json")
+	tools.SaveToAFile(t, `
+	{
+		"endpoints": [
+			{
+				"url": "/test",
+				"method": "GET",
+				"response": {"status": 200, "type": "static", "format": "json", "static": {"price": "$$5"}}
+			}
+		]
+	}`, file)
+	factory := mocks.NewFactoryMock(t)
+	factory.On("SetDefinitions", mock.Anything).Return()
+	factory.On("CreateEndpoint", mock.MatchedBy(func(e dto.Endpoint) bool {
+		static, ok := e.Response.Static.(map[string]any)
+		return ok && static["price"] == "$5"
+	}), mock.AnythingOfType("string")).Return(&mocks.HandlerMock{}, nil)
+	_, err := parser.NewLoader(factory, "", logger.NewTestLogger()).LoadConfig(file)
+	require.NoError(t, err)
+}
+
+func TestLoader_LoadConfigWithListeners(t *testing.T) {
+	t.Parallel()
+	const listeners = `"listeners": [
+		{"name": "auth", "port": 8081},
+		{"name": "secure", "port": 8443, "tls": {"cert": "certs/cert.pem", "key": "certs/key.pem"}}]`
+	endpoint := func(url, placement string) string {
+		return `{"url": "` + url + `", "method": "GET", ` + placement +
+			`"response": {"status": 200, "type": "static", "format": "json", "static": {}}}`
+	}
+	testCases := []struct {
+		name          string
+		content       string
+		expectedError error
+		asserts       func(t *testing.T, config *parser.Config, dir string)
+	}{
+		{
+			name: "endpoints assigned to listeners and hosts",
+			content: `{` + listeners + `, "endpoints": [` +
+				endpoint("/users", ``) + `,` +
+				endpoint("/users", `"hosts": ["api.example.test"],`) + `,` +
+				endpoint("/token", `"listeners": ["auth", "default"],`) + `,` +
+				endpoint("/token", `"listeners": ["secure"], "hosts": ["auth.example.test"],`) + `]}`,
+			asserts: func(t *testing.T, config *parser.Config, dir string) {
+				require.Len(t, config.Handlers, 3)
+				_, ok := config.Handlers[1].(api.HostHandler)
+				require.True(t, ok)
+				require.Len(t, config.Listeners, 2)
+				require.Equal(t, "auth", config.Listeners[0].Name)
+				require.Len(t, config.Listeners[0].Handlers, 1)
+				require.Len(t, config.Listeners[1].Handlers, 1)
+				require.Equal(t, path.Join(dir, "certs/cert.pem"), config.Listeners[1].TLS.Cert)
+			},
+		},
+		{
+			name:          "unknown listener",
+			content:       `{"endpoints": [` + endpoint("/users", `"listeners": ["auth"],`) + `]}`,
+			expectedError: parser.ErrUnknownListener,
+		},
+		{
+			name: "duplicated listener",
+			content: `{"listeners": [{"name": "auth", "port": 8081}, {"name": "auth", "port": 8082}],
+				"endpoints": []}`,
+			expectedError: parser.ErrDuplicatedListener,
+		},
+		{
+			name:          "reserved listener name",
+			content:       `{"listeners": [{"name": "default", "port": 8081}], "endpoints": []}`,
+			expectedError: parser.ErrValidation,
+		},
+		{
+			name: "duplicated endpoint of the host",
+			content: `{"endpoints": [` +
+				endpoint("/users", `"hosts": ["api.example.test"],`) + `,` +
+				endpoint("/users", `"hosts": ["api.example.test", "auth.example.test"],`) + `]}`,
+			expectedError: parser.ErrDuplicatedEndpoint,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			tools.SaveToAFile(t, tc.content, path.Join(dir, "main.json"))
+			factory := mocks.NewFactoryMock(t)
+			if tc.expectedError == nil {
+				factory.On("SetDefinitions", mock.Anything).Return()
+				factory.On("CreateEndpoint", mock.AnythingOfType("dto.Endpoint"), dir).
+					Return(&mocks.HandlerMock{}, nil)
+			}
+			config, err := parser.NewLoader(factory, "", logger.NewTestLogger()).LoadConfig(path.Join(dir, "main.json"))
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			tc.asserts(t, config, dir)
+		})
+	}
+}
+
+func TestLoader_LoadConfigWithFallbacks(t *testing.T) {
+	t.Parallel()
+	const response = `"response": {"status": 200, "type": "static", "format": "json", "static": {}}`
+	const proxy = `"proxy": {"url": "http://backend:8080/orders", "method": "GET", "type": "static"}`
+	testCases := []struct {
+		name          string
+		files         map[string]string
+		expectedError error
+		asserts       func(t *testing.T, config *parser.Config)
+	}{
+		{
+			name: "catch all and matched endpoint",
+			files: map[string]string{"main.json": `{
+				"include": ["orders.json"],
+				"catch_all": {"url": "http://backend:8080", "headers": {"X-Faker": "true"}},
+				"endpoints": []}`,
+				"orders.json": `{"endpoints": [{"url": "/orders", "method": "GET",
+					"match": {"query": {"mode": "fake"}}, ` + response + `, ` + proxy + `}]}`,
+			},
+			asserts: func(t *testing.T, config *parser.Config) {
+				require.NotNil(t, config.CatchAll)
+				require.Len(t, config.Handlers, 1)
+			},
+		},
+		{
+			name: "duplicated catch all",
+			files: map[string]string{
+				"main.json":  `{"include": ["other.json"], "catch_all": {"url": "http://backend"}, "endpoints": []}`,
+				"other.json": `{"catch_all": {"url": "http://other"}, "endpoints": []}`,
+			},
+			expectedError: parser.ErrDuplicatedCatchAll,
+		},
+		{
+			name:          "catch all without url",
+			files:         map[string]string{"main.json": `{"catch_all": {"url": "backend"}, "endpoints": []}`},
+			expectedError: parser.ErrValidation,
+		},
+		{
+			name: "response and proxy without match",
+			files: map[string]string{"main.json": `{"endpoints": [
+				{"url": "/orders", "method": "GET", ` + response + `, ` + proxy + `}]}`},
+			expectedError: parser.ErrValidation,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			for name, content := range tc.files {
+				tools.SaveToAFile(t, content, path.Join(dir, name))
+			}
+			factory := mocks.NewFactoryMock(t)
+			if tc.expectedError == nil {
+				factory.On("SetDefinitions", mock.Anything).Return()
+				factory.On("CreateEndpoint", mock.AnythingOfType("dto.Endpoint"), dir).
+					Return(&mocks.HandlerMock{}, nil)
+				catchAll := dto.CatchAll{URL: "http://backend:8080", Headers: map[string]string{"X-Faker": "true"}}
+				factory.On("CreateCatchAll", catchAll).Return(&mocks.HandlerMock{}, nil)
+			}
+			config, err := parser.NewLoader(factory, "", logger.NewTestLogger()).LoadConfig(path.Join(dir, "main.json"))
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			tc.asserts(t, config)
+		})
+	}
+}
+
+func TestLoader_LoadConfigWithUpstreams(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name              string
+		proxy             string
+		expectedError     error
+		expectedUpstreams dto.Upstreams
+	}{
+		{
+			name:              "single url",
+			proxy:             `"url": "http://orders:8080"`,
+			expectedUpstreams: dto.Upstreams{{URL: "http://orders:8080"}},
+		},
+		{
+			name: "weighted upstreams with mirror",
+			proxy: `"url": ["http://stable:8080", {"url": "http://canary:8080", "weight": 5}],
+				"balance": "weighted", "health": {"max_fails": 2, "cooldown": "10s"},
+				"mirror": {"url": "http://shadow:8080"}`,
+			expectedUpstreams: dto.Upstreams{{URL: "http://stable:8080"}, {URL: "http://canary:8080", Weight: 5}},
+		},
+		{
+			name:          "url which is not a string or a list",
+			proxy:         `"url": 8080`,
+			expectedError: dto.ErrInvalidUpstreams,
+		},
+		{
+			name:          "empty list of upstreams",
+			proxy:         `"url": []`,
+			expectedError: parser.ErrValidation,
+		},
+		{
+			name:          "negative weight",
+			proxy:         `"url": [{"url": "http://stable:8080", "weight": -1}]`,
+			expectedError: parser.ErrValidation,
+		},
+		{
+			name:          "unknown balance",
+			proxy:         `"url": ["http://a", "http://b"], "balance": "least_conn"`,
+			expectedError: parser.ErrValidation,
+		},
+		{
+			name:          "mirror without url",
+			proxy:         `"url": "http://orders:8080", "mirror": {"headers": {"X-Shadow": "true"}}`,
+			expectedError: parser.ErrValidation,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			file := path.Join(t.TempDir(), "config.json")
+			tools.SaveToAFile(t, `{"endpoints": [{"url": "/orders", "method": "GET",
+				"proxy": {"method": "GET", "type": "static", `+tc.proxy+`}}]}`, file)
+			factory := mocks.NewFactoryMock(t)
+			if tc.expectedError == nil {
+				factory.On("SetDefinitions", mock.Anything).Return()
+				factory.On("CreateEndpoint", mock.MatchedBy(func(e dto.Endpoint) bool {
+					return reflect.DeepEqual(e.Proxy.URL, tc.expectedUpstreams)
+				}), mock.AnythingOfType("string")).Return(&mocks.HandlerMock{}, nil)
+			}
+			_, err := parser.NewLoader(factory, "", logger.NewTestLogger()).LoadConfig(file)
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestLoader_LoadConfigWithScenarios(t *testing.T) {
+	t.Parallel()
+	endpoint := func(url, placement string) string {
+		return `{"url": "` + url + `", "method": "GET", ` + placement +
+			`"response": {"status": 200, "type": "static", "format": "json", "static": {}}}`
+	}
+	testCases := []struct {
+		name             string
+		content          string
+		expectedError    error
+		expectedHandlers int
+	}{
+		{
+			name: "scenario endpoints share the route",
+			content: `{"endpoints": [` +
+				endpoint("/orders", `"scenario": {"name": "order", "state": "started"},`) + `,` +
+				endpoint("/orders", `"scenario": {"name": "order", "state": "approved"},`) + `,` +
+				endpoint("/orders", `"hosts": ["api.example.test"], "scenario": {"name": "order",
+					"key": {"mapped": {"from": "header", "param": "X-User"}}},`) + `]}`,
+			// the routes of both hosts and the four routes of the admin API
+			expectedHandlers: 6,
+		},
+		{
+			name: "scenario endpoint duplicating the route of the endpoint",
+			content: `{"endpoints": [` +
+				endpoint("/orders", ``) + `,` +
+				endpoint("/orders", `"scenario": {"name": "order", "state": "approved"},`) + `]}`,
+			expectedError: parser.ErrDuplicatedEndpoint,
+		},
+		{
+			name: "scenario endpoints of the different hosts sharing one",
+			content: `{"endpoints": [` +
+				endpoint("/orders", `"hosts": ["api.example.test"], "scenario": {"name": "order"},`) + `,` +
+				endpoint("/orders", `"hosts": ["api.example.test", "auth.example.test"], "scenario": {"name": "order"},`) +
+				`]}`,
+			expectedError: parser.ErrDuplicatedEndpoint,
+		},
+		{
+			name:          "scenario without name",
+			content:       `{"endpoints": [` + endpoint("/orders", `"scenario": {"state": "approved"},`) + `]}`,
+			expectedError: parser.ErrValidation,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			tools.SaveToAFile(t, tc.content, path.Join(dir, "main.json"))
+			factory := mocks.NewFactoryMock(t)
+			if tc.expectedError == nil {
+				scenarios := api.NewScenarios()
+				factory.On("SetDefinitions", mock.Anything).Return()
+				factory.On("Scenarios").Return(scenarios)
+				factory.On("CreateEndpoint", mock.AnythingOfType("dto.Endpoint"), dir).
+					Return(func(e dto.Endpoint, _ string) (api.Handler, error) {
+						return api.NewScenarioHandler(&mocks.HandlerMock{}, scenarios, api.ScenarioStep{
+							Name: e.Scenario.Name, State: e.Scenario.State,
+						}, logger.NewTestLogger()), nil
+					})
+			}
+			config, err := parser.NewLoader(factory, "", logger.NewTestLogger()).LoadConfig(path.Join(dir, "main.json"))
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, config.Handlers, tc.expectedHandlers)
+		})
+	}
+}
+
+func TestLoader_LoadConfigWithSequences(t *testing.T) {
+	t.Parallel()
+	sequence := `"sequence": {"responses": [{"status": 200, "type": "static", "format": "json", "static": {}}]}`
+	testCases := []struct {
+		name             string
+		content          string
+		expectedError    error
+		expectedHandlers int
+	}{
+		{
+			name:    "sequence endpoint with the admin API",
+			content: `{"endpoints": [{"url": "/jobs", "method": "GET", ` + sequence + `}]}`,
+			// the endpoint and the route of the admin API
+			expectedHandlers: 2,
+		},
+		{
+			name:          "sequence under the admin prefix",
+			content:       `{"endpoints": [{"url": "/__admin/jobs", "method": "GET", ` + sequence + `}]}`,
+			expectedError: parser.ErrReservedURL,
+		},
+		{
+			name:    "admin prefix as the part of the name",
+			content: `{"endpoints": [{"url": "/__administrators", "method": "GET", ` + sequence + `}]}`,
+			// the endpoint and the route of the admin API
+			expectedHandlers: 2,
+		},
+		{
+			name: "sequence with proxy",
+			content: `{"endpoints": [{"url": "/jobs", "method": "GET", ` + sequence + `,
+				"proxy": {"type": "static", "method": "GET", "url": "http://localhost:8080"}}]}`,
+			expectedError: parser.ErrValidation,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			tools.SaveToAFile(t, tc.content, path.Join(dir, "main.json"))
+			factory := mocks.NewFactoryMock(t)
+			if tc.expectedError == nil {
+				factory.On("SetDefinitions", mock.Anything).Return()
+				factory.On("Sequences").Return(api.NewSequences())
+				factory.On("CreateEndpoint", mock.AnythingOfType("dto.Endpoint"), dir).Return(&mocks.HandlerMock{}, nil)
+			}
+			config, err := parser.NewLoader(factory, "", logger.NewTestLogger()).LoadConfig(path.Join(dir, "main.json"))
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, config.Handlers, tc.expectedHandlers)
+		})
+	}
+}
+
+func TestLoader_LoadConfigWithDefinitions(t *testing.T) {
+	t.Parallel()
+	endpoint := `"endpoints": [{"url": "/users", "method": "GET", "response": {
+		"status": 200, "type": "dynamic", "format": "json", "object": [{"key": "user", "ref": {"name": "user"}}]}}]`
+	testCases := []struct {
+		name          string
+		definitions   string
+		expectedError error
+	}{
+		{
+			name: "valid definition",
+			definitions: `"definitions": {"user": [
+				{"key": "id", "mapped": {"from": "url", "param": "id"}},
+				{"key": "tags", "array": {"min": 0, "max": 2, "element": [{"static": {"value": "tag"}}]}}]}`,
+		},
+		{
+			name:          "mapped without the location",
+			definitions:   `"definitions": {"user": [{"key": "id", "mapped": {"param": "id"}}]}`,
+			expectedError: parser.ErrValidation,
+		},
+		{
+			name:          "mapped body without the path",
+			definitions:   `"definitions": {"user": [{"key": "name", "mapped": {"from": "body"}}]}`,
+			expectedError: parser.ErrValidation,
+		},
+		{
+			name: "nested object with unknown conversion",
+			definitions: `"definitions": {"user": [{"key": "address", "object": [
+				{"key": "zip", "mapped": {"from": "query", "param": "zip", "as": "date"}}]}]}`,
+			expectedError: parser.ErrValidation,
+		},
+		{
+			name: "array with max lower than min",
+			definitions: `"definitions": {"user": [
+				{"key": "tags", "array": {"min": 3, "max": 1, "element": [{"static": {"value": "tag"}}]}}]}`,
+			expectedError: parser.ErrValidation,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			tools.SaveToAFile(t, `{`+tc.definitions+`, `+endpoint+`}`, path.Join(dir, "main.json"))
+			factory := mocks.NewFactoryMock(t)
+			if tc.expectedError == nil {
+				factory.On("SetDefinitions", mock.Anything).Return()
+				factory.On("CreateEndpoint", mock.AnythingOfType("dto.Endpoint"), dir).Return(&mocks.HandlerMock{}, nil)
+			}
+			config, err := parser.NewLoader(factory, "", logger.NewTestLogger()).LoadConfig(path.Join(dir, "main.json"))
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, config.Handlers, 1)
+		})
+	}
+}
+
+func TestLoader_LoadConfigWithConflictingKinds(t *testing.T) {
+	t.Parallel()
+	response := `"response": {"status": 200, "type": "static", "format": "json", "static": {}}`
+	proxy := `"proxy": {"type": "static", "method": "GET", "url": "http://localhost:8080"}`
+	websocket := `"websocket": {}`
+	graphql := `"graphql": {"schema": "schema.graphql"}`
+	testCases := []struct {
+		name string
+		kind string
+	}{
+		{name: "response with websocket", kind: response + ", " + websocket},
+		{name: "response with graphql", kind: response + ", " + graphql},
+		{name: "proxy with websocket", kind: proxy + ", " + websocket},
+		{name: "proxy with graphql", kind: proxy + ", " + graphql},
+		{name: "websocket with graphql", kind: websocket + ", " + graphql},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			tools.SaveToAFile(t, `{"endpoints": [{"url": "/live", "method": "GET", `+tc.kind+`}]}`, path.Join(dir, "main.json"))
+			factory := mocks.NewFactoryMock(t)
+			_, err := parser.NewLoader(factory, "", logger.NewTestLogger()).LoadConfig(path.Join(dir, "main.json"))
+			require.ErrorIs(t, err, parser.ErrValidation)
+		})
+	}
+}
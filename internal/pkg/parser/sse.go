@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/encoders"
+	"github.com/vimek-go/server-faker/internal/pkg/parser/dto"
+
+	"github.com/pkg/errors"
+)
+
+func (f *factory) createSSEEndpoint(endpoint dto.Endpoint, baseDir string) (api.ResponseHandler, error) {
+	response := endpoint.Response
+	if response.SSE == nil {
+		return nil, errors.Wrapf(ErrValidation, "sse endpoint %s %s without sse section", endpoint.Method, endpoint.URL)
+	}
+	options, err := f.prepareEncoderOptions(response, baseDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "endpoint %s %s", endpoint.Method, endpoint.URL)
+	}
+	encoder, err := encoders.New(response.Format, options)
+	if err != nil {
+		return nil, errors.Wrapf(
+			api.ErrNotSupportedFormat,
+			"format %s is not supported in sse endpoint %s %s",
+			response.Format,
+			endpoint.Method,
+			endpoint.URL,
+		)
+	}
+	valuer, err := f.PrepareValuer(response.Object, endpoint.URL)
+	if err != nil {
+		return nil, err
+	}
+	if valuer == nil && len(response.SSE.Events) == 0 {
+		return nil, errors.Wrapf(
+			ErrValidation,
+			"sse endpoint %s %s needs the events or the object generating them",
+			endpoint.Method,
+			endpoint.URL,
+		)
+	}
+	// the generated events without the interval would be sent in a busy loop until the client disconnects
+	if valuer != nil && response.SSE.Count == 0 && response.SSE.Interval.Duration() <= 0 {
+		return nil, errors.Wrapf(
+			ErrValidation,
+			"sse endpoint %s %s generating events needs the interval or the count",
+			endpoint.Method,
+			endpoint.URL,
+		)
+	}
+
+	stream := api.SSEStream{
+		Valuer:   valuer,
+		Event:    response.SSE.Event,
+		IDs:      response.SSE.IDs,
+		Retry:    response.SSE.Retry.Duration(),
+		Interval: response.SSE.Interval.Duration(),
+		Count:    response.SSE.Count,
+		Duration: response.SSE.Duration.Duration(),
+		Done:     response.SSE.Done,
+	}
+	for _, event := range response.SSE.Events {
+		stream.Events = append(stream.Events, api.SSEEvent{Event: event.Event, ID: event.ID, Data: event.Data})
+	}
+	return api.NewSSEHandler(endpoint.Method, endpoint.URL, response.Status, stream, encoder, f.logger), nil
+}
@@ -0,0 +1,125 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/vimek-go/server-faker/internal/pkg/parser/dto"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrUnresolvedVariable = errors.New("unresolved variable")
+	ErrUnknownProfile     = errors.New("unknown profile")
+)
+
+// matches $$ used to escape the dollar sign, ${VAR} and ${VAR:-default}
+var variablePattern = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+const (
+	escapedDollar = "$$"
+	variablesKey  = "variables"
+	profilesKey   = "profiles"
+)
+
+// prepareVariables overlays the variables of the file with the selected profile
+// variables of the including file take precedence over the included one
+func (l *loader) prepareVariables(
+	environment dto.Environment,
+	inherited map[string]string,
+	state *loadState,
+) map[string]string {
+	rval := make(map[string]string, len(environment.Variables)+len(inherited))
+	for k, v := range environment.Variables {
+		rval[k] = v
+	}
+	if profile, ok := environment.Profiles[l.profile]; ok && len(l.profile) > 0 {
+		state.profileFound = true
+		for k, v := range profile {
+			rval[k] = v
+		}
+	}
+	for k, v := range inherited {
+		rval[k] = v
+	}
+	return rval
+}
+
+// substituteVariables replaces the variables in all the string values of the json document
+// the environment is only the fallback for the variables which are not declared in the config
+// $$ is resolved in every file, the files without any dollar sign are returned untouched
+func (l *loader) substituteVariables(content []byte, variables map[string]string) ([]byte, error) {
+	if !bytes.ContainsRune(content, '$') {
+		return content, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	// keep the numbers untouched
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+
+	unresolved := make(map[string]bool)
+	if root, ok := document.(map[string]any); ok {
+		for k, v := range root {
+			// variables are not substituted in their own declarations
+			if k == variablesKey || k == profilesKey {
+				continue
+			}
+			root[k] = l.substituteNode(v, variables, unresolved)
+		}
+	}
+	if len(unresolved) > 0 {
+		names := make([]string, 0, len(unresolved))
+		for name := range unresolved {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, errors.Wrapf(ErrUnresolvedVariable, "variables: %s", strings.Join(names, ", "))
+	}
+	return json.Marshal(document)
+}
+
+func (l *loader) substituteNode(node any, variables map[string]string, unresolved map[string]bool) any {
+	switch value := node.(type) {
+	case map[string]any:
+		for k, v := range value {
+			value[k] = l.substituteNode(v, variables, unresolved)
+		}
+		return value
+	case []any:
+		for i := range value {
+			value[i] = l.substituteNode(value[i], variables, unresolved)
+		}
+		return value
+	case string:
+		return l.substituteString(value, variables, unresolved)
+	}
+	return node
+}
+
+func (l *loader) substituteString(value string, variables map[string]string, unresolved map[string]bool) string {
+	return variablePattern.ReplaceAllStringFunc(value, func(match string) string {
+		if match == escapedDollar {
+			return "$"
+		}
+		groups := variablePattern.FindStringSubmatch(match)
+		name, hasDefault, defaultValue := groups[1], len(groups[2]) > 0, groups[3]
+		if configValue, ok := variables[name]; ok {
+			return configValue
+		}
+		if envValue, ok := l.lookupEnv(name); ok && len(envValue) > 0 {
+			return envValue
+		}
+		if hasDefault {
+			return defaultValue
+		}
+		unresolved[name] = true
+		return match
+	})
+}
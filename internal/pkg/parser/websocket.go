@@ -0,0 +1,101 @@
+package parser
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/vimek-go/server-faker/internal/pkg/api"
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/parser/dto"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/pkg/errors"
+)
+
+func (f *factory) CreateWebSocketEndpoint(endpoint dto.Endpoint) (api.Handler, error) {
+	if endpoint.Method != http.MethodGet {
+		return nil, errors.Wrapf(
+			ErrNotHandled,
+			"websocket endpoint %s has to use method GET, got %s",
+			endpoint.URL,
+			endpoint.Method,
+		)
+	}
+	ws := endpoint.WebSocket
+	var flow api.WebSocketFlow
+	var err error
+	if flow.OnConnect, err = f.prepareWebSocketMessages(ws.OnConnect, endpoint.URL); err != nil {
+		return nil, err
+	}
+	for _, reply := range ws.Replies {
+		match := api.WebSocketMatch{Text: reply.Match.Text, Path: reply.Match.Path, Value: reply.Match.Value}
+		if len(reply.Match.Regex) > 0 {
+			if match.Regex, err = regexp.Compile(reply.Match.Regex); err != nil {
+				return nil, errors.Wrapf(err, "websocket endpoint %s, invalid match regex", endpoint.URL)
+			}
+		}
+		messages, err := f.prepareWebSocketMessages(reply.Messages, endpoint.URL)
+		if err != nil {
+			return nil, err
+		}
+		flow.Replies = append(flow.Replies, api.WebSocketReply{
+			Match:    match,
+			Messages: messages,
+			Close:    prepareWebSocketClose(reply.Close),
+		})
+	}
+	for _, push := range ws.Pushes {
+		message, err := f.prepareWebSocketMessage(push.Message, endpoint.URL)
+		if err != nil {
+			return nil, err
+		}
+		flow.Pushes = append(flow.Pushes, api.WebSocketPush{
+			Interval: push.Interval.Duration(),
+			Count:    push.Count,
+			Message:  message,
+		})
+	}
+	flow.Close = prepareWebSocketClose(ws.Close)
+	return api.NewWebSocketHandler(endpoint.URL, flow, f.logger), nil
+}
+
+func (f *factory) prepareWebSocketMessages(messages []dto.WebSocketMessage, url string) ([]values.Valuer, error) {
+	rval := make([]values.Valuer, len(messages))
+	for i := range messages {
+		valuer, err := f.prepareWebSocketMessage(messages[i], url)
+		if err != nil {
+			return nil, err
+		}
+		rval[i] = valuer
+	}
+	return rval, nil
+}
+
+func (f *factory) prepareWebSocketMessage(message dto.WebSocketMessage, url string) (values.Valuer, error) {
+	switch message.Type {
+	case enums.ResponseTypes.Static():
+		return values.NewStaticValuer("", message.Static), nil
+	case enums.ResponseTypes.Dynamic():
+		valuer, err := f.PrepareValuer(message.Object, url)
+		if err != nil {
+			return nil, err
+		}
+		if valuer == nil {
+			return nil, errors.Wrapf(ErrValidation, "websocket endpoint %s, dynamic message without object", url)
+		}
+		return valuer, nil
+	}
+	return nil, errors.Wrapf(ErrNotHandled, "websocket endpoint %s, message type %s", url, message.Type)
+}
+
+func prepareWebSocketClose(closing *dto.WebSocketClose) *api.WebSocketClose {
+	if closing == nil {
+		return nil
+	}
+	return &api.WebSocketClose{
+		After:         closing.After.Duration(),
+		AfterMessages: closing.AfterMessages,
+		Code:          closing.Code,
+		Reason:        closing.Reason,
+	}
+}
@@ -0,0 +1,70 @@
+package parser_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/parser"
+	"github.com/vimek-go/server-faker/internal/pkg/parser/dto"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFactory_CreateWebSocketEndpoint(t *testing.T) {
+	t.Parallel()
+	message := dto.WebSocketMessage{Type: enums.ResponseTypes.Static(), Static: "pong"}
+	testCases := []struct {
+		name          string
+		method        string
+		websocket     dto.WebSocket
+		expectedError string
+	}{
+		{
+			name:   "websocket endpoint creation",
+			method: http.MethodGet,
+			websocket: dto.WebSocket{
+				OnConnect: []dto.WebSocketMessage{message},
+				Replies:   []dto.WebSocketReply{{Match: dto.WebSocketMatch{Regex: "^ping$"}, Messages: []dto.WebSocketMessage{message}}},
+				Pushes:    []dto.WebSocketPush{{Interval: dto.Duration(1000), Message: message}},
+			},
+		},
+		{
+			name:          "error on method other than GET",
+			method:        http.MethodPost,
+			expectedError: "has to use method GET",
+		},
+		{
+			name:   "error on invalid regex",
+			method: http.MethodGet,
+			websocket: dto.WebSocket{
+				Replies: []dto.WebSocketReply{{Match: dto.WebSocketMatch{Regex: "(ping"}}},
+			},
+			expectedError: "invalid match regex",
+		},
+		{
+			name:   "error on dynamic message without object",
+			method: http.MethodGet,
+			websocket: dto.WebSocket{
+				OnConnect: []dto.WebSocketMessage{{Type: enums.ResponseTypes.Dynamic()}},
+			},
+			expectedError: "dynamic message without object",
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			f := parser.NewFactory(nil, logger.NewTestLogger())
+			handler, err := f.CreateEndpoint(dto.Endpoint{URL: "/ws", Method: tc.method, WebSocket: &tc.websocket}, "")
+			if len(tc.expectedError) > 0 {
+				require.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, http.MethodGet, handler.Method())
+			require.Equal(t, "/ws", handler.URL())
+		})
+	}
+}
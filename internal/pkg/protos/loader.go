@@ -0,0 +1,177 @@
+package protos
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+var (
+	ErrLoadingFailed   = errors.New("loading proto definitions failed")
+	ErrMessageNotFound = errors.New("message not found")
+	ErrServiceNotFound = errors.New("service not found")
+)
+
+const protoExtension = ".proto"
+
+type Loader interface {
+	// Load reads a .proto file, compiled with its imports, or a binary descriptor set
+	Load(path string, importPaths []string) (*protoregistry.Files, error)
+	FindMessage(path string, importPaths []string, name string) (protoreflect.MessageDescriptor, error)
+	FindService(path string, importPaths []string, name string) (protoreflect.ServiceDescriptor, error)
+}
+
+type loader struct {
+	mutex  sync.Mutex
+	loaded map[string]*protoregistry.Files
+	logger logger.Logger
+}
+
+func NewLoader(logger logger.Logger) Loader {
+	return &loader{loaded: make(map[string]*protoregistry.Files), logger: logger}
+}
+
+func (l *loader) Load(path string, importPaths []string) (*protoregistry.Files, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.Wrapf(ErrLoadingFailed, "path %s: %v", path, err)
+	}
+	cacheKey := absPath + "|" + strings.Join(importPaths, "|")
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if files, ok := l.loaded[cacheKey]; ok {
+		return files, nil
+	}
+
+	l.logger.Infof("loading proto definitions from %s", path)
+	var files *protoregistry.Files
+	if filepath.Ext(absPath) == protoExtension {
+		files, err = l.compile(absPath, importPaths)
+	} else {
+		files, err = l.readDescriptorSet(absPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	l.loaded[cacheKey] = files
+	return files, nil
+}
+
+func (l *loader) FindMessage(
+	path string,
+	importPaths []string,
+	name string,
+) (protoreflect.MessageDescriptor, error) {
+	files, err := l.Load(path, importPaths)
+	if err != nil {
+		return nil, err
+	}
+	descriptor, err := files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, errors.Wrapf(ErrMessageNotFound, "message %s in %s", name, path)
+	}
+	message, ok := descriptor.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, errors.Wrapf(ErrMessageNotFound, "%s in %s is not a message", name, path)
+	}
+	return message, nil
+}
+
+func (l *loader) FindService(
+	path string,
+	importPaths []string,
+	name string,
+) (protoreflect.ServiceDescriptor, error) {
+	files, err := l.Load(path, importPaths)
+	if err != nil {
+		return nil, err
+	}
+	descriptor, err := files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, errors.Wrapf(ErrServiceNotFound, "service %s in %s", name, path)
+	}
+	service, ok := descriptor.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, errors.Wrapf(ErrServiceNotFound, "%s in %s is not a service", name, path)
+	}
+	return service, nil
+}
+
+func (l *loader) compile(path string, importPaths []string) (*protoregistry.Files, error) {
+	// the file name has to be relative to the import path it is found in
+	// otherwise its imports are not resolved
+	fileName := filepath.Base(path)
+	paths := make([]string, 0, len(importPaths)+1)
+	for _, importPath := range importPaths {
+		absImportPath, err := filepath.Abs(importPath)
+		if err != nil {
+			return nil, errors.Wrapf(ErrLoadingFailed, "import path %s: %v", importPath, err)
+		}
+		paths = append(paths, absImportPath)
+		if rel, err := filepath.Rel(absImportPath, path); err == nil && !strings.HasPrefix(rel, "..") {
+			fileName = filepath.ToSlash(rel)
+		}
+	}
+	if fileName == filepath.Base(path) {
+		paths = append(paths, filepath.Dir(path))
+	}
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: paths}),
+	}
+	compiled, err := compiler.Compile(context.Background(), fileName)
+	if err != nil {
+		return nil, errors.Wrapf(ErrLoadingFailed, "compiling %s: %v", path, err)
+	}
+
+	files := new(protoregistry.Files)
+	for _, file := range compiled {
+		if err := l.register(files, file); err != nil {
+			return nil, errors.Wrapf(ErrLoadingFailed, "registering %s: %v", path, err)
+		}
+	}
+	return files, nil
+}
+
+// register adds the file with all its imports to the registry
+func (l *loader) register(files *protoregistry.Files, file protoreflect.FileDescriptor) error {
+	if _, err := files.FindFileByPath(file.Path()); err == nil {
+		return nil
+	}
+	imports := file.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		if err := l.register(files, imports.Get(i).FileDescriptor); err != nil {
+			return err
+		}
+	}
+	return files.RegisterFile(file)
+}
+
+func (l *loader) readDescriptorSet(path string) (*protoregistry.Files, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(ErrLoadingFailed, "reading %s: %v", path, err)
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(content, &set); err != nil {
+		return nil, errors.Wrapf(ErrLoadingFailed, "unmarshalling descriptor set %s: %v", path, err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, errors.Wrapf(ErrLoadingFailed, "descriptor set %s: %v", path, err)
+	}
+	return files, nil
+}
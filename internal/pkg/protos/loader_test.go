@@ -0,0 +1,137 @@
+package protos_test
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/protos"
+	"github.com/vimek-go/server-faker/internal/pkg/tools"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const userProto = `syntax = "proto3";
+package test.v1;
+
+import "google/protobuf/timestamp.proto";
+import "common/status.proto";
+
+message User {
+  string name = 1;
+  google.protobuf.Timestamp created = 2;
+  test.common.Status status = 3;
+}
+`
+
+const statusProto = `syntax = "proto3";
+package test.common;
+
+enum Status {
+  UNKNOWN = 0;
+  ACTIVE = 1;
+}
+`
+
+func TestLoader_FindMessage(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name          string
+		setup         func(*testing.T, string) (string, []string)
+		message       string
+		expectedError error
+	}{
+		{
+			name: "proto file with imports",
+			setup: func(t *testing.T, dir string) (string, []string) {
+				require.NoError(t, os.MkdirAll(path.Join(dir, "common"), 0o755))
+				tools.SaveToAFile(t, statusProto, path.Join(dir, "common", "status.proto"))
+				tools.SaveToAFile(t, userProto, path.Join(dir, "user.proto"))
+				return path.Join(dir, "user.proto"), nil
+			},
+			message: "test.v1.User",
+		},
+		{
+			name: "proto file in import path",
+			setup: func(t *testing.T, dir string) (string, []string) {
+				require.NoError(t, os.MkdirAll(path.Join(dir, "protos", "common"), 0o755))
+				require.NoError(t, os.MkdirAll(path.Join(dir, "protos", "v1"), 0o755))
+				tools.SaveToAFile(t, statusProto, path.Join(dir, "protos", "common", "status.proto"))
+				tools.SaveToAFile(t, userProto, path.Join(dir, "protos", "v1", "user.proto"))
+				return path.Join(dir, "protos", "v1", "user.proto"), []string{path.Join(dir, "protos")}
+			},
+			message: "test.v1.User",
+		},
+		{
+			name: "descriptor set",
+			setup: func(t *testing.T, dir string) (string, []string) {
+				set := &descriptorpb.FileDescriptorSet{
+					File: []*descriptorpb.FileDescriptorProto{
+						protodesc.ToFileDescriptorProto(timestamppb.File_google_protobuf_timestamp_proto),
+					},
+				}
+				content, err := proto.Marshal(set)
+				require.NoError(t, err)
+				filePath := path.Join(dir, "descriptors.pb")
+				require.NoError(t, os.WriteFile(filePath, content, 0o600))
+				return filePath, nil
+			},
+			message: "google.protobuf.Timestamp",
+		},
+		{
+			name: "unknown message",
+			setup: func(t *testing.T, dir string) (string, []string) {
+				tools.SaveToAFile(t, statusProto, path.Join(dir, "status.proto"))
+				return path.Join(dir, "status.proto"), nil
+			},
+			message:       "test.common.User",
+			expectedError: protos.ErrMessageNotFound,
+		},
+		{
+			name: "enum is not a message",
+			setup: func(t *testing.T, dir string) (string, []string) {
+				tools.SaveToAFile(t, statusProto, path.Join(dir, "status.proto"))
+				return path.Join(dir, "status.proto"), nil
+			},
+			message:       "test.common.Status",
+			expectedError: protos.ErrMessageNotFound,
+		},
+		{
+			name: "invalid proto file",
+			setup: func(t *testing.T, dir string) (string, []string) {
+				tools.SaveToAFile(t, `syntax = "proto3"; message {`, path.Join(dir, "invalid.proto"))
+				return path.Join(dir, "invalid.proto"), nil
+			},
+			message:       "Invalid",
+			expectedError: protos.ErrLoadingFailed,
+		},
+		{
+			name: "missing file",
+			setup: func(_ *testing.T, dir string) (string, []string) {
+				return path.Join(dir, "missing.pb"), nil
+			},
+			message:       "Missing",
+			expectedError: protos.ErrLoadingFailed,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filePath, importPaths := tc.setup(t, t.TempDir())
+			loader := protos.NewLoader(logger.NewTestLogger())
+			message, err := loader.FindMessage(filePath, importPaths, tc.message)
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.message, string(message.FullName()))
+		})
+	}
+}
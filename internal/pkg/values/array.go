@@ -20,7 +20,10 @@ func NewArrayValuer(key string, min, max int, valuer Valuer) Valuer {
 
 func (av *ArrayValuer) Generate(c *gin.Context) (any, error) {
 	var length int
-	if av.max == av.min {
+	// the element of the reference which reached its depth ends the recursion with the empty array
+	if _, endsRecursion := av.valuer.(*NilValuer); av.valuer == nil || endsRecursion {
+		length = 0
+	} else if av.max == av.min {
 		length = av.min
 	} else {
 		length = tools.GenerateRandomInt(av.min, av.max)
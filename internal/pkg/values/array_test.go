@@ -17,6 +17,7 @@ func TestArrayValuer_Generate(t *testing.T) {
 		key      string
 		min      int
 		max      int
+		element  values.Valuer
 		expected any
 	}{
 		{
@@ -45,13 +46,31 @@ func TestArrayValuer_Generate(t *testing.T) {
 			max:      3,
 			expected: []any{"test", "test", "test"},
 		},
+		{
+			name:     "empty objects",
+			min:      2,
+			max:      2,
+			element:  values.NewObjectValuer("", nil),
+			expected: []any{map[string]any{}, map[string]any{}},
+		},
+		{
+			name:     "reference which reached its depth",
+			min:      2,
+			max:      2,
+			element:  values.NewNilValuer(""),
+			expected: []any{},
+		},
 	}
 
 	for i := range testCases {
 		testCase := testCases[i]
 		t.Run(testCase.name, func(t *testing.T) {
 			t.Parallel()
-			av := values.NewArrayValuer(testCase.key, testCase.min, testCase.max, testStaticValuer)
+			element := testCase.element
+			if element == nil {
+				element = testStaticValuer
+			}
+			av := values.NewArrayValuer(testCase.key, testCase.min, testCase.max, element)
 			generated, err := av.Generate(nil)
 			assert.NoError(t, err)
 			assert.Equal(t, testCase.expected, generated)
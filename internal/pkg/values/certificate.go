@@ -0,0 +1,91 @@
+package values
+
+import (
+	"crypto/x509"
+
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// certificateFields read the attributes of the client certificate
+// the attributes with many values are selected with the index of the mapping
+var certificateFields = map[string]func(*x509.Certificate) []string{
+	"subject":             func(c *x509.Certificate) []string { return []string{c.Subject.String()} },
+	"issuer":              func(c *x509.Certificate) []string { return []string{c.Issuer.String()} },
+	"common_name":         func(c *x509.Certificate) []string { return []string{c.Subject.CommonName} },
+	"serial_number":       func(c *x509.Certificate) []string { return []string{c.SerialNumber.String()} },
+	"organization":        func(c *x509.Certificate) []string { return c.Subject.Organization },
+	"organizational_unit": func(c *x509.Certificate) []string { return c.Subject.OrganizationalUnit },
+	"country":             func(c *x509.Certificate) []string { return c.Subject.Country },
+	"locality":            func(c *x509.Certificate) []string { return c.Subject.Locality },
+	"province":            func(c *x509.Certificate) []string { return c.Subject.Province },
+	"email":               func(c *x509.Certificate) []string { return c.EmailAddresses },
+	"dns":                 func(c *x509.Certificate) []string { return c.DNSNames },
+}
+
+type CertificateMapper struct {
+	keyValue
+	field      string
+	read       func(*x509.Certificate) []string
+	index      *int
+	conversion enums.ConversionType
+	logger     logger.Logger
+}
+
+func newCertificateMapper(
+	responseKey, field string,
+	index *int,
+	conversion enums.ConversionType,
+	logger logger.Logger,
+) (Valuer, error) {
+	read, ok := certificateFields[field]
+	if !ok {
+		return nil, errors.Wrapf(ErrUnknownCertificateField, "field: %s, response key: %s", field, responseKey)
+	}
+	return &CertificateMapper{
+		keyValue:   keyValue{key: responseKey},
+		field:      field,
+		read:       read,
+		index:      index,
+		conversion: conversion,
+		logger:     logger,
+	}, nil
+}
+
+func (cm *CertificateMapper) Generate(c *gin.Context) (any, error) {
+	if c.Request.TLS == nil || len(c.Request.TLS.PeerCertificates) == 0 {
+		return nil, errors.Wrapf(ErrNoClientCertificate, "mapping field: %s", cm.field)
+	}
+	fieldValues := cm.read(c.Request.TLS.PeerCertificates[0])
+	cm.logger.Infof("certificate field[%s] value %+v", cm.field, fieldValues)
+	if len(fieldValues) == 0 {
+		return nil, errors.Wrapf(ErrFailedLocatingElement, "client certificate has no field: %s", cm.field)
+	}
+	fieldValue, err := selectIndex(fieldValues, cm.index, cm.field)
+	if err != nil {
+		return nil, err
+	}
+
+	var value any = fieldValue
+	if cm.conversion != enums.ConversionTypes.None() {
+		value, err = transform(fieldValue, cm.conversion)
+		if err != nil {
+			return nil, errors.Wrapf(err, "param key: [%s]", cm.field)
+		}
+	}
+	if key := cm.keyValue.Key(); key != nil {
+		return map[string]any{*key: value}, nil
+	}
+	return value, nil
+}
+
+func (cm *CertificateMapper) Type() enums.GenerationType {
+	return enums.GenerationTypes.SingleValue()
+}
+
+func (cm *CertificateMapper) IsNil() bool {
+	return cm == nil
+}
@@ -0,0 +1,126 @@
+package values_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCertificateMapper_Generate(t *testing.T) {
+	t.Parallel()
+	certificate := &x509.Certificate{
+		SerialNumber: big.NewInt(1234),
+		Subject: pkix.Name{
+			CommonName:         "orders-service",
+			Organization:       []string{"acme", "acme-test"},
+			OrganizationalUnit: []string{"payments"},
+		},
+		DNSNames: []string{"orders.internal"},
+	}
+	index := 1
+	outOfRange := 5
+	testCases := []struct {
+		name          string
+		field         string
+		responseKey   string
+		index         *int
+		conversion    enums.ConversionType
+		noCertificate bool
+		expected      any
+		expectedError error
+	}{
+		{
+			name:     "common name",
+			field:    "common_name",
+			expected: "orders-service",
+		},
+		{
+			name:        "organization with response key",
+			field:       "organization",
+			responseKey: "org",
+			expected:    map[string]any{"org": "acme"},
+		},
+		{
+			name:     "organization with index",
+			field:    "organization",
+			index:    &index,
+			expected: "acme-test",
+		},
+		{
+			name:       "serial number as number",
+			field:      "serial_number",
+			conversion: enums.ConversionTypes.Number(),
+			expected:   float64(1234),
+		},
+		{
+			name:     "subject",
+			field:    "subject",
+			expected: "CN=orders-service,OU=payments,O=acme+O=acme-test",
+		},
+		{
+			name:          "index out of range",
+			field:         "dns",
+			index:         &outOfRange,
+			expectedError: values.ErrFailedLocatingElement,
+		},
+		{
+			name:          "empty field",
+			field:         "email",
+			expectedError: values.ErrFailedLocatingElement,
+		},
+		{
+			name:          "request without certificate",
+			field:         "common_name",
+			noCertificate: true,
+			expectedError: values.ErrNoClientCertificate,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			conversion := tc.conversion
+			if len(conversion) == 0 {
+				conversion = enums.ConversionTypes.None()
+			}
+			valuer, err := values.NewMappedValuer(
+				tc.responseKey, tc.field, "", "/",
+				enums.RequestLocations.Certificate(), tc.index, conversion, logger.NewTestLogger(),
+			)
+			require.NoError(t, err)
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			if !tc.noCertificate {
+				c.Request.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{certificate}}
+			}
+
+			value, err := valuer.Generate(c)
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, value)
+		})
+	}
+}
+
+func TestCertificateMapper_UnknownField(t *testing.T) {
+	t.Parallel()
+	_, err := values.NewMappedValuer(
+		"", "password", "", "/",
+		enums.RequestLocations.Certificate(), nil, enums.ConversionTypes.None(), logger.NewTestLogger(),
+	)
+	require.ErrorIs(t, err, values.ErrUnknownCertificateField)
+}
@@ -3,10 +3,15 @@ package values
 import "errors"
 
 var (
-	ErrFailedBindingBody     = errors.New("failed binding body")
-	ErrConversionFailed      = errors.New("failed converting param")
-	ErrFailedLocatingElement = errors.New("failed locating element")
-	ErrEmptyKey              = errors.New("url param cannot have empty key")
-	ErrNotHandledType        = errors.New("valuer type is not handled")
-	ErrNotHandledKind        = errors.New("random kind is not handled")
+	ErrFailedBindingBody       = errors.New("failed binding body")
+	ErrConversionFailed        = errors.New("failed converting param")
+	ErrFailedLocatingElement   = errors.New("failed locating element")
+	ErrEmptyKey                = errors.New("url param cannot have empty key")
+	ErrNotHandledType          = errors.New("valuer type is not handled")
+	ErrNotHandledKind          = errors.New("random kind is not handled")
+	ErrNoClientCertificate     = errors.New("request without client certificate")
+	ErrUnknownCertificateField = errors.New("unknown client certificate field")
+	ErrNoUpstreamBody          = errors.New("no upstream response body")
+	ErrNoResponseBody          = errors.New("no json response body")
+	ErrNotStored               = errors.New("value not stored")
 )
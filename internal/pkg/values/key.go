@@ -0,0 +1,19 @@
+package values
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GenerateKey returns the request value the state is kept per, the empty key when the valuer is nil
+func GenerateKey(c *gin.Context, key Valuer) (string, error) {
+	if key == nil || key.IsNil() {
+		return "", nil
+	}
+	value, err := key.Generate(c)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(value), nil
+}
@@ -0,0 +1,47 @@
+package values_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateKey(t *testing.T) {
+	t.Parallel()
+	user, err := values.NewMappedValuer("", "X-User", "", "", enums.RequestLocations.Header(), nil,
+		enums.ConversionTypes.None(), logger.NewTestLogger())
+	require.NoError(t, err)
+	testCases := []struct {
+		name          string
+		key           values.Valuer
+		user          string
+		expected      string
+		expectedError error
+	}{
+		{name: "no key"},
+		{name: "number", key: values.NewStaticValuer("", 7), expected: "7"},
+		{name: "mapped", key: user, user: "john", expected: "john"},
+		{name: "missing value", key: user, expectedError: values.ErrFailedLocatingElement},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/test", nil)
+			if len(tc.user) > 0 {
+				c.Request.Header.Set("X-User", tc.user)
+			}
+			key, err := values.GenerateKey(c, tc.key)
+			require.ErrorIs(t, err, tc.expectedError)
+			require.Equal(t, tc.expected, key)
+		})
+	}
+}
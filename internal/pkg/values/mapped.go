@@ -11,14 +11,26 @@ import (
 
 	"github.com/PaesslerAG/jsonpath"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/pkg/errors"
 )
 
+const (
+	// upstreamBodyKey keeps the decoded body of the proxied response in the context
+	upstreamBodyKey = "server-faker/upstream-body"
+	// responseBodyKey keeps the decoded body of the mocked response in the context
+	responseBodyKey = "server-faker/response-body"
+)
+
 type PayloadMapper struct {
 	keyValue
-	path       string
-	conversion enums.ConversionType
-	logger     logger.Logger
+	// read returns the decoded request body, the upstream body or the response body
+	read func(*gin.Context) (any, error)
+	// fromResponse is set when the body of the mocked response is read
+	fromResponse bool
+	path         string
+	conversion   enums.ConversionType
+	logger       logger.Logger
 }
 
 func NewMappedValuer(
@@ -35,16 +47,79 @@ func NewMappedValuer(
 		return newQueryMapper(responseKey, valueKey, index, conversion, logger), nil
 	case enums.RequestLocations.URL():
 		return newURLMapper(responseKey, valueKey, url, conversion, logger)
+	case enums.RequestLocations.Certificate():
+		return newCertificateMapper(responseKey, valueKey, index, conversion, logger)
+	case enums.RequestLocations.Upstream():
+		return newUpstreamMapper(responseKey, path, conversion, logger), nil
+	case enums.RequestLocations.Response():
+		return newResponseMapper(responseKey, path, conversion, logger), nil
+	case enums.RequestLocations.Header():
+		return newHeaderMapper(responseKey, valueKey, index, conversion, logger)
 	}
 	return nil, errors.Wrapf(ErrNotHandledType, "requested valuer mapped with location %s", location)
 }
 
 func newPayloadMapper(responseKey, path string, conversion enums.ConversionType, logger logger.Logger) Valuer {
-	return &PayloadMapper{keyValue: keyValue{key: responseKey}, path: path, conversion: conversion, logger: logger}
+	return &PayloadMapper{
+		keyValue:   keyValue{key: responseKey},
+		read:       getPayload,
+		path:       path,
+		conversion: conversion,
+		logger:     logger,
+	}
+}
+
+// newUpstreamMapper reads the body of the proxied response instead of the request body
+func newUpstreamMapper(responseKey, path string, conversion enums.ConversionType, logger logger.Logger) Valuer {
+	return &PayloadMapper{
+		keyValue:   keyValue{key: responseKey},
+		read:       getUpstreamBody,
+		path:       path,
+		conversion: conversion,
+		logger:     logger,
+	}
+}
+
+// newResponseMapper reads the body of the mocked response, e.g. to store the generated values
+func newResponseMapper(responseKey, path string, conversion enums.ConversionType, logger logger.Logger) Valuer {
+	return &PayloadMapper{
+		keyValue:     keyValue{key: responseKey},
+		read:         getResponseBody,
+		fromResponse: true,
+		path:         path,
+		conversion:   conversion,
+		logger:       logger,
+	}
+}
+
+// MapsResponse tells if any of the valuers reads the body of the mocked response,
+// so the response has to be kept for them
+func MapsResponse(valuers ...Valuer) bool {
+	for _, valuer := range valuers {
+		switch typed := valuer.(type) {
+		case *PayloadMapper:
+			if typed.fromResponse {
+				return true
+			}
+		case *ObjectValuer:
+			if MapsResponse(typed.valuers...) {
+				return true
+			}
+		case *ArrayValuer:
+			if MapsResponse(typed.valuer) {
+				return true
+			}
+		case *StoredValuer:
+			if MapsResponse(typed.key) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 func (pm *PayloadMapper) Generate(c *gin.Context) (any, error) {
-	body, err := getPayload(c)
+	body, err := pm.read(c)
 	if err != nil {
 		return nil, err
 	}
@@ -77,7 +152,11 @@ func (pm *PayloadMapper) IsNil() bool {
 
 type QueryMapper struct {
 	keyValue
-	key        string
+	key string
+	// location names the source of the values in the logs and errors
+	location enums.RequestLocation
+	// read returns the query values, or the header values of the key
+	read       func(c *gin.Context, key string) []string
 	index      *int
 	conversion enums.ConversionType
 	logger     logger.Logger
@@ -92,36 +171,51 @@ func newQueryMapper(
 	return &QueryMapper{
 		keyValue:   keyValue{key: responseKey},
 		key:        valueKey,
+		location:   enums.RequestLocations.Query(),
+		read:       (*gin.Context).QueryArray,
 		index:      index,
 		conversion: conversion,
 		logger:     logger,
 	}
 }
 
+// newHeaderMapper reads the values of the request header, like the query mapper reads the query
+func newHeaderMapper(
+	responseKey, valueKey string,
+	index *int,
+	conversion enums.ConversionType,
+	logger logger.Logger,
+) (Valuer, error) {
+	if len(valueKey) == 0 {
+		return nil, errors.Wrapf(ErrEmptyKey, "header name cannot be empty, response key: %s", responseKey)
+	}
+	return &QueryMapper{
+		keyValue: keyValue{key: responseKey},
+		key:      valueKey,
+		location: enums.RequestLocations.Header(),
+		read: func(c *gin.Context, key string) []string {
+			return c.Request.Header.Values(key)
+		},
+		index:      index,
+		conversion: conversion,
+		logger:     logger,
+	}, nil
+}
+
 func (qm *QueryMapper) Generate(c *gin.Context) (any, error) {
-	paramValues := c.QueryArray(qm.key)
-	qm.logger.Infof("query key[%s] value %+v", qm.key, paramValues)
+	paramValues := qm.read(c, qm.key)
+	qm.logger.Infof("%s key[%s] value %+v", qm.location, qm.key, paramValues)
 
 	if len(paramValues) == 0 {
-		return nil, errors.Wrapf(ErrFailedLocatingElement, "no query argument provided for key: %s", qm.key)
+		return nil, errors.Wrapf(ErrFailedLocatingElement, "no %s argument provided for key: %s", qm.location, qm.key)
 	}
 
-	paramValue := paramValues[0]
-	if qm.index != nil {
-		if *qm.index < 0 || *qm.index >= len(paramValues) {
-			return nil, errors.Wrapf(
-				ErrFailedLocatingElement,
-				"index %d out of range for key: %s, array %v",
-				*qm.index,
-				qm.key,
-				paramValues,
-			)
-		}
-		paramValue = paramValues[*qm.index]
+	paramValue, err := selectIndex(paramValues, qm.index, qm.key)
+	if err != nil {
+		return nil, err
 	}
 
 	var value any
-	var err error
 	if qm.conversion != enums.ConversionTypes.None() {
 		value, err = transform(paramValue, qm.conversion)
 		if err != nil {
@@ -136,6 +230,23 @@ func (qm *QueryMapper) Generate(c *gin.Context) (any, error) {
 	return value, nil
 }
 
+// selectIndex picks the element at the index, the first element without the index
+func selectIndex(values []string, index *int, key string) (string, error) {
+	if index == nil {
+		return values[0], nil
+	}
+	if *index < 0 || *index >= len(values) {
+		return "", errors.Wrapf(
+			ErrFailedLocatingElement,
+			"index %d out of range for key: %s, array %v",
+			*index,
+			key,
+			values,
+		)
+	}
+	return values[*index], nil
+}
+
 func (qm *QueryMapper) Type() enums.GenerationType {
 	return enums.GenerationTypes.SingleValue()
 }
@@ -198,14 +309,41 @@ func (um *URLMapper) getSegmentValueFromURL(segmentNumber int, url string) strin
 	return fragments[segmentNumber]
 }
 
+// getPayload caches the body in the context, so many mappers can read it
 func getPayload(c *gin.Context) (any, error) {
 	var body any
-	if err := c.ShouldBindJSON(&body); err != nil {
+	if err := c.ShouldBindBodyWith(&body, binding.JSON); err != nil {
 		return nil, ErrFailedBindingBody
 	}
 	return body, nil
 }
 
+// SetUpstreamBody stores the decoded body of the proxied response for the upstream mappers
+func SetUpstreamBody(c *gin.Context, body any) {
+	c.Set(upstreamBodyKey, body)
+}
+
+func getUpstreamBody(c *gin.Context) (any, error) {
+	body, ok := c.Get(upstreamBodyKey)
+	if !ok {
+		return nil, ErrNoUpstreamBody
+	}
+	return body, nil
+}
+
+// SetResponseBody stores the decoded body of the mocked response for the response mappers
+func SetResponseBody(c *gin.Context, body any) {
+	c.Set(responseBodyKey, body)
+}
+
+func getResponseBody(c *gin.Context) (any, error) {
+	body, ok := c.Get(responseBodyKey)
+	if !ok {
+		return nil, ErrNoResponseBody
+	}
+	return body, nil
+}
+
 func transform(val any, conversion enums.ConversionType) (any, error) {
 	switch conversion {
 	case enums.ConversionTypes.Text():
@@ -124,6 +124,23 @@ func TestPayloadMapper_Generate(t *testing.T) {
 	}
 }
 
+func TestPayloadMapper_GenerateManyFields(t *testing.T) {
+	t.Parallel()
+	fields := make([]values.Valuer, 0, 2)
+	for _, field := range []string{"id", "name"} {
+		mapper, err := values.NewMappedValuer(field, "", "$."+field, "", enums.RequestLocations.Body(), nil,
+			enums.ConversionTypes.None(), logger.NewTestLogger())
+		require.NoError(t, err)
+		fields = append(fields, mapper)
+	}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(`{"id":"7","name":"john"}`))
+
+	value, err := values.NewObjectValuer("", fields).Generate(c)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"id": "7", "name": "john"}, value)
+}
+
 func TestURLMapper_Generate(t *testing.T) {
 	t.Parallel()
 	const servedURL = "http://domain.com/super/page/1234"
@@ -304,6 +321,80 @@ func TestQueryMapper_Generate(t *testing.T) {
 	}
 }
 
+func TestHeaderMapper_Generate(t *testing.T) {
+	t.Parallel()
+	index := 1
+	testCases := []struct {
+		name          string
+		responseKey   string
+		header        string
+		index         *int
+		expected      any
+		expectedError error
+	}{
+		{
+			name:     "header value",
+			header:   "x-user-id",
+			expected: "42",
+		},
+		{
+			name:        "header value with response key",
+			header:      "Authorization",
+			responseKey: "token",
+			expected:    map[string]any{"token": "Bearer abc"},
+		},
+		{
+			name:     "header value at the index",
+			header:   "X-Role",
+			index:    &index,
+			expected: "admin",
+		},
+		{
+			name:          "missing header",
+			header:        "X-Missing",
+			expectedError: values.ErrFailedLocatingElement,
+		},
+		{
+			name:          "empty header name",
+			expectedError: values.ErrEmptyKey,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			subject, err := values.NewMappedValuer(
+				tc.responseKey,
+				tc.header,
+				"",
+				"",
+				enums.RequestLocations.Header(),
+				tc.index,
+				enums.ConversionTypes.None(),
+				logger.NewTestLogger(),
+			)
+			if err != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodGet, "http://domain.com", nil)
+			c.Request.Header.Set("X-User-Id", "42")
+			c.Request.Header.Set("Authorization", "Bearer abc")
+			c.Request.Header.Add("X-Role", "user")
+			c.Request.Header.Add("X-Role", "admin")
+			actual, err := subject.Generate(c)
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
 func TestQueryMapper_IsNill_Type(t *testing.T) {
 	t.Parallel()
 	testCases := []struct {
@@ -337,3 +428,93 @@ func TestQueryMapper_IsNill_Type(t *testing.T) {
 		})
 	}
 }
+
+func TestUpstreamMapper_Generate(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name          string
+		upstream      any
+		path          string
+		expected      any
+		expectedError error
+	}{
+		{
+			name:     "value from upstream body",
+			upstream: map[string]any{"user": map[string]any{"name": "john"}},
+			path:     "$.user.name",
+			expected: map[string]any{"owner": "john"},
+		},
+		{
+			name:          "error without upstream body",
+			path:          "$.user.name",
+			expectedError: values.ErrNoUpstreamBody,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			valuer, err := values.NewMappedValuer(
+				"owner", "", tc.path, "/",
+				enums.RequestLocations.Upstream(), nil, enums.ConversionTypes.None(), logger.NewTestLogger(),
+			)
+			require.NoError(t, err)
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/", strings.NewReader(`{"user":{"name":"request"}}`))
+			if tc.upstream != nil {
+				values.SetUpstreamBody(c, tc.upstream)
+			}
+			value, err := valuer.Generate(c)
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, value)
+		})
+	}
+}
+
+func TestMapsResponse(t *testing.T) {
+	t.Parallel()
+	mapped := func(location enums.RequestLocation) values.Valuer {
+		valuer, err := values.NewMappedValuer("id", "", "$.id", "", location, nil,
+			enums.ConversionTypes.None(), logger.NewTestLogger())
+		require.NoError(t, err)
+		return valuer
+	}
+	testCases := []struct {
+		name     string
+		valuers  []values.Valuer
+		expected bool
+	}{
+		{name: "no valuers"},
+		{name: "nil valuer", valuers: []values.Valuer{nil}},
+		{name: "request body", valuers: []values.Valuer{mapped(enums.RequestLocations.Body())}},
+		{name: "response", valuers: []values.Valuer{mapped(enums.RequestLocations.Response())}, expected: true},
+		{
+			name: "nested in object and array",
+			valuers: []values.Valuer{
+				values.NewStaticValuer("", "static"),
+				values.NewObjectValuer("", []values.Valuer{
+					values.NewArrayValuer("ids", 1, 1, mapped(enums.RequestLocations.Response())),
+				}),
+			},
+			expected: true,
+		},
+		{
+			name: "key of stored value",
+			valuers: []values.Valuer{
+				values.NewStoredValuer("", "sessions", mapped(enums.RequestLocations.Response()), values.NewStore()),
+			},
+			expected: true,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, values.MapsResponse(tc.valuers...))
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package values
+
+import (
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NilValuer ends recursive trees once the allowed depth is reached.
+// Arrays with nil elements are generated empty.
+type NilValuer struct {
+	keyValue
+}
+
+func NewNilValuer(key string) Valuer {
+	return &NilValuer{keyValue: keyValue{key: key}}
+}
+
+func (nv *NilValuer) Generate(_ *gin.Context) (any, error) {
+	if key := nv.keyValue.Key(); key != nil {
+		return map[string]any{*key: nil}, nil
+	}
+	return nil, nil
+}
+
+func (nv *NilValuer) Type() enums.GenerationType {
+	return enums.GenerationTypes.SingleValue()
+}
+
+func (nv *NilValuer) IsNil() bool {
+	return true
+}
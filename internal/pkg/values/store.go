@@ -0,0 +1,105 @@
+package values
+
+import (
+	"sync"
+	"time"
+
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// Store keeps the values saved by the endpoints in memory, the values are grouped by the names of the stores
+type Store interface {
+	// Set saves the value under the key, the value does not expire when ttl is 0
+	Set(name, key string, value any, ttl time.Duration)
+	// Get returns the value of the key which has not expired yet
+	Get(name, key string) (any, bool)
+}
+
+type storedEntry struct {
+	value   any
+	expires time.Time
+}
+
+type store struct {
+	mutex   sync.Mutex
+	entries map[string]map[string]storedEntry
+	now     func() time.Time
+}
+
+func NewStore() Store {
+	return &store{entries: make(map[string]map[string]storedEntry), now: time.Now}
+}
+
+func (s *store) Set(name, key string, value any, ttl time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entries, ok := s.entries[name]
+	if !ok {
+		entries = make(map[string]storedEntry)
+		s.entries[name] = entries
+	}
+	// the expired entries are dropped when the store is written, so it does not grow with the stale values
+	now := s.now()
+	for k, entry := range entries {
+		if entry.expired(now) {
+			delete(entries, k)
+		}
+	}
+	entry := storedEntry{value: value}
+	if ttl > 0 {
+		entry.expires = now.Add(ttl)
+	}
+	entries[key] = entry
+}
+
+func (s *store) Get(name, key string) (any, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entry, ok := s.entries[name][key]
+	if !ok || entry.expired(s.now()) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (e storedEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// StoredValuer reads the value saved in the store under the key generated from the request
+type StoredValuer struct {
+	keyValue
+	store Store
+	name  string
+	key   Valuer
+}
+
+func NewStoredValuer(responseKey, name string, key Valuer, store Store) Valuer {
+	return &StoredValuer{keyValue: keyValue{key: responseKey}, store: store, name: name, key: key}
+}
+
+func (sv *StoredValuer) Generate(c *gin.Context) (any, error) {
+	key, err := GenerateKey(c, sv.key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "key of store %s", sv.name)
+	}
+	value, ok := sv.store.Get(sv.name, key)
+	if !ok {
+		return nil, errors.Wrapf(ErrNotStored, "store %s, key %s", sv.name, key)
+	}
+	if key := sv.keyValue.Key(); key != nil {
+		return map[string]any{*key: value}, nil
+	}
+	return value, nil
+}
+
+func (sv *StoredValuer) Type() enums.GenerationType {
+	return enums.GenerationTypes.SingleValue()
+}
+
+func (sv *StoredValuer) IsNil() bool {
+	return sv == nil
+}
@@ -0,0 +1,102 @@
+package values_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vimek-go/server-faker/internal/pkg/enums"
+	"github.com/vimek-go/server-faker/internal/pkg/logger"
+	"github.com/vimek-go/server-faker/internal/pkg/values"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+	store := values.NewStore()
+	store.Set("sessions", "abc", "john", 0)
+	store.Set("sessions", "short", "jane", 10*time.Millisecond)
+	store.Set("carts", "abc", []any{1, 2}, 0)
+
+	value, ok := store.Get("sessions", "abc")
+	require.True(t, ok)
+	require.Equal(t, "john", value)
+	value, ok = store.Get("carts", "abc")
+	require.True(t, ok)
+	require.Equal(t, []any{1, 2}, value)
+	_, ok = store.Get("sessions", "missing")
+	require.False(t, ok)
+	_, ok = store.Get("unknown", "abc")
+	require.False(t, ok)
+
+	value, ok = store.Get("sessions", "short")
+	require.True(t, ok)
+	require.Equal(t, "jane", value)
+	time.Sleep(20 * time.Millisecond)
+	_, ok = store.Get("sessions", "short")
+	require.False(t, ok)
+}
+
+func TestStoredValuer_Generate(t *testing.T) {
+	t.Parallel()
+	token, err := values.NewMappedValuer("", "X-Token", "", "", enums.RequestLocations.Header(), nil,
+		enums.ConversionTypes.None(), logger.NewTestLogger())
+	require.NoError(t, err)
+	store := values.NewStore()
+	store.Set("sessions", "abc", map[string]any{"name": "john"}, 0)
+	store.Set("settings", "", "dark", 0)
+	testCases := []struct {
+		name          string
+		responseKey   string
+		store         string
+		key           values.Valuer
+		token         string
+		expected      any
+		expectedError error
+	}{
+		{
+			name:        "value of the key",
+			responseKey: "user",
+			store:       "sessions",
+			key:         token,
+			token:       "abc",
+			expected:    map[string]any{"user": map[string]any{"name": "john"}},
+		},
+		{
+			name:     "value without the key",
+			store:    "settings",
+			expected: "dark",
+		},
+		{
+			name:          "not stored",
+			store:         "sessions",
+			key:           token,
+			token:         "other",
+			expectedError: values.ErrNotStored,
+		},
+		{
+			name:          "missing key",
+			store:         "sessions",
+			key:           token,
+			expectedError: values.ErrFailedLocatingElement,
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/me", nil)
+			if len(tc.token) > 0 {
+				c.Request.Header.Set("X-Token", tc.token)
+			}
+			valuer := values.NewStoredValuer(tc.responseKey, tc.store, tc.key, store)
+			value, err := valuer.Generate(c)
+			require.ErrorIs(t, err, tc.expectedError)
+			require.Equal(t, tc.expected, value)
+		})
+	}
+}